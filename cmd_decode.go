@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/erpc-go/jce2go/dynamic"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// jce2go decode -schema test.jce -type test::RequestPacket < packet.bin，按 schema 把 jce 数据转为 JSON
+func runDecode(args []string) {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	schema := fs.String("schema", "", "jce file which defines the type")
+	typeName := fs.String("type", "", "struct to decode, e.g. test::RequestPacket")
+	in := fs.String("in", "raw", "input format: raw, hex or base64")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go decode -schema <file.jce> -type <module::Struct> [-in raw|hex|base64] [file]\n")
+		fmt.Fprintf(os.Stderr, "read from stdin if file is not given\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *schema == "" || *typeName == "" || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	p := parseSchema(*schema)
+
+	data, err := readInput(fs.Arg(0))
+	if err == nil {
+		data, err = decodeInput(data, *in)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	obj, err := dynamic.Decode(p, *typeName, data)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	out, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+}
+
+// 解析 schema 文件，出错直接退出
+func parseSchema(filename string) *parser.Parser {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	p, err := parser.ParseSource(filename, src)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return p
+}
+
+// 读取文件内容，filename 为空或者 - 时读标准输入
+func readInput(filename string) ([]byte, error) {
+	if filename == "" || filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(filename)
+}
+
+// 按输入格式转为二进制，hex、base64 忽略其中的空白字符
+func decodeInput(data []byte, format string) ([]byte, error) {
+	text := strings.Join(strings.Fields(string(data)), "")
+	switch format {
+	case "raw":
+		return data, nil
+	case "hex":
+		return hex.DecodeString(strings.TrimPrefix(text, "0x"))
+	case "base64":
+		if b, err := base64.StdEncoding.DecodeString(text); err == nil {
+			return b, nil
+		}
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(text, "="))
+	}
+	return nil, fmt.Errorf("unknown input format %q, expect raw, hex or base64", format)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/doc"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// jce2go doc [-format md|html] [-o DIR] file.jce...，生成 jce 文件以及它们 include 的文件的接口文档
+func runDoc(args []string) {
+	fs := flag.NewFlagSet("doc", flag.ExitOnError)
+	format := fs.String("format", "md", "output format: md or html")
+	outdir := fs.String("o", "doc", "output directory")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go doc [-format md|html] [-o DIR] <file.jce>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ps, err := parseInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	files, err := doc.Generate(descriptor.Build(ps...), doc.Options{Format: *format})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	written, err := (&plugin.Response{Files: files}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]doc %s\n", f)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/wire"
+)
+
+// jce2go dump < packet.bin，不需要 schema，按 tag、type 输出 jce 数据的结构
+func runDump(args []string) {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	in := fs.String("in", "raw", "input format: raw, hex or base64")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go dump [-in raw|hex|base64] [file]\n")
+		fmt.Fprintf(os.Stderr, "read from stdin if file is not given\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	data, err := readInput(fs.Arg(0))
+	if err == nil {
+		data, err = decodeInput(data, *in)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err = wire.Dump(os.Stdout, data); err != nil {
+		os.Exit(1)
+	}
+}
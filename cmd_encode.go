@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/dynamic"
+)
+
+// jce2go encode -schema test.jce -type test::RequestPacket < packet.json，按 schema 把 JSON 编码为 jce 数据
+func runEncode(args []string) {
+	fs := flag.NewFlagSet("encode", flag.ExitOnError)
+	schema := fs.String("schema", "", "jce file which defines the type")
+	typeName := fs.String("type", "", "struct to encode, e.g. test::RequestPacket")
+	out := fs.String("out", "raw", "output format: raw, hex or base64")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go encode -schema <file.jce> -type <module::Struct> [-out raw|hex|base64] [file.json]\n")
+		fmt.Fprintf(os.Stderr, "read from stdin if file is not given\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *schema == "" || *typeName == "" || fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *out != "raw" && *out != "hex" && *out != "base64" {
+		fmt.Fprintf(os.Stderr, "unknown output format %q, expect raw, hex or base64\n", *out)
+		os.Exit(1)
+	}
+
+	p := parseSchema(*schema)
+
+	input, err := readInput(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	data, err := dynamic.EncodeJSON(p, *typeName, input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch *out {
+	case "hex":
+		fmt.Println(hex.EncodeToString(data))
+	case "base64":
+		fmt.Println(base64.StdEncoding.EncodeToString(data))
+	default:
+		os.Stdout.Write(data)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/jsonschema"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// jce2go export-jsonschema [-o DIR] file.jce...，把 jce 文件以及它们 include 的文件转换为 JSON Schema
+func runExportJSONSchema(args []string) {
+	fs := flag.NewFlagSet("export-jsonschema", flag.ExitOnError)
+	outdir := fs.String("o", ".", "output directory")
+	baseURI := fs.String("base-uri", "", "prefix of $id, e.g. https://example.com/schemas/")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go export-jsonschema [-o DIR] [-base-uri URI] <file.jce>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ps, err := parseInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	files, err := jsonschema.Export(descriptor.Build(ps...), jsonschema.Options{BaseURI: *baseURI})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	written, err := (&plugin.Response{Files: files}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]export-jsonschema %s\n", f)
+	}
+}
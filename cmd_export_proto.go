@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/protobuf"
+)
+
+// jce2go export-proto [-o DIR] file.jce...，把 jce 文件以及它们 include 的文件转换为 proto3
+func runExportProto(args []string) {
+	fs := flag.NewFlagSet("export-proto", flag.ExitOnError)
+	outdir := fs.String("o", ".", "output directory")
+	pkg := fs.String("package", "", "prefix of proto package, e.g. com.foo makes module test package com.foo.test")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go export-proto [-o DIR] [-package PREFIX] <file.jce>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ps, err := parseInputs(fs.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	files, warnings, err := protobuf.Export(descriptor.Build(ps...), protobuf.ExportOptions{Package: *pkg})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+
+	written, err := (&plugin.Response{Files: files}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]export-proto %s\n", f)
+	}
+}
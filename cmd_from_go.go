@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/fromgo"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// jce2go from-go [-o DIR] [-module NAME] ./pkg...，从 go 的 struct 生成 jce 文件
+func runFromGo(args []string) {
+	fs := flag.NewFlagSet("from-go", flag.ExitOnError)
+	outdir := fs.String("o", ".", "output directory")
+	module := fs.String("module", "", "jce module name, default to the go package name")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go from-go [-o DIR] [-module NAME] <dir>...\n")
+		fmt.Fprintf(os.Stderr, "require and default values are recovered from the descriptors registered by jce2go generated code,\n")
+		fmt.Fprintf(os.Stderr, "other structs are converted lossily: all fields are optional without default values.\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || fs.NArg() > 1 && *module != "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var files []plugin.File
+	for _, dir := range fs.Args() {
+		f, err := fromgo.Convert(dir, fromgo.Options{Module: *module})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		files = append(files, f)
+	}
+
+	written, err := (&plugin.Response{Files: files}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]from-go %s\n", f)
+	}
+}
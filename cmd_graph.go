@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/graph"
+)
+
+// jce2go graph [-format dot|mermaid] [-types] [-root TYPE] [-depth N] file.jce...，输出 include 或者类型的依赖图
+func runGraph(args []string) {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "output format: dot or mermaid")
+	types := fs.Bool("types", false, "graph struct and enum references instead of file includes")
+	root := fs.String("root", "", "only show types reachable from this type, e.g. test::RequestPacket (implies -types)")
+	depth := fs.Int("depth", 0, "only show nodes within N levels from the roots, 0 for no limit")
+	out := fs.String("o", "", "output file, default stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go graph [-format dot|mermaid] [-types] [-root TYPE] [-depth N] [-o FILE] <file.jce>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 || (*format != "dot" && *format != "mermaid") {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var g *graph.Graph
+	ps, err := parseInputs(fs.Args())
+	switch {
+	case *types || *root != "":
+		if err == nil {
+			g, err = graph.Types(descriptor.Build(ps...), *root, *depth)
+		}
+	case err == nil:
+		g = graph.Files(descriptor.Build(ps...), *depth)
+	default:
+		// 无法解析（如 include 有环）时只根据 #include 指令建图，仍然可以标出环
+		fmt.Fprintf(os.Stderr, "warning: %v, graph built from #include directives only\n", err)
+		g, err = graph.Includes(fs.Args(), *depth)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, c := range g.Cycles() {
+		fmt.Fprintf(os.Stderr, "warning: cycle: %s\n", strings.Join(c, ", "))
+	}
+	for _, e := range g.Unused() {
+		fmt.Fprintf(os.Stderr, "warning: unused include: %s includes %s\n", e.From, e.To)
+	}
+
+	content := g.DOT()
+	if *format == "mermaid" {
+		content = g.Mermaid()
+	}
+	if *out == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := ioutil.WriteFile(*out, []byte(content), 0o666); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("[ok]graph %s\n", *out)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/protobuf"
+)
+
+// 可以重复指定的参数，如 -I a -I b
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// jce2go import-proto [-o DIR] [-I PATH] file.proto...，把 proto3 文件以及它们 import 的文件转换为 jce
+func runImportProto(args []string) {
+	fs := flag.NewFlagSet("import-proto", flag.ExitOnError)
+	outdir := fs.String("o", ".", "output directory")
+	var paths listFlag
+	fs.Var(&paths, "I", "directory to search for imports, can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go import-proto [-o DIR] [-I PATH]... <file.proto>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var files []plugin.File
+	seen := map[string]bool{}
+	for _, name := range fs.Args() {
+		out, err := protobuf.Import(name, paths)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, f := range out {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				files = append(files, f)
+			}
+		}
+	}
+
+	written, err := (&plugin.Response{Files: files}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]import-proto %s\n", f)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/thrift"
+)
+
+// jce2go import-thrift [-o DIR] [-I PATH] file.thrift...，把 thrift 文件以及它们 include 的文件转换为 jce
+func runImportThrift(args []string) {
+	fs := flag.NewFlagSet("import-thrift", flag.ExitOnError)
+	outdir := fs.String("o", ".", "output directory")
+	var paths listFlag
+	fs.Var(&paths, "I", "directory to search for includes, can be repeated")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go import-thrift [-o DIR] [-I PATH]... <file.thrift>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var files []plugin.File
+	seen := map[string]bool{}
+	for _, name := range fs.Args() {
+		out, warnings, err := thrift.Import(name, paths)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+		}
+		for _, f := range out {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				files = append(files, f)
+			}
+		}
+	}
+
+	written, err := (&plugin.Response{Files: files}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]import-thrift %s\n", f)
+	}
+}
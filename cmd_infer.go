@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/infer"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// jce2go infer *.bin，从多个 jce 二进制样本推断 struct 的定义
+func runInfer(args []string) {
+	fs := flag.NewFlagSet("infer", flag.ExitOnError)
+	in := fs.String("in", "raw", "input format: raw, hex or base64")
+	outdir := fs.String("o", ".", "output directory")
+	module := fs.String("module", "infer", "module name, also the output file name")
+	name := fs.String("name", "Packet", "name of the top level struct")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go infer [-in raw|hex|base64] [-o DIR] [-module NAME] [-name NAME] <sample>...\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var samples []infer.Sample
+	for _, filename := range fs.Args() {
+		data, err := readInput(filename)
+		if err == nil {
+			data, err = decodeInput(data, *in)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, err)
+			os.Exit(1)
+		}
+		samples = append(samples, infer.Sample{Name: filename, Data: data})
+	}
+
+	src, warnings, err := infer.Infer(samples, infer.Options{Module: *module, Name: *name})
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	written, err := (&plugin.Response{Files: []plugin.File{{Name: *module + ".jce", Content: string(src)}}}).Write(*outdir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range written {
+		fmt.Printf("[ok]infer %s\n", f)
+	}
+}
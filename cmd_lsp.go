@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/erpc-go/jce2go/log"
+	"github.com/erpc-go/jce2go/lsp"
+)
+
+// jce2go lsp，通过标准输入输出提供语言服务
+func runLSP(args []string) {
+	fs := flag.NewFlagSet("lsp", flag.ExitOnError)
+	debug := fs.Bool("debug", false, "enable debug log, write to stderr")
+	fs.Bool("stdio", true, "communicate over stdin/stdout, the only supported mode")
+	fs.Parse(args)
+
+	// 标准输出用于和编辑器通信，日志只能写到标准错误
+	log.DefaultLogger.SetOutput(os.Stderr)
+	if *debug {
+		log.DefaultLogger.SetLevel(log.DebugLevel)
+	}
+
+	if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/refactor"
+)
+
+// jce2go rename file.jce:Line:Col NewName，在 include 链中重命名符号
+func runRename(args []string) {
+	fs := flag.NewFlagSet("rename", flag.ExitOnError)
+	dryRun := fs.Bool("n", false, "only print the edits, do not write files")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: jce2go rename [-n] <file.jce:Line:Col> <NewName>\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	filename, line, column, err := parseLocation(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	edits, err := refactor.Rename(filename, line, column, fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *dryRun {
+		for _, e := range edits {
+			fmt.Println(e)
+		}
+		return
+	}
+
+	files, err := refactor.Apply(edits)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, f := range files {
+		fmt.Printf("[ok]rename %s\n", f)
+	}
+}
+
+// 解析 file.jce:Line:Col 格式的位置
+func parseLocation(s string) (filename string, line, column int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 3 {
+		return "", 0, 0, fmt.Errorf("invalid location %q, expect file.jce:Line:Col", s)
+	}
+
+	n := len(parts)
+	if line, err = strconv.Atoi(parts[n-2]); err != nil {
+		return "", 0, 0, fmt.Errorf("invalid line in %q", s)
+	}
+	if column, err = strconv.Atoi(parts[n-1]); err != nil {
+		return "", 0, 0, fmt.Errorf("invalid column in %q", s)
+	}
+	return strings.Join(parts[:n-2], ":"), line, column, nil
+}
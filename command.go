@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// 子命令，如 jce2go lsp
+type command struct {
+	usage string
+	run   func(args []string)
+}
+
+var commands = map[string]*command{
+	"decode":            {usage: "decode jce binary to json with a schema", run: runDecode},
+	"dump":              {usage: "dump jce binary as a tag/type tree without schema", run: runDump},
+	"doc":               {usage: "generate markdown or html documentation", run: runDoc},
+	"encode":            {usage: "encode json to jce binary with a schema", run: runEncode},
+	"export-jsonschema": {usage: "convert jce files to JSON Schema (draft 2020-12)", run: runExportJSONSchema},
+	"from-go":           {usage: "generate jce from go structs with tag:\"N\" struct tags", run: runFromGo},
+	"export-proto":      {usage: "convert jce files to proto3", run: runExportProto},
+	"graph":             {usage: "print include or type dependency graph as dot or mermaid", run: runGraph},
+	"import-proto":      {usage: "convert proto3 files to jce", run: runImportProto},
+	"import-thrift":     {usage: "convert thrift files to jce", run: runImportThrift},
+	"infer":             {usage: "infer a draft jce struct from sample binaries", run: runInfer},
+	"lsp":               {usage: "start language server on stdin/stdout", run: runLSP},
+	"rename":            {usage: "rename a struct, enum or enum member across included files", run: runRename},
+}
+
+// 运行子命令，第一个参数不是子命令时返回 false
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+	cmd.run(args[1:])
+	return true
+}
+
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "supported [COMMAND]:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-18s %s\n", name, commands[name].usage)
+	}
+}
@@ -1,6 +1,9 @@
 // DO NOT EDIT IT.
 // code generated by jce2go v1.0.
 // source: base.jce
+// source hash: sha256:f0b82308e23949fb21f6406556505c32e0451c11c35e096b5a427b5232cc6e90
+
+// model ts
 package base
 
 import (
@@ -15,23 +18,37 @@ var _ = fmt.Errorf
 var _ = io.ReadFull
 var _ = jce.Int1
 
-// enum Code implement
-type Code int32
+// mm
+// mmo
+type EMsgSendType int32
 
+// mm
+// mmoo
+/*dmm*/
 const (
-	CodeSuccess Code = 0
-	CodeError   Code = 1
+	// ooo
+	EMsgSendTypeHhh             EMsgSendType = 0   // mmm  // oo
+	EMsgSendTypeESendTypeOnline EMsgSendType = 199 // test
+	// jjjj;
+	EMsgSendTypeESendTypeOffline EMsgSendType = 88 //ooo
+	// oomm
+	/*
+	   sdf
+	   wer
+	   asdf
+	*/
 )
 
-// const implement
 const (
-	ERPC_VERSION int16 = 0x01
-	TUP_VERSION  int32 = 0x03
+	// const co
+	ERPC_VERSION int16 = 0x01 // hhhh
+	TUP_VERSION  int32 = 0x03 // mm
+	// lll
+	Jj string = "tet" // owd
 )
 
-// Request struct implement
+// test
 type Request struct {
-	B int8 `json:"b" tag:"1"`
 }
 
 func (st *Request) resetDefault() {
@@ -51,11 +68,6 @@ func (st *Request) ReadFrom(r io.Reader) (n int64, err error) {
 		return
 	}
 
-	// [step 1] read B
-	if err = decoder.ReadInt8(&st.B, 1, true); err != nil {
-		return
-	}
-
 	if err = decoder.ReadStructEnd(); err != nil {
 		return
 	}
@@ -69,17 +81,11 @@ func (st *Request) ReadFrom(r io.Reader) (n int64, err error) {
 // WriteTo encode struct to io.Writer
 func (st *Request) WriteTo(w io.Writer) (n int64, err error) {
 	encoder := jce.NewEncoder(w)
-	st.resetDefault()
 
 	if err = encoder.WriteStructBegin(); err != nil {
 		return
 	}
 
-	// [step 1] write B
-	if err = encoder.WriteInt8(st.B, 1); err != nil {
-		return
-	}
-
 	if err = encoder.WriteStructEnd(); err != nil {
 		return
 	}
@@ -7,9 +7,7 @@ import (
 )
 
 func TestRequest(t *testing.T) {
-	req := Request{
-		B: 12,
-	}
+	req := Request{}
 
 	b := bytes.NewBuffer(make([]byte, 0))
 	_, err := req.WriteTo(b)
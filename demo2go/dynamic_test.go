@@ -0,0 +1,72 @@
+package demo2go
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/erpc-go/jce2go/demo2go/base"
+	"github.com/erpc-go/jce2go/demo2go/test"
+	"github.com/erpc-go/jce2go/dynamic"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// jce2go encode 按 schema 编码的结果需要和生成代码的 WriteTo 逐字节相同，包括 tag 18 的结构体成员 req。
+// go 的 map 遍历顺序是随机的，map 最多只有一个元素
+func TestDynamicEncode(t *testing.T) {
+	req := &test.RequestPacket{
+		B:       1,
+		S:       2,
+		I:       3,
+		L:       4,
+		F:       5,
+		D:       6,
+		S1:      "hello",
+		S2:      "test",
+		I2:      99,
+		Buffer1: []int8{1, 2, 3},
+		Buffer2: []uint8{8, 8, 2},
+		Arr1:    []string{"a", "b", "c"},
+		Arr2:    [][]int32{{23, 23}, {2, 1, 8}},
+		M1:      map[string]string{"a": "b"},
+		Arr4:    []map[int32]string{{1: "2"}},
+		Arr3:    []base.Request{{}},
+		M2:      map[string]base.Request{"a": {}},
+	}
+	buf := &bytes.Buffer{}
+	if _, err := req.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join("..", "demo", "test.jce")
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parser.ParseSource(filename, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := dynamic.EncodeJSON(p, "test::RequestPacket", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, buf.Bytes()) {
+		t.Fatalf("encode %s\ngot  %x\nwant %x", in, data, buf.Bytes())
+	}
+
+	// 解码生成代码编码的数据
+	obj, err := dynamic.Decode(p, "test::RequestPacket", buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := dynamic.Encode(p, "test::RequestPacket", obj); err != nil || !bytes.Equal(again, buf.Bytes()) {
+		t.Fatalf("decode and encode again: %v\ngot  %x\nwant %x", err, again, buf.Bytes())
+	}
+}
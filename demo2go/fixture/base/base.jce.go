@@ -0,0 +1,86 @@
+// DO NOT EDIT IT.
+// code generated by jce2go v1.0.
+// source: base.jce
+// source hash: sha256:64c2ba639ce81b3156181218439a36bab0009fd7f77144bf75f6c363e4a26b38
+
+package base
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/erpc-go/jce-codec"
+)
+
+// 占位使用，避免导入的这些包没有被使用
+var _ = fmt.Errorf
+var _ = io.ReadFull
+var _ = jce.Int1
+
+type Item struct {
+	Id   int32  `json:"id" tag:"0"`
+	Name string `json:"name" tag:"1"`
+}
+
+func (st *Item) resetDefault() {
+	st.Name = "none"
+}
+
+// ReadFrom reads from io.Reader and put into struct.
+func (st *Item) ReadFrom(r io.Reader) (n int64, err error) {
+	var (
+		have bool
+		ty   jce.JceEncodeType
+	)
+
+	decoder := jce.NewDecoder(r)
+	st.resetDefault()
+
+	if err = decoder.ReadStructBegin(); err != nil {
+		return
+	}
+
+	// [step 0] read Id
+	if err = decoder.ReadInt32(&st.Id, 0, true); err != nil {
+		return
+	}
+	// [step 1] read Name
+	if err = decoder.ReadString(&st.Name, 1, false); err != nil {
+		return
+	}
+
+	if err = decoder.ReadStructEnd(); err != nil {
+		return
+	}
+
+	_ = err
+	_ = have
+	_ = ty
+	return
+}
+
+// WriteTo encode struct to io.Writer
+func (st *Item) WriteTo(w io.Writer) (n int64, err error) {
+	encoder := jce.NewEncoder(w)
+
+	if err = encoder.WriteStructBegin(); err != nil {
+		return
+	}
+
+	// [step 0] write Id
+	if err = encoder.WriteInt32(st.Id, 0); err != nil {
+		return
+	}
+	// [step 1] write Name
+	if err = encoder.WriteString(st.Name, 1); err != nil {
+		return
+	}
+
+	if err = encoder.WriteStructEnd(); err != nil {
+		return
+	}
+
+	// flush to io.Writer
+	err = encoder.Flush()
+	return
+}
@@ -0,0 +1,397 @@
+// DO NOT EDIT IT.
+// code generated by jce2go v1.0.
+// source: test.jce
+// source hash: sha256:9d9c1fee3bdb3e864593e5e388be63371fa1bc962d7d42986d033c93ca68fbe6
+
+package test
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/erpc-go/jce-codec"
+	"github.com/erpc-go/jce2go/demo2go/fixture/base"
+)
+
+// 占位使用，避免导入的这些包没有被使用
+var _ = fmt.Errorf
+var _ = io.ReadFull
+var _ = jce.Int1
+
+type Color int32
+
+const (
+	ColorRed   Color = 1
+	ColorGreen Color = 2
+)
+
+const (
+	VERSION int16  = 3
+	NAME    string = "test"
+)
+
+type Packet struct {
+	B       int8                `json:"b" tag:"0"`
+	S       string              `json:"s" tag:"1"`
+	C       Color               `json:"c" tag:"2"`
+	Raw     []uint8             `json:"raw" tag:"3"`
+	Items   []base.Item         `json:"items" tag:"4"`
+	M       map[string]int32    `json:"m" tag:"5"`
+	F       float32             `json:"f" tag:"6"`
+	D       float64             `json:"d" tag:"7"`
+	L       int64               `json:"l" tag:"8"`
+	Ok      bool                `json:"ok" tag:"9"`
+	Us      uint16              `json:"us" tag:"10"`
+	Nested  [][]string          `json:"nested" tag:"11"`
+	ById    map[int32]base.Item `json:"byId" tag:"12"`
+	Item    base.Item           `json:"item" tag:"13"`
+	Default string              `json:"default" tag:"15"`
+	From    string              `json:"from" tag:"16"`
+	Big     string              `json:"big" tag:"20"`
+}
+
+func (st *Packet) resetDefault() {
+	st.C = ColorGreen
+	st.F = 1
+}
+
+// ReadFrom reads from io.Reader and put into struct.
+func (st *Packet) ReadFrom(r io.Reader) (n int64, err error) {
+	var (
+		have bool
+		ty   jce.JceEncodeType
+	)
+
+	decoder := jce.NewDecoder(r)
+	st.resetDefault()
+
+	if err = decoder.ReadStructBegin(); err != nil {
+		return
+	}
+
+	// [step 0] read B
+	if err = decoder.ReadInt8(&st.B, 0, true); err != nil {
+		return
+	}
+	// [step 1] read S
+	if err = decoder.ReadString(&st.S, 1, true); err != nil {
+		return
+	}
+	// [step 2] read C
+	if err = decoder.ReadInt32((*int32)(&st.C), 2, false); err != nil {
+		return
+	}
+	// [step 3] read Raw
+	if err = decoder.ReadSliceUint8(&st.Raw, 3, false); err != nil {
+		return
+	}
+	// [step 4] read Items
+	var length1 uint32
+
+	// [step 4.1] read type、tag
+	if ty, have, err = decoder.ReadHead(4, false); err != nil || !have {
+		return
+	}
+	// [step 4.2] read list length
+	if length1, err = decoder.ReadLength(); err != nil {
+		return
+	}
+	// [step 4.3] read data
+	st.Items = make([]base.Item, length1)
+	for i1 := uint32(0); i1 < length1; i1++ {
+		// [step 0] read Items[i1]
+		if _, err = st.Items[i1].ReadFrom(decoder.Reader()); err != nil {
+			return
+		}
+
+	}
+	// [step 5] read M
+	var length2 uint32
+
+	// [step 5.1] read type、tag
+	if ty, have, err = decoder.ReadHead(5, false); err != nil {
+		return
+	}
+	// [step 5.2] read length
+	if length2, err = decoder.ReadLength(); err != nil {
+		return
+	}
+	// [step 5.3] read data
+	st.M = make(map[string]int32, 0)
+	var k2 string
+	var v2 int32
+	for i := uint32(0); i < length2; i++ {
+		// [step 0] read k2
+		if err = decoder.ReadString(&k2, 0, false); err != nil {
+			return
+		}
+		// [step 1] read v2
+		if err = decoder.ReadInt32(&v2, 1, false); err != nil {
+			return
+		}
+
+		st.M[k2] = v2
+	}
+	// [step 6] read F
+	if err = decoder.ReadFloat32(&st.F, 6, false); err != nil {
+		return
+	}
+	// [step 7] read D
+	if err = decoder.ReadFloat64(&st.D, 7, false); err != nil {
+		return
+	}
+	// [step 8] read L
+	if err = decoder.ReadInt64(&st.L, 8, false); err != nil {
+		return
+	}
+	// [step 9] read Ok
+	if err = decoder.ReadBool(&st.Ok, 9, false); err != nil {
+		return
+	}
+	// [step 10] read Us
+	if err = decoder.ReadUint16(&st.Us, 10, false); err != nil {
+		return
+	}
+	// [step 11] read Nested
+	var length3 uint32
+
+	// [step 11.1] read type、tag
+	if ty, have, err = decoder.ReadHead(11, false); err != nil || !have {
+		return
+	}
+	// [step 11.2] read list length
+	if length3, err = decoder.ReadLength(); err != nil {
+		return
+	}
+	// [step 11.3] read data
+	st.Nested = make([][]string, length3)
+	for i3 := uint32(0); i3 < length3; i3++ {
+		// [step 0] read Nested[i3]
+		var length4 uint32
+
+		// [step 0.1] read type、tag
+		if ty, have, err = decoder.ReadHead(0, false); err != nil || !have {
+			return
+		}
+		// [step 0.2] read list length
+		if length4, err = decoder.ReadLength(); err != nil {
+			return
+		}
+		// [step 0.3] read data
+		st.Nested[i3] = make([]string, length4)
+		for i4 := uint32(0); i4 < length4; i4++ {
+			// [step 0] read Nested[i3][i4]
+			if err = decoder.ReadString(&st.Nested[i3][i4], 0, false); err != nil {
+				return
+			}
+
+		}
+
+	}
+	// [step 12] read ById
+	var length5 uint32
+
+	// [step 12.1] read type、tag
+	if ty, have, err = decoder.ReadHead(12, false); err != nil {
+		return
+	}
+	// [step 12.2] read length
+	if length5, err = decoder.ReadLength(); err != nil {
+		return
+	}
+	// [step 12.3] read data
+	st.ById = make(map[int32]base.Item, 0)
+	var k5 int32
+	var v5 base.Item
+	for i := uint32(0); i < length5; i++ {
+		// [step 0] read k5
+		if err = decoder.ReadInt32(&k5, 0, false); err != nil {
+			return
+		}
+		// [step 1] read v5
+		if _, err = v5.ReadFrom(decoder.Reader()); err != nil {
+			return
+		}
+
+		st.ById[k5] = v5
+	}
+	// [step 13] read Item
+	if _, err = st.Item.ReadFrom(decoder.Reader()); err != nil {
+		return
+	}
+	// [step 15] read Default
+	if err = decoder.ReadString(&st.Default, 15, false); err != nil {
+		return
+	}
+	// [step 16] read From
+	if err = decoder.ReadString(&st.From, 16, false); err != nil {
+		return
+	}
+	// [step 20] read Big
+	if err = decoder.ReadString(&st.Big, 20, false); err != nil {
+		return
+	}
+
+	if err = decoder.ReadStructEnd(); err != nil {
+		return
+	}
+
+	_ = err
+	_ = have
+	_ = ty
+	return
+}
+
+// WriteTo encode struct to io.Writer
+func (st *Packet) WriteTo(w io.Writer) (n int64, err error) {
+	encoder := jce.NewEncoder(w)
+
+	if err = encoder.WriteStructBegin(); err != nil {
+		return
+	}
+
+	// [step 0] write B
+	if err = encoder.WriteInt8(st.B, 0); err != nil {
+		return
+	}
+	// [step 1] write S
+	if err = encoder.WriteString(st.S, 1); err != nil {
+		return
+	}
+	// [step 2] write C
+	if err = encoder.WriteInt32(int32(st.C), 2); err != nil {
+		return
+	}
+	// [step 3] write Raw
+	if err = encoder.WriteSliceUint8(st.Raw, 3); err != nil {
+		return
+	}
+	// [step 4] write Items
+	// [step 4.1] write type、tag
+	if err = encoder.WriteHead(jce.List, 4); err != nil {
+		return
+	}
+	// [step 4.2] write list length
+	if err = encoder.WriteLength(uint32(len(st.Items))); err != nil {
+		return
+	}
+	// [step 4.3] write data
+	for _, v7 := range st.Items {
+		// [step 0] write v7
+		if _, err = v7.WriteTo(encoder.Writer()); err != nil {
+			return
+		}
+	}
+	// [step 5] write M
+	// [step 5.1] write type、tag
+	if err = encoder.WriteHead(jce.Map, 5); err != nil {
+		return
+	}
+	// [step 5.2] write length
+	if err = encoder.WriteLength(uint32(len(st.M))); err != nil {
+		return
+	}
+	// [step 5.3] write data
+	for k8, v8 := range st.M {
+		// [step 0] write k8
+		if err = encoder.WriteString(k8, 0); err != nil {
+			return
+		}
+		// [step 1] write v8
+		if err = encoder.WriteInt32(v8, 1); err != nil {
+			return
+		}
+	}
+	// [step 6] write F
+	if err = encoder.WriteFloat32(st.F, 6); err != nil {
+		return
+	}
+	// [step 7] write D
+	if err = encoder.WriteFloat64(st.D, 7); err != nil {
+		return
+	}
+	// [step 8] write L
+	if err = encoder.WriteInt64(st.L, 8); err != nil {
+		return
+	}
+	// [step 9] write Ok
+	if err = encoder.WriteBool(st.Ok, 9); err != nil {
+		return
+	}
+	// [step 10] write Us
+	if err = encoder.WriteUint16(st.Us, 10); err != nil {
+		return
+	}
+	// [step 11] write Nested
+	// [step 11.1] write type、tag
+	if err = encoder.WriteHead(jce.List, 11); err != nil {
+		return
+	}
+	// [step 11.2] write list length
+	if err = encoder.WriteLength(uint32(len(st.Nested))); err != nil {
+		return
+	}
+	// [step 11.3] write data
+	for _, v9 := range st.Nested {
+		// [step 0] write v9
+		// [step 0.1] write type、tag
+		if err = encoder.WriteHead(jce.List, 0); err != nil {
+			return
+		}
+		// [step 0.2] write list length
+		if err = encoder.WriteLength(uint32(len(v9))); err != nil {
+			return
+		}
+		// [step 0.3] write data
+		for _, v10 := range v9 {
+			// [step 0] write v10
+			if err = encoder.WriteString(v10, 0); err != nil {
+				return
+			}
+		}
+	}
+	// [step 12] write ById
+	// [step 12.1] write type、tag
+	if err = encoder.WriteHead(jce.Map, 12); err != nil {
+		return
+	}
+	// [step 12.2] write length
+	if err = encoder.WriteLength(uint32(len(st.ById))); err != nil {
+		return
+	}
+	// [step 12.3] write data
+	for k11, v11 := range st.ById {
+		// [step 0] write k11
+		if err = encoder.WriteInt32(k11, 0); err != nil {
+			return
+		}
+		// [step 1] write v11
+		if _, err = v11.WriteTo(encoder.Writer()); err != nil {
+			return
+		}
+	}
+	// [step 13] write Item
+	if _, err = st.Item.WriteTo(encoder.Writer()); err != nil {
+		return
+	}
+	// [step 15] write Default
+	if err = encoder.WriteString(st.Default, 15); err != nil {
+		return
+	}
+	// [step 16] write From
+	if err = encoder.WriteString(st.From, 16); err != nil {
+		return
+	}
+	// [step 20] write Big
+	if err = encoder.WriteString(st.Big, 20); err != nil {
+		return
+	}
+
+	if err = encoder.WriteStructEnd(); err != nil {
+		return
+	}
+
+	// flush to io.Writer
+	err = encoder.Flush()
+	return
+}
@@ -0,0 +1,78 @@
+package demo2go
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/demo2go/fixture/base"
+	"github.com/erpc-go/jce2go/demo2go/fixture/test"
+	"github.com/erpc-go/jce2go/lang/langtest"
+)
+
+var update = flag.Bool("update", false, "rewrite lang/testdata/fixtures.hex")
+
+// lang/testdata/fixtures.hex 中的数据，各语言生成器的测试解码后重新编码，结果需要和这里相同。
+// go 的 map 遍历顺序是随机的，map 最多只有一个元素，保证编码结果固定
+var fixtures = []struct {
+	comment string
+	value   test.Packet
+}{
+	{
+		comment: `require 成员和默认值，f 为 0`,
+		value:   test.Packet{B: -3, S: "hi", C: test.ColorGreen},
+	},
+	{
+		comment: `所有的类型，items[0].name 为默认值，item 为结构体成员，big 的长度超过 255`,
+		value: test.Packet{
+			B:       127,
+			S:       "你好",
+			C:       test.ColorRed,
+			Raw:     []uint8{1, 2, 3},
+			Items:   []base.Item{{Id: 1, Name: "none"}, {Id: 70000, Name: "x"}},
+			M:       map[string]int32{"b": -40000},
+			F:       1.5,
+			D:       -2.25,
+			L:       1 << 40,
+			Ok:      true,
+			Us:      65535,
+			Nested:  [][]string{{"a", "b"}, {}},
+			ById:    map[int32]base.Item{7: {Id: 7, Name: "seven"}},
+			Item:    base.Item{Id: 13, Name: "item"},
+			Default: "y",
+			From:    "z",
+			Big:     strings.Repeat("x", 300),
+		},
+	},
+}
+
+// 使用生成的 go 代码编码 fixtures，和 lang/testdata/fixtures.hex 中的数据比较，-update 时重新生成
+func TestLangFixtures(t *testing.T) {
+	out := &bytes.Buffer{}
+	out.WriteString("# 生成的 go 代码编码的 test::Packet，由 demo2go/fixture_test.go 生成，不要手动修改\n")
+	var got []string
+	for i := range fixtures {
+		buf := &bytes.Buffer{}
+		if _, err := fixtures[i].value.WriteTo(buf); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, hex.EncodeToString(buf.Bytes()))
+		out.WriteString("\n# " + fixtures[i].comment + "\n")
+		out.WriteString(got[i] + "\n")
+	}
+
+	filename := filepath.Join(langtest.Dir(), "fixtures.hex")
+	if *update {
+		if err := ioutil.WriteFile(filename, out.Bytes(), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	if strings.Join(got, "\n") != strings.Join(langtest.Fixtures(t), "\n") {
+		t.Errorf("%s is out of date, run go test ./demo2go -run TestLangFixtures -update:\n%s", filename, out.Bytes())
+	}
+}
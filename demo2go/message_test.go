@@ -36,14 +36,10 @@ func TestMessage(t *testing.T) {
 			},
 		},
 		Arr3: []base.Request{
-			{
-				B: 2,
-			},
+			{},
 		},
 		M2: map[string]base.Request{
-			"a": {
-				B: 88,
-			},
+			"a": {},
 		},
 	}
 
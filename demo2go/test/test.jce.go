@@ -1,8 +1,13 @@
 // DO NOT EDIT IT.
 // code generated by jce2go v1.0.
 // source: test.jce
+// source hash: sha256:57c9b0be3b7d6f7d185e8b0cf722ed899d1322b1d115fd578872be73747e16ab
+
+// hhhhhhhhhhhhhhhh
 package test
 
+// iii
+
 import (
 	"fmt"
 	"io"
@@ -16,22 +21,24 @@ var _ = fmt.Errorf
 var _ = io.ReadFull
 var _ = jce.Int1
 
-// RequestPacket struct implement
+// test
 type RequestPacket struct {
-	B       int8                    `json:"b" tag:"1"`
-	S       int16                   `json:"s" tag:"2"`
-	I       int32                   `json:"i" tag:"3"`
-	L       int64                   `json:"l" tag:"4"`
-	F       float32                 `json:"f" tag:"5"`
-	D       float64                 `json:"d" tag:"6"`
-	S1      string                  `json:"s1" tag:"7"`
-	S2      string                  `json:"s2" tag:"8"`
-	I2      int32                   `json:"i2" tag:"9"`
+	// jjjjl
+	B  int8    `json:"b" tag:"1"` //oo
+	S  int16   `json:"s" tag:"2"`
+	I  int32   `json:"i" tag:"3"`
+	L  int64   `json:"l" tag:"4"`
+	F  float32 `json:"f" tag:"5"`
+	D  float64 `json:"d" tag:"6"`
+	S1 string  `json:"s1" tag:"7"`
+	S2 string  `json:"s2" tag:"8"`
+	I2 int32   `json:"i2" tag:"9"`
+	/*sdf*/
 	Buffer1 []int8                  `json:"buffer1" tag:"10"`
 	Buffer2 []uint8                 `json:"buffer2" tag:"11"`
 	Arr1    []string                `json:"arr1" tag:"12"`
 	Arr2    [][]int32               `json:"arr2" tag:"13"`
-	M1      map[string]string       `json:"m1" tag:"14"`
+	M1      map[string]string       `json:"m1" tag:"14"` //ooo
 	Arr4    []map[int32]string      `json:"arr4" tag:"15"`
 	Arr3    []base.Request          `json:"arr3" tag:"16"`
 	M2      map[string]base.Request `json:"m2" tag:"17"`
@@ -291,7 +298,6 @@ func (st *RequestPacket) ReadFrom(r io.Reader) (n int64, err error) {
 // WriteTo encode struct to io.Writer
 func (st *RequestPacket) WriteTo(w io.Writer) (n int64, err error) {
 	encoder := jce.NewEncoder(w)
-	st.resetDefault()
 
 	if err = encoder.WriteStructBegin(); err != nil {
 		return
@@ -35,14 +35,10 @@ func TestRequestPacket(t *testing.T) {
 			},
 		},
 		Arr3: []base.Request{
-			{
-				B: 2,
-			},
+			{},
 		},
 		M2: map[string]base.Request{
-			"a": {
-				B: 88,
-			},
+			"a": {},
 		},
 	}
 
@@ -0,0 +1,280 @@
+// Package descriptor 把语法分析树转换为完整解析后的 schema 描述，供非 go 的工具使用
+//
+// 描述的结构见 descriptor.jce，可以输出为 json 或者自描述的 jce 格式
+package descriptor
+
+import (
+	_ "embed"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/erpc-go/jce2go/dynamic"
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/utils"
+	"github.com/erpc-go/jce2go/wire"
+)
+
+// Schema 描述本身的 jce 定义
+//
+//go:embed descriptor.jce
+var Schema string
+
+// SchemaName 输出时使用的文件名
+const SchemaName = "descriptor.jce"
+
+// Type 类型
+type Type struct {
+	Kind       string  `json:"kind"`
+	IsUnsigned bool    `json:"isUnsigned,omitempty"`
+	Name       string  `json:"name,omitempty"`
+	Params     []*Type `json:"params,omitempty"`
+	Len        int     `json:"len,omitempty"`
+}
+
+// Member struct 成员
+type Member struct {
+	Tag      int32  `json:"tag"`
+	Name     string `json:"name"`
+	Type     *Type  `json:"type"`
+	Required bool   `json:"required,omitempty"`
+	Default  string `json:"default,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Struct 结构体
+type Struct struct {
+	Name    string    `json:"name"`
+	Members []*Member `json:"members,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+// EnumValue 枚举成员
+type EnumValue struct {
+	Name    string `json:"name"`
+	Value   int32  `json:"value"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Enum 枚举
+type Enum struct {
+	Name    string       `json:"name"`
+	Values  []*EnumValue `json:"values,omitempty"`
+	Comment string       `json:"comment,omitempty"`
+}
+
+// Const 常量
+type Const struct {
+	Name    string `json:"name"`
+	Type    *Type  `json:"type"`
+	Value   string `json:"value"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Arg 接口方法的参数
+type Arg struct {
+	Name  string `json:"name"`
+	Type  *Type  `json:"type"`
+	IsOut bool   `json:"isOut,omitempty"`
+}
+
+// Method 接口方法
+type Method struct {
+	Name    string `json:"name"`
+	Return  *Type  `json:"return"` // void 时 kind 为 void
+	Args    []*Arg `json:"args,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// Interface 接口
+type Interface struct {
+	Name    string    `json:"name"`
+	Methods []*Method `json:"methods,omitempty"`
+	Comment string    `json:"comment,omitempty"`
+}
+
+// File 一个 jce 文件
+type File struct {
+	Name       string       `json:"name"`
+	Module     string       `json:"moduleName"`
+	Includes   []string     `json:"includes,omitempty"`
+	Enums      []*Enum      `json:"enums,omitempty"`
+	Consts     []*Const     `json:"consts,omitempty"`
+	Structs    []*Struct    `json:"structs,omitempty"`
+	Interfaces []*Interface `json:"interfaces,omitempty"`
+}
+
+// Set 输入文件以及它们 include 的所有文件
+type Set struct {
+	Files []*File `json:"files,omitempty"`
+}
+
+// Build 从语法分析树构建描述，被 include 的文件在前，同一个文件只出现一次
+func Build(ps ...*parser.Parser) *Set {
+	set := &Set{}
+	seen := map[string]bool{}
+
+	var visit func(p *parser.Parser)
+	visit = func(p *parser.Parser) {
+		name := filepath.Clean(p.Filepath)
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+
+		for _, inc := range p.IncParse {
+			visit(inc)
+		}
+		set.Files = append(set.Files, buildFile(p, name))
+	}
+	for _, p := range ps {
+		visit(p)
+	}
+	return set
+}
+
+func buildFile(p *parser.Parser, name string) *File {
+	f := &File{Name: name, Module: p.Module}
+	for _, inc := range p.IncParse {
+		f.Includes = append(f.Includes, filepath.Clean(inc.Filepath))
+	}
+
+	for _, en := range p.Enums {
+		values := en.Values()
+		e := &Enum{Name: en.Name, Comment: utils.TrimComment(en.TypeComment + en.Comment)}
+		for _, mb := range en.Member {
+			if mb.Type == parser.EnumTypeComment {
+				continue
+			}
+			e.Values = append(e.Values, &EnumValue{Name: mb.Key, Value: values[mb.Key], Comment: utils.TrimComment(mb.Comment)})
+		}
+		f.Enums = append(f.Enums, e)
+	}
+
+	for _, cst := range p.Consts {
+		f.Consts = append(f.Consts, &Const{
+			Name:    cst.Name,
+			Type:    buildType(p, cst.Type),
+			Value:   cst.Value,
+			Comment: utils.TrimComment(cst.PreComment + cst.Comment),
+		})
+	}
+
+	for _, st := range p.Structs {
+		s := &Struct{Name: st.Name, Comment: utils.TrimComment(st.Comment)}
+		for _, mb := range st.Member {
+			if mb.CommentType != "" {
+				continue
+			}
+			s.Members = append(s.Members, &Member{
+				Tag:      mb.Tag,
+				Name:     mb.Key,
+				Type:     buildType(p, mb.Type),
+				Required: mb.Require,
+				Default:  mb.OriginDefault,
+				Comment:  utils.TrimComment(mb.Comment),
+			})
+		}
+		f.Structs = append(f.Structs, s)
+	}
+
+	for _, itf := range p.Interfaces {
+		in := &Interface{Name: itf.Name, Comment: utils.TrimComment(itf.Comment)}
+		for _, fun := range itf.Funcs {
+			m := &Method{Name: fun.Name, Return: &Type{Kind: "void"}, Comment: utils.TrimComment(fun.Comment)}
+			if fun.RetType != nil {
+				m.Return = buildType(p, fun.RetType)
+			}
+			for _, arg := range fun.Args {
+				m.Args = append(m.Args, &Arg{Name: arg.Name, Type: buildType(p, arg.Type), IsOut: arg.IsOut})
+			}
+			in.Methods = append(in.Methods, m)
+		}
+		f.Interfaces = append(f.Interfaces, in)
+	}
+	return f
+}
+
+// buildType 转换类型，struct、enum 使用全名
+func buildType(p *parser.Parser, t *parser.VarType) *Type {
+	switch t.Type {
+	case lex.TkTVector:
+		return &Type{Kind: "vector", Params: []*Type{buildType(p, t.TypeK)}}
+	case lex.TkTArray:
+		return &Type{Kind: "array", Params: []*Type{buildType(p, t.TypeK)}, Len: int(t.TypeL)}
+	case lex.TkTMap:
+		return &Type{Kind: "map", Params: []*Type{buildType(p, t.TypeK), buildType(p, t.TypeV)}}
+	case lex.TkName:
+		kind := "struct"
+		if t.CType == lex.TkEnum {
+			kind = "enum"
+		}
+		name := t.TypeSt
+		if !strings.Contains(name, "::") {
+			name = p.Module + "::" + name
+		}
+		return &Type{Kind: kind, Name: strings.Replace(name, "::", ".", 1)}
+	}
+	return &Type{Kind: lex.TokenMap[t.Type], IsUnsigned: t.Unsigned}
+}
+
+// schema 描述本身的语法分析树
+func schemaParser() (*parser.Parser, error) {
+	return parser.ParseSource(SchemaName, []byte(Schema))
+}
+
+// MarshalJCE 输出自描述的 jce 格式，即 SelfDescribing 结构体
+func (s *Set) MarshalJCE() ([]byte, error) {
+	p, err := schemaParser()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := dynamic.EncodeJSON(p, "descriptor::DescriptorSet", data)
+	if err != nil {
+		return nil, err
+	}
+
+	w := wire.NewWriter()
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteString(Schema, 0)
+	w.WriteString("descriptor.DescriptorSet", 1)
+	w.WriteSimpleList(payload, 2)
+	w.WriteHead(wire.StructEnd, 0)
+	return w.Bytes(), nil
+}
+
+// UnmarshalJCE 读取 MarshalJCE 的输出，payload 按其中携带的 schema 解析
+func UnmarshalJCE(data []byte) (*Set, error) {
+	p, err := schemaParser()
+	if err != nil {
+		return nil, err
+	}
+	outer, err := dynamic.Decode(p, "descriptor::SelfDescribing", data)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, _ := outer.Get("schema")
+	typeName, _ := outer.Get("type")
+	payload, _ := outer.Get("payload")
+	if p, err = parser.ParseSource(SchemaName, []byte(schema.(string))); err != nil {
+		return nil, err
+	}
+	obj, err := dynamic.Decode(p, typeName.(string), payload.([]byte))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	set := &Set{}
+	return set, json.Unmarshal(js, set)
+}
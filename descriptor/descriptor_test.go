@@ -0,0 +1,98 @@
+package descriptor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/erpc-go/jce2go/parser"
+)
+
+func TestBuild(t *testing.T) {
+	src, err := ioutil.ReadFile("../demo/test.jce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parser.ParseSource("../demo/test.jce", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := Build(p, p.IncParse[0])
+	if len(set.Files) != 2 || set.Files[0].Module != "base" || set.Files[1].Module != "test" {
+		t.Fatalf("unexpected files %+v", set.Files)
+	}
+
+	base := set.Files[0]
+	if v := base.Enums[0].Values; v[0].Value != 0 || v[2].Name != "eSendTypeOffline" || v[2].Value != 88 {
+		t.Errorf("unexpected enum values %+v %+v %+v", v[0], v[1], v[2])
+	}
+	m2 := set.Files[1].Structs[0].Members[16]
+	if m2.Name != "m2" || m2.Type.Kind != "map" || m2.Type.Params[1].Kind != "struct" || m2.Type.Params[1].Name != "base.request" {
+		t.Errorf("unexpected member %+v", m2)
+	}
+
+	data, err := set.MarshalJCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := UnmarshalJCE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := json.Marshal(set)
+	b, _ := json.Marshal(again)
+	if string(a) != string(b) {
+		t.Fatalf("jce round trip mismatch\n%s\n%s", a, b)
+	}
+}
+
+func TestBuildInterface(t *testing.T) {
+	src := `module test
+{
+    struct Req
+    {
+        0 require string name;
+    };
+
+    // 服务
+    interface Service
+    {
+        // 发送
+        int send(Req req, out string rsp);
+        void ping();
+    };
+};
+`
+	p, err := parser.ParseSource("test.jce", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := Build(p)
+	itf := set.Files[0].Interfaces
+	if len(itf) != 1 || itf[0].Name != "Service" || itf[0].Comment != "服务" || len(itf[0].Methods) != 2 {
+		t.Fatalf("unexpected interfaces %+v", itf)
+	}
+	send, ping := itf[0].Methods[0], itf[0].Methods[1]
+	if send.Comment != "发送" || send.Return.Kind != "int" || len(send.Args) != 2 ||
+		send.Args[0].Type.Name != "test.Req" || send.Args[0].IsOut || !send.Args[1].IsOut {
+		t.Errorf("unexpected method %+v", send)
+	}
+	if ping.Return.Kind != "void" || len(ping.Args) != 0 {
+		t.Errorf("unexpected method %+v", ping)
+	}
+
+	data, err := set.MarshalJCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := UnmarshalJCE(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := json.Marshal(set)
+	b, _ := json.Marshal(again)
+	if string(a) != string(b) {
+		t.Fatalf("jce round trip mismatch\n%s\n%s", a, b)
+	}
+}
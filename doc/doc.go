@@ -0,0 +1,202 @@
+// Package doc 从完整解析后的 schema 生成 Markdown 或 HTML 的接口文档
+//
+// 每个 module 生成一个页面（同一个 module 分布在多个文件时合并），包括：
+//
+//	enum        成员名和计算后的取值
+//	const       类型和取值
+//	struct      成员的 tag、require、类型、默认值和注释
+//	interface   方法的返回值和参数
+//
+// 另外生成列出所有 module 的 index 页面。引用的 struct、enum 链接到定义所在的页面，
+// 页面不引用任何外部资源，可以离线浏览
+package doc
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Options 生成的参数
+type Options struct {
+	Format string // md 或者 html，默认为 md
+}
+
+// Page 一个 module 的页面
+type Page struct {
+	Module     string
+	Files      []string // 定义 module 的 jce 文件
+	Deps       []string // include 的文件所在的 module
+	Enums      []*descriptor.Enum
+	Consts     []*descriptor.Const
+	Structs    []*descriptor.Struct
+	Interfaces []*descriptor.Interface
+}
+
+// Generate 生成 set 中所有 module 的文档
+func Generate(set *descriptor.Set, opts Options) ([]plugin.File, error) {
+	if opts.Format == "" {
+		opts.Format = "md"
+	}
+	if opts.Format != "md" && opts.Format != "html" {
+		return nil, fmt.Errorf("unknown format %s, expect md or html", opts.Format)
+	}
+
+	pages := buildPages(set)
+	var files []plugin.File
+	for _, p := range pages {
+		if p.Module == "index" {
+			return nil, fmt.Errorf("module index conflicts with the index page")
+		}
+		content, err := render(opts.Format, "module", p.Module, p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, plugin.File{Name: p.Module + "." + opts.Format, Content: content})
+	}
+	content, err := render(opts.Format, "index", "", pages)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, plugin.File{Name: "index." + opts.Format, Content: content}), nil
+}
+
+// 按 module 合并文件，保持 set 中的顺序（被 include 的在前）
+func buildPages(set *descriptor.Set) []*Page {
+	modules := map[string]string{}
+	for _, f := range set.Files {
+		modules[f.Name] = f.Module
+	}
+
+	var pages []*Page
+	byModule := map[string]*Page{}
+	for _, f := range set.Files {
+		p := byModule[f.Module]
+		if p == nil {
+			p = &Page{Module: f.Module}
+			byModule[f.Module] = p
+			pages = append(pages, p)
+		}
+		p.Files = append(p.Files, filepath.ToSlash(f.Name))
+		for _, inc := range f.Includes {
+			if m, ok := modules[inc]; ok && m != p.Module && !contains(p.Deps, m) {
+				p.Deps = append(p.Deps, m)
+			}
+		}
+		p.Enums = append(p.Enums, f.Enums...)
+		p.Consts = append(p.Consts, f.Consts...)
+		p.Structs = append(p.Structs, f.Structs...)
+		p.Interfaces = append(p.Interfaces, f.Interfaces...)
+	}
+	return pages
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// 执行 templates/<name>.<format>.tmpl，cur 为当前页面的 module，用于生成链接
+func render(format, name, cur string, data interface{}) (string, error) {
+	file := name + "." + format + ".tmpl"
+	src, err := templateFS.ReadFile("templates/" + file)
+	if err != nil {
+		return "", err
+	}
+
+	r := &renderer{format: format, cur: cur}
+	var b bytes.Buffer
+	if format == "html" {
+		t, err := htmltemplate.New(file).Funcs(htmltemplate.FuncMap{
+			"type": func(t *descriptor.Type) htmltemplate.HTML { return htmltemplate.HTML(r.typ(t)) },
+			"args": func(args []*descriptor.Arg) htmltemplate.HTML { return htmltemplate.HTML(r.args(args)) },
+			"page": r.page,
+		}).Parse(string(src))
+		if err != nil {
+			return "", err
+		}
+		err = t.Execute(&b, data)
+		return b.String(), err
+	}
+
+	t, err := template.New(file).Funcs(template.FuncMap{
+		"type": r.typ,
+		"args": r.args,
+		"page": r.page,
+		"text": mdText,
+	}).Parse(string(src))
+	if err != nil {
+		return "", err
+	}
+	err = t.Execute(&b, data)
+	return b.String(), err
+}
+
+type renderer struct {
+	format string
+	cur    string
+}
+
+// module 对应的页面
+func (r *renderer) page(module string) string {
+	return module + "." + r.format
+}
+
+// 类型的 jce 写法，已经转义，struct、enum 为链接
+func (r *renderer) typ(t *descriptor.Type) string {
+	switch t.Kind {
+	case "vector":
+		return "vector&lt;" + r.typ(t.Params[0]) + "&gt;"
+	case "array":
+		return r.typ(t.Params[0]) + "[" + strconv.Itoa(t.Len) + "]"
+	case "map":
+		return "map&lt;" + r.typ(t.Params[0]) + ", " + r.typ(t.Params[1]) + "&gt;"
+	case "struct", "enum":
+		module, short := lang.SplitName(t.Name)
+		text, href := short, "#"+short
+		if module != r.cur {
+			text, href = module+"::"+short, r.page(module)+"#"+short
+		}
+		if r.format == "html" {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(href), html.EscapeString(text))
+		}
+		return fmt.Sprintf("[%s](%s)", text, href)
+	}
+	if t.IsUnsigned {
+		return "unsigned " + t.Kind
+	}
+	return t.Kind
+}
+
+// 方法的参数列表
+func (r *renderer) args(args []*descriptor.Arg) string {
+	var list []string
+	for _, a := range args {
+		s := r.typ(a.Type) + " " + html.EscapeString(a.Name)
+		if a.IsOut {
+			s = "out " + s
+		}
+		list = append(list, s)
+	}
+	return strings.Join(list, ", ")
+}
+
+// Markdown 表格中的文本：转义 HTML 和 |，换行转换为 <br>
+var mdText = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "|", `\|`, "\n", "<br>").Replace
@@ -0,0 +1,130 @@
+package doc
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+const baseJce = `module base
+{
+    // 消息类型
+    enum Kind
+    {
+        kA,
+        kB = 5,
+    };
+
+    const string NAME = "a|b";
+
+    struct Item
+    {
+        0 require int id;
+    };
+};
+`
+
+const svcJce = `#include "base.jce"
+
+module svc
+{
+    struct Req
+    {
+        0 require  vector<base::Item>  items;
+        1 optional base::Kind          kind = base::kB;  // a < b
+        2 optional map<string, Req>    sub;
+    };
+
+    interface Service
+    {
+        // 查询
+        int query(Req req, out vector<base::Item> rsp);
+        void ping();
+    };
+};
+`
+
+func buildSet(t *testing.T) *descriptor.Set {
+	dir := t.TempDir()
+	for name, src := range map[string]string{"base.jce": baseJce, "svc.jce": svcJce} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(dir, "svc.jce")
+	p, err := parser.ParseSource(file, []byte(svcJce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return descriptor.Build(p)
+}
+
+func generate(t *testing.T, set *descriptor.Set, format string) map[string]string {
+	files, err := Generate(set, Options{Format: format})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret := map[string]string{}
+	for _, f := range files {
+		ret[f.Name] = f.Content
+	}
+	return ret
+}
+
+func TestMarkdown(t *testing.T) {
+	files := generate(t, buildSet(t), "md")
+	if len(files) != 3 || files["index.md"] == "" {
+		t.Fatalf("unexpected files %v", files)
+	}
+	for name, wants := range map[string][]string{
+		"base.md": {
+			"<a id=\"Kind\"></a>\n### enum Kind\n\n消息类型",
+			"| kB | 5 |",
+			`| NAME | string | "a\|b" |  |`,
+		},
+		"svc.md": {
+			"Depends on: [base](base.md)",
+			"| 0 | items | require | vector&lt;[base::Item](base.md#Item)&gt; |  |  |",
+			"| 1 | kind | optional | [base::Kind](base.md#Kind) | base::kB | a &lt; b |",
+			"| 2 | sub | optional | map&lt;string, [Req](#Req)&gt; |  |  |",
+			"| query | int | [Req](#Req) req, out vector&lt;[base::Item](base.md#Item)&gt; rsp | 查询 |",
+			"| ping | void |  |  |",
+		},
+		"index.md": {"| [svc](svc.md) | `"},
+	} {
+		for _, want := range wants {
+			if !strings.Contains(files[name], want) {
+				t.Errorf("%s: missing %q in:\n%s", name, want, files[name])
+			}
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	files := generate(t, buildSet(t), "html")
+	svc := files["svc.html"]
+	for _, want := range []string{
+		`<h3 id="Req">struct Req</h3>`,
+		`<td class="type">vector&lt;<a href="base.html#Item">base::Item</a>&gt;</td>`,
+		`<td class="comment">a &lt; b</td>`,
+		`<td class="type"><a href="#Req">Req</a> req, out vector&lt;<a href="base.html#Item">base::Item</a>&gt; rsp</td>`,
+	} {
+		if !strings.Contains(svc, want) {
+			t.Errorf("missing %q in:\n%s", want, svc)
+		}
+	}
+	// 离线浏览，不引用外部资源
+	for name, content := range files {
+		if strings.Contains(content, "http") {
+			t.Errorf("%s references external resources", name)
+		}
+	}
+
+	if _, err := Generate(buildSet(t), Options{Format: "pdf"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
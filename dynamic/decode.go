@@ -0,0 +1,316 @@
+package dynamic
+
+import (
+	"strconv"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/wire"
+)
+
+// decoder 按 schema 解码
+type decoder struct {
+	r *wire.Reader
+}
+
+// Decode 按 schema 解码一个结构体，typeName 见 FindStruct。
+// 和生成代码的 ReadFrom 一样，数据中没有的 optional 成员取默认值，未知的 tag 直接跳过。
+func Decode(p *parser.Parser, typeName string, data []byte) (Object, error) {
+	fp, st, err := FindStruct(p, typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decoder{r: wire.NewReader(data)}
+	path := fp.Module + "." + st.Name
+
+	off := d.r.Offset()
+	ty, _, err := d.r.ReadHead()
+	if err != nil {
+		return nil, d.wrap(err, path)
+	}
+	if ty != wire.StructBegin {
+		return nil, &Error{Path: path, Offset: off, Msg: "expect StructBegin, got " + ty.String()}
+	}
+
+	obj, err := d.readStruct(fp, st, path)
+	if err != nil {
+		return nil, err
+	}
+	if d.r.Len() > 0 {
+		return obj, &Error{Path: path, Offset: d.r.Offset(), Msg: strconv.Itoa(d.r.Len()) + " bytes left after struct end"}
+	}
+	return obj, nil
+}
+
+// wrap 给底层的格式错误加上字段路径
+func (d *decoder) wrap(err error, path string) error {
+	switch e := err.(type) {
+	case *Error:
+		return e
+	case *wire.Error:
+		return &Error{Path: path, Offset: e.Offset, Msg: e.Msg}
+	}
+	return &Error{Path: path, Offset: d.r.Offset(), Msg: err.Error()}
+}
+
+// readStruct 读取结构体的字段直到 StructEnd，StructBegin 已经读取。
+// 生成代码的 WriteTo 写入结构体成员时 StructBegin 的 tag 总是 0，tag 对应不到结构体成员时，
+// 按位置对应到上一个读取的成员之后的第一个结构体成员
+func (d *decoder) readStruct(p *parser.Parser, st *parser.StructInfo, path string) (Object, error) {
+	var order []*parser.StructMember
+	members := map[int]*parser.StructMember{}
+	for i := range st.Member {
+		if mb := &st.Member[i]; mb.CommentType == "" {
+			order = append(order, mb)
+			members[int(mb.Tag)] = mb
+		}
+	}
+	index := func(mb *parser.StructMember) int {
+		for i, v := range order {
+			if v == mb {
+				return i
+			}
+		}
+		return -1
+	}
+
+	values := map[int]interface{}{}
+	last := -1 // 上一个读取的成员在 order 中的下标
+	for {
+		ty, tag, err := d.r.ReadHead()
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		if ty == wire.StructEnd {
+			break
+		}
+
+		mb, ok := members[tag]
+		if ty == wire.StructBegin && (!ok || !isStruct(mb.Type) || index(mb) <= last) {
+			ok = false
+			for _, v := range order[last+1:] {
+				if isStruct(v.Type) {
+					mb, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok {
+			// 新版本协议增加的字段，跳过
+			if err = d.r.Skip(ty); err != nil {
+				return nil, d.wrap(err, path+"."+strconv.Itoa(tag))
+			}
+			continue
+		}
+
+		v, err := d.readValue(p, mb.Type, ty, path+"."+memberName(mb))
+		if err != nil {
+			return nil, err
+		}
+		values[int(mb.Tag)] = v
+		last = index(mb)
+	}
+
+	obj := make(Object, 0, len(members))
+	for i := range st.Member {
+		mb := &st.Member[i]
+		if mb.CommentType != "" {
+			continue
+		}
+
+		v, ok := values[int(mb.Tag)]
+		if !ok {
+			if mb.Require {
+				return nil, &Error{Path: path + "." + memberName(mb), Offset: d.r.Offset(), Msg: "require field tag " + strconv.Itoa(int(mb.Tag)) + " not found"}
+			}
+			var err error
+			if v, err = defaultValue(p, mb); err != nil {
+				return nil, &Error{Path: path + "." + memberName(mb), Offset: d.r.Offset(), Msg: err.Error()}
+			}
+		}
+		obj = append(obj, Field{Name: memberName(mb), Value: v})
+	}
+	return obj, nil
+}
+
+// readValue 按类型读取 data，head 已经读取
+func (d *decoder) readValue(p *parser.Parser, t *parser.VarType, ty wire.Type, path string) (interface{}, error) {
+	off := d.r.Offset()
+	mismatch := func() error {
+		return &Error{Path: path, Offset: off, Msg: "type " + t.String() + " can not decode from " + ty.String()}
+	}
+
+	switch {
+	case isInt(t):
+		if !ty.IsInt() {
+			return nil, mismatch()
+		}
+		v, err := d.r.ReadInt(ty)
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		if min, max := intRange(t); v < min || v > max {
+			return nil, &Error{Path: path, Offset: off, Msg: strconv.FormatInt(v, 10) + " overflows " + t.String()}
+		}
+		if t.Type == lex.TkTBool {
+			return v != 0, nil
+		}
+		if t.Type == lex.TkTLong && t.Unsigned {
+			return uint64(v), nil
+		}
+		return v, nil
+
+	case t.Type == lex.TkTFloat || t.Type == lex.TkTDouble:
+		if ty != wire.Float && ty != wire.Double && ty != wire.ZeroTag {
+			return nil, mismatch()
+		}
+		v, err := d.r.ReadFloat(ty)
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		if t.Type == lex.TkTFloat {
+			return float32(v), nil
+		}
+		return v, nil
+
+	case t.Type == lex.TkTString:
+		if ty != wire.String1 && ty != wire.String4 {
+			return nil, mismatch()
+		}
+		v, err := d.r.ReadString(ty)
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		return v, nil
+
+	case t.Type == lex.TkTVector || t.Type == lex.TkTArray:
+		if ty == wire.SimpleList && t.TypeK.Type == lex.TkTByte {
+			n, err := d.r.ReadLength()
+			if err != nil {
+				return nil, d.wrap(err, path)
+			}
+			b, err := d.r.ReadBytes(int(n))
+			if err != nil {
+				return nil, d.wrap(err, path)
+			}
+			if t.TypeK.Unsigned {
+				return append([]byte{}, b...), nil
+			}
+			list := make([]interface{}, len(b))
+			for i, c := range b {
+				list[i] = int64(int8(c))
+			}
+			return list, nil
+		}
+		if ty != wire.List {
+			return nil, mismatch()
+		}
+		n, err := d.r.ReadLength()
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		list := make([]interface{}, 0, n)
+		for i := uint32(0); i < n; i++ {
+			v, err := d.readField(p, t.TypeK, path+"["+strconv.Itoa(int(i))+"]")
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		if t.TypeK.Type == lex.TkTByte && t.TypeK.Unsigned {
+			b := make([]byte, len(list))
+			for i, v := range list {
+				b[i] = byte(v.(int64))
+			}
+			return b, nil
+		}
+		return list, nil
+
+	case t.Type == lex.TkTMap:
+		if ty != wire.Map {
+			return nil, mismatch()
+		}
+		n, err := d.r.ReadLength()
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		scalar := isScalarKey(p, t.TypeK)
+		obj, entries := Object{}, []Entry{}
+		for i := uint32(0); i < n; i++ {
+			k, err := d.readField(p, t.TypeK, path+"{key "+strconv.Itoa(int(i))+"}")
+			if err != nil {
+				return nil, err
+			}
+			elemPath := path + "[" + keyString(k) + "]"
+			v, err := d.readField(p, t.TypeV, elemPath)
+			if err != nil {
+				return nil, err
+			}
+			if scalar {
+				obj = append(obj, Field{Name: keyString(k), Value: v})
+			} else {
+				entries = append(entries, Entry{Key: k, Value: v})
+			}
+		}
+		if scalar {
+			return obj, nil
+		}
+		return entries, nil
+
+	case t.Type == lex.TkName && t.CType == lex.TkEnum:
+		if !ty.IsInt() {
+			return nil, mismatch()
+		}
+		v, err := d.r.ReadInt(ty)
+		if err != nil {
+			return nil, d.wrap(err, path)
+		}
+		_, en := p.FindEnum(t.TypeSt)
+		if en == nil {
+			return nil, &Error{Path: path, Offset: off, Msg: "enum " + t.TypeSt + " not found"}
+		}
+		return newEnumValues(en).name(int32(v)), nil
+
+	case t.Type == lex.TkName:
+		if ty != wire.StructBegin {
+			return nil, mismatch()
+		}
+		fp, st := p.FindStruct(t.TypeSt)
+		if st == nil {
+			return nil, &Error{Path: path, Offset: off, Msg: "struct " + t.TypeSt + " not found"}
+		}
+		return d.readStruct(fp, st, path)
+	}
+
+	return nil, &Error{Path: path, Offset: off, Msg: "unsupported type " + t.String()}
+}
+
+// 是否为结构体类型
+func isStruct(t *parser.VarType) bool {
+	return t.Type == lex.TkName && t.CType != lex.TkEnum
+}
+
+// readField 读取一个完整的字段，用于 list、map 的元素
+func (d *decoder) readField(p *parser.Parser, t *parser.VarType, path string) (interface{}, error) {
+	ty, _, err := d.r.ReadHead()
+	if err != nil {
+		return nil, d.wrap(err, path)
+	}
+	return d.readValue(p, t, ty, path)
+}
+
+// map 的 key 转换为 JSON 对象的 key
+func keyString(k interface{}) string {
+	switch v := k.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return "?"
+}
@@ -0,0 +1,231 @@
+package dynamic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/wire"
+)
+
+const schema = `module test
+{
+    enum Color
+    {
+        Red = 1,
+        Green,
+    };
+
+    struct Item
+    {
+        0 require int id;
+        1 optional string name = "none";
+    };
+
+    struct Packet
+    {
+        0 require  byte                   b;
+        1 require  string                 s;
+        2 optional Color                  c = Green;
+        3 optional vector<unsigned byte>  raw;
+        4 optional vector<Item>           items;
+        5 optional map<string, int>       m;
+        6 optional float                  f;
+    };
+
+    struct Wrapper
+    {
+        0  require  int            id;
+        1  optional Item           first;
+        18 optional map<int, Item> byId;
+        20 optional Item           last;
+    };
+};
+`
+
+func parse(t *testing.T) *parser.Parser {
+	filename := filepath.Join(t.TempDir(), "test.jce")
+	if err := ioutil.WriteFile(filename, []byte(schema), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	p, err := parser.ParseSource(filename, []byte(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func packet() []byte {
+	w := wire.NewWriter()
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(-3, 0)
+	w.WriteString("hi", 1)
+	w.WriteSimpleList([]byte{1, 2}, 3)
+	w.WriteHead(wire.List, 4)
+	w.WriteLength(1)
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(7, 0)
+	w.WriteHead(wire.StructEnd, 0)
+	w.WriteHead(wire.Map, 5)
+	w.WriteLength(1)
+	w.WriteString("k", 0)
+	w.WriteInt(300, 1)
+	w.WriteString("unknown", 20)
+	w.WriteHead(wire.StructEnd, 0)
+	return w.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	p := parse(t)
+
+	obj, err := Decode(p, "test::Packet", packet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := json.Marshal(obj)
+	want := `{"b":-3,"s":"hi","c":"Green","raw":"AQI=","items":[{"id":7,"name":"none"}],"m":{"k":300},"f":0}`
+	if string(out) != want {
+		t.Fatalf("got %s\nwant %s", out, want)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	p := parse(t)
+
+	data := packet()
+	_, err := Decode(p, "test.Packet", data[:len(data)-3])
+	e, ok := err.(*Error)
+	if !ok || e.Offset < 0 || !strings.HasPrefix(e.Path, "test.Packet") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	w := wire.NewWriter()
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(0, 0)
+	w.WriteHead(wire.StructEnd, 0)
+	if _, err = Decode(p, "Packet", w.Bytes()); err == nil || !strings.Contains(err.Error(), "test.Packet.s") {
+		t.Fatalf("require field error expected, got %v", err)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	p := parse(t)
+
+	// 和 Decode 的结果互逆
+	obj, err := Decode(p, "test::Packet", packet())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Encode(p, "test::Packet", obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Decode(p, "test::Packet", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := json.Marshal(obj)
+	b, _ := json.Marshal(again)
+	if string(a) != string(b) {
+		t.Fatalf("round trip mismatch\n%s\n%s", a, b)
+	}
+
+	// 枚举可以用名字或者数字，缺省的成员取默认值
+	for _, in := range []string{`{"b": 1, "s": "x", "c": "Red"}`, `{"b": 1, "s": "x", "c": 1, "items": [{"id": 2}]}`} {
+		data, err := EncodeJSON(p, "test::Packet", []byte(in))
+		if err != nil {
+			t.Fatal(err)
+		}
+		obj, err := Decode(p, "test::Packet", data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, _ := obj.Get("c"); c != "Red" {
+			t.Fatalf("%s: c = %v", in, c)
+		}
+	}
+}
+
+// 结构体成员和生成代码的 WriteTo 相同：StructBegin 的 tag 总是 0，不写入成员的 tag
+func TestEncodeStructMember(t *testing.T) {
+	p := parse(t)
+
+	w := wire.NewWriter()
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(1, 0)
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(2, 0)
+	w.WriteString("a", 1)
+	w.WriteHead(wire.StructEnd, 0)
+	w.WriteHead(wire.Map, 18)
+	w.WriteLength(1)
+	w.WriteInt(3, 0)
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(3, 0)
+	w.WriteString("none", 1)
+	w.WriteHead(wire.StructEnd, 0)
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(4, 0)
+	w.WriteString("b", 1)
+	w.WriteHead(wire.StructEnd, 0)
+	w.WriteHead(wire.StructEnd, 0)
+	want := w.Bytes()
+
+	in := `{"id":1,"first":{"id":2,"name":"a"},"byId":{"3":{"id":3,"name":"none"}},"last":{"id":4,"name":"b"}}`
+	data, err := EncodeJSON(p, "test::Wrapper", []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(want) {
+		t.Fatalf("got  %x\nwant %x", data, want)
+	}
+
+	// 按位置解码 tag 为 0 的结构体成员
+	obj, err := Decode(p, "test::Wrapper", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out, _ := json.Marshal(obj); string(out) != in {
+		t.Fatalf("got %s\nwant %s", out, in)
+	}
+
+	// 也兼容 StructBegin 带成员 tag 的数据
+	w = wire.NewWriter()
+	w.WriteHead(wire.StructBegin, 0)
+	w.WriteInt(1, 0)
+	w.WriteHead(wire.StructBegin, 20)
+	w.WriteInt(4, 0)
+	w.WriteHead(wire.StructEnd, 0)
+	w.WriteHead(wire.StructEnd, 0)
+	if obj, err = Decode(p, "test::Wrapper", w.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	last, _ := obj.Get("last")
+	if item, ok := last.(Object); !ok {
+		t.Fatalf("unexpected last %v", last)
+	} else if id, _ := item.Get("id"); id != int64(4) {
+		t.Fatalf("unexpected last %v", item)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	p := parse(t)
+
+	cases := map[string]string{
+		`{"s": "x"}`:                          "test.Packet.b: require field",
+		`{"b": 128, "s": "x"}`:                "test.Packet.b: 128 overflows byte",
+		`{"b": 1, "s": "x", "c": "Blue"}`:     "test.Packet.c: unknown enum member Blue",
+		`{"b": 1, "s": "x", "x": 1}`:          "test.Packet: unknown field x",
+		`{"b": 1, "s": "x", "items": [{}]}`:   "test.Packet.items[0].id: require field",
+		`{"b": 1, "s": "x", "m": {"k": "v"}}`: "test.Packet.m[k]: expect integer, got string",
+	}
+	for in, want := range cases {
+		_, err := EncodeJSON(p, "test::Packet", []byte(in))
+		if err == nil || !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s: got %v, want %s", in, err, want)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/wire"
+)
+
+// encoder 按 schema 编码
+type encoder struct {
+	w *wire.Writer
+}
+
+// EncodeJSON 把 JSON 按 schema 编码为 jce 数据，typeName 见 FindStruct
+func EncodeJSON(p *parser.Parser, typeName string, data []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, &Error{Path: typeName, Offset: -1, Msg: "unexpected data after json value"}
+	}
+	return Encode(p, typeName, v)
+}
+
+// Encode 把 JSON 值按 schema 编码为 jce 数据，v 可以是 json.Unmarshal 的结果，也可以是 Decode 的结果。
+// 和生成代码的 WriteTo 一样，所有成员都会写入：没有给出的成员取默认值，require 且没有默认值的成员必须给出。
+func Encode(p *parser.Parser, typeName string, v interface{}) ([]byte, error) {
+	fp, st, err := FindStruct(p, typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &encoder{w: wire.NewWriter()}
+	if err = e.writeStruct(fp, st, v, fp.Module+"."+st.Name); err != nil {
+		return nil, err
+	}
+	return e.w.Bytes(), nil
+}
+
+func errorf(path string, format string, args ...interface{}) error {
+	return &Error{Path: path, Offset: -1, Msg: fmt.Sprintf(format, args...)}
+}
+
+// writeStruct 写入一个结构体。和生成代码的 WriteTo 一样，StructBegin 的 tag 总是 0，
+// 结构体作为成员、容器的元素时也不写入成员的 tag
+func (e *encoder) writeStruct(p *parser.Parser, st *parser.StructInfo, v interface{}, path string) error {
+	fields, err := toFields(v, path)
+	if err != nil {
+		return err
+	}
+
+	known := map[string]bool{}
+	for i := range st.Member {
+		if mb := &st.Member[i]; mb.CommentType == "" {
+			known[memberName(mb)] = true
+		}
+	}
+	var unknown []string
+	for name := range fields {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return errorf(path, "unknown field %s", strings.Join(unknown, ", "))
+	}
+
+	e.w.WriteHead(wire.StructBegin, 0)
+	for i := range st.Member {
+		mb := &st.Member[i]
+		if mb.CommentType != "" {
+			continue
+		}
+
+		name := memberName(mb)
+		mv, ok := fields[name]
+		if !ok || mv == nil {
+			if mb.Require && mb.OriginDefault == "" {
+				return errorf(path+"."+name, "require field tag %d is missing", mb.Tag)
+			}
+			if mv, err = defaultValue(p, mb); err != nil {
+				return errorf(path+"."+name, "%v", err)
+			}
+		}
+		if err = e.writeValue(p, mb.Type, mv, int(mb.Tag), path+"."+name); err != nil {
+			return err
+		}
+	}
+	e.w.WriteHead(wire.StructEnd, 0)
+	return nil
+}
+
+// writeValue 按类型写入一个字段
+func (e *encoder) writeValue(p *parser.Parser, t *parser.VarType, v interface{}, tag int, path string) error {
+	switch {
+	case t.Type == lex.TkTBool:
+		switch b := v.(type) {
+		case bool:
+			e.w.WriteBool(b, tag)
+			return nil
+		}
+		n, err := toInt(v, t, path)
+		if err != nil {
+			return err
+		}
+		e.w.WriteInt(n, tag)
+
+	case isInt(t):
+		n, err := toInt(v, t, path)
+		if err != nil {
+			return err
+		}
+		e.w.WriteInt(n, tag)
+
+	case t.Type == lex.TkTFloat || t.Type == lex.TkTDouble:
+		f, err := toFloat(v, path)
+		if err != nil {
+			return err
+		}
+		if t.Type == lex.TkTFloat {
+			if math.Abs(f) > math.MaxFloat32 && !math.IsInf(f, 0) {
+				return errorf(path, "%v overflows float", f)
+			}
+			e.w.WriteFloat(float32(f), tag)
+		} else {
+			e.w.WriteDouble(f, tag)
+		}
+
+	case t.Type == lex.TkTString:
+		s, ok := v.(string)
+		if !ok {
+			return errorf(path, "expect string, got %s", kind(v))
+		}
+		e.w.WriteString(s, tag)
+
+	case t.Type == lex.TkTVector || t.Type == lex.TkTArray:
+		return e.writeList(p, t, v, tag, path)
+
+	case t.Type == lex.TkTMap:
+		return e.writeMap(p, t, v, tag, path)
+
+	case t.Type == lex.TkName && t.CType == lex.TkEnum:
+		_, en := p.FindEnum(t.TypeSt)
+		if en == nil {
+			return errorf(path, "enum %s not found", t.TypeSt)
+		}
+		n, err := enumValue(newEnumValues(en), v, path)
+		if err != nil {
+			return err
+		}
+		e.w.WriteInt(int64(n), tag)
+
+	case t.Type == lex.TkName:
+		fp, st := p.FindStruct(t.TypeSt)
+		if st == nil {
+			return errorf(path, "struct %s not found", t.TypeSt)
+		}
+		return e.writeStruct(fp, st, v, path)
+
+	default:
+		return errorf(path, "unsupported type %s", t.String())
+	}
+	return nil
+}
+
+// writeList 写入 vector 和数组，vector<byte> 使用 SimpleList
+func (e *encoder) writeList(p *parser.Parser, t *parser.VarType, v interface{}, tag int, path string) error {
+	if t.TypeK.Type == lex.TkTByte {
+		var b []byte
+		switch s := v.(type) {
+		case []byte:
+			b = s
+		case string:
+			var err error
+			if b, err = base64.StdEncoding.DecodeString(s); err != nil {
+				return errorf(path, "invalid base64: %v", err)
+			}
+		default:
+			list, ok := v.([]interface{})
+			if !ok {
+				return errorf(path, "expect array or base64 string, got %s", kind(v))
+			}
+			b = make([]byte, len(list))
+			for i, item := range list {
+				n, err := toInt(item, t.TypeK, path+"["+strconv.Itoa(i)+"]")
+				if err != nil {
+					return err
+				}
+				b[i] = byte(n)
+			}
+		}
+		e.w.WriteSimpleList(b, tag)
+		return nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return errorf(path, "expect array, got %s", kind(v))
+	}
+	e.w.WriteHead(wire.List, tag)
+	e.w.WriteLength(uint32(len(list)))
+	for i, item := range list {
+		if err := e.writeValue(p, t.TypeK, item, 0, path+"["+strconv.Itoa(i)+"]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMap 写入 map，JSON 对象的 key 按 key 的类型转换
+func (e *encoder) writeMap(p *parser.Parser, t *parser.VarType, v interface{}, tag int, path string) error {
+	var entries []Entry
+	switch m := v.(type) {
+	case Object:
+		for _, f := range m {
+			entries = append(entries, Entry{Key: f.Name, Value: f.Value})
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entries = append(entries, Entry{Key: k, Value: m[k]})
+		}
+	case []Entry:
+		entries = m
+	case []interface{}:
+		for i, item := range m {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				return errorf(path+"["+strconv.Itoa(i)+"]", "expect {\"key\": k, \"value\": v}, got %s", kind(item))
+			}
+			entries = append(entries, Entry{Key: obj["key"], Value: obj["value"]})
+		}
+	default:
+		return errorf(path, "expect object or array, got %s", kind(v))
+	}
+
+	e.w.WriteHead(wire.Map, tag)
+	e.w.WriteLength(uint32(len(entries)))
+	for _, en := range entries {
+		key := en.Key
+		// JSON 对象的 key 只能是字符串，整数、bool 类型的 key 需要转换
+		if s, ok := key.(string); ok && t.TypeK.Type != lex.TkTString && t.TypeK.CType != lex.TkEnum {
+			key = json.Number(s)
+			if t.TypeK.Type == lex.TkTBool {
+				key = s == "true"
+			}
+		}
+		elemPath := path + "[" + keyString(en.Key) + "]"
+		if err := e.writeValue(p, t.TypeK, key, 0, elemPath); err != nil {
+			return err
+		}
+		if err := e.writeValue(p, t.TypeV, en.Value, 1, elemPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// toFields 把结构体的 JSON 值转换为按名字索引的字段
+func toFields(v interface{}, path string) (map[string]interface{}, error) {
+	switch obj := v.(type) {
+	case map[string]interface{}:
+		return obj, nil
+	case Object:
+		fields := make(map[string]interface{}, len(obj))
+		for _, f := range obj {
+			fields[f.Name] = f.Value
+		}
+		return fields, nil
+	}
+	return nil, errorf(path, "expect object, got %s", kind(v))
+}
+
+// toInt 转换为整数并检查取值范围，unsigned long 按补码保存在 int64 中
+func toInt(v interface{}, t *parser.VarType, path string) (int64, error) {
+	ulong := t.Type == lex.TkTLong && t.Unsigned
+
+	var n int64
+	switch x := v.(type) {
+	case json.Number:
+		if ulong {
+			u, err := strconv.ParseUint(string(x), 0, 64)
+			if err != nil {
+				return 0, errorf(path, "invalid %s %s", t.String(), x)
+			}
+			return int64(u), nil
+		}
+		i, err := strconv.ParseInt(string(x), 0, 64)
+		if err != nil {
+			return 0, errorf(path, "invalid integer %s", x)
+		}
+		n = i
+	case int64:
+		n = x
+	case uint64:
+		if !ulong && x > math.MaxInt64 {
+			return 0, errorf(path, "%d overflows %s", x, t.String())
+		}
+		n = int64(x)
+	case float64:
+		if x != math.Trunc(x) {
+			return 0, errorf(path, "invalid integer %v", x)
+		}
+		n = int64(x)
+	case bool:
+		if x {
+			n = 1
+		}
+	default:
+		return 0, errorf(path, "expect integer, got %s", kind(v))
+	}
+
+	if min, max := intRange(t); !ulong && (n < min || n > max) {
+		return 0, errorf(path, "%d overflows %s", n, t.String())
+	}
+	return n, nil
+}
+
+// toFloat 转换为浮点数
+func toFloat(v interface{}, path string) (float64, error) {
+	switch x := v.(type) {
+	case json.Number:
+		f, err := strconv.ParseFloat(string(x), 64)
+		if err != nil {
+			return 0, errorf(path, "invalid number %s", x)
+		}
+		return f, nil
+	case float32:
+		return float64(x), nil
+	case float64:
+		return x, nil
+	case int64:
+		return float64(x), nil
+	}
+	return 0, errorf(path, "expect number, got %s", kind(v))
+}
+
+// enumValue 枚举可以使用成员名（可带 Enum:: 或 module:: 前缀）或者数字
+func enumValue(ev *enumValues, v interface{}, path string) (int32, error) {
+	if s, ok := v.(string); ok {
+		name := s
+		if i := strings.LastIndex(name, "::"); i >= 0 {
+			name = name[i+2:]
+		}
+		if n, ok := ev.byName[name]; ok {
+			return n, nil
+		}
+		if _, err := strconv.ParseInt(s, 0, 32); err != nil {
+			return 0, errorf(path, "unknown enum member %s", s)
+		}
+		v = json.Number(s)
+	}
+
+	n, err := toInt(v, &parser.VarType{Type: lex.TkTInt}, path)
+	if err != nil {
+		return 0, err
+	}
+	return int32(n), nil
+}
+
+// JSON 值的类型名，用于错误信息
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number, int64, uint64, float32, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}, []Entry, []byte:
+		return "array"
+	case map[string]interface{}, Object:
+		return "object"
+	}
+	return "unknown"
+}
@@ -0,0 +1,251 @@
+package dynamic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// 不生成代码，直接按照语法分析树（StructInfo、VarType）在运行时解释 jce 数据
+//
+// 和 JSON 的对应关系与生成代码的 json 序列化保持一致：
+// 1. struct 为对象，字段名为 jce 中的成员名，按定义顺序输出
+// 2. enum 为成员名，未知的取值输出数字
+// 3. vector<byte> 为数字数组，vector<unsigned byte> 为 base64 字符串
+// 4. key 为 string、整数、enum 的 map 为对象，其他 map 为 [{"key": k, "value": v}] 数组
+
+// Field 对象中的一个字段
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Object 保持字段顺序的 JSON 对象
+type Object []Field
+
+// Get 按名字获取字段的值
+func (o Object) Get(name string) (interface{}, bool) {
+	for _, f := range o {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// MarshalJSON 按字段顺序输出
+func (o Object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.Name)
+		b.Write(key)
+		b.WriteByte(':')
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Entry key 不能作为 JSON 对象 key 的 map 元素
+type Entry struct {
+	Key   interface{} `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// Error 按 schema 编解码出错，Path 为出错的字段路径，如 RequestPacket.arr3[0].b
+type Error struct {
+	Path   string
+	Offset int // 解码时出错位置的字节偏移，编码时为 -1
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	if e.Offset < 0 {
+		return e.Path + ": " + e.Msg
+	}
+	return fmt.Sprintf("%s: offset %d: %s", e.Path, e.Offset, e.Msg)
+}
+
+// FindStruct 查找要编解码的结构体，name 可以是 module::Struct、module.Struct 或者当前 module 中的 Struct
+func FindStruct(p *parser.Parser, name string) (*parser.Parser, *parser.StructInfo, error) {
+	if !strings.Contains(name, "::") {
+		name = strings.Replace(name, ".", "::", 1)
+	}
+	fp, st := p.FindStruct(name)
+	if st == nil {
+		return nil, nil, fmt.Errorf("struct %s not found in %s", name, p.Filepath)
+	}
+	return fp, st, nil
+}
+
+// 成员在 JSON 中的名字，和生成代码的 json tag 一致，使用 jce 中的原始名字
+func memberName(mb *parser.StructMember) string {
+	if mb.OriginKey != "" {
+		return mb.OriginKey
+	}
+	return mb.Key
+}
+
+// 整数类型的取值范围
+func intRange(t *parser.VarType) (min, max int64) {
+	bits := map[lex.TokenType]uint{lex.TkTBool: 1, lex.TkTByte: 8, lex.TkTShort: 16, lex.TkTInt: 32, lex.TkTLong: 64}[t.Type]
+	if t.Type == lex.TkTBool {
+		return 0, 1
+	}
+	if t.Unsigned && bits == 64 {
+		// unsigned long 的所有取值都可以保存在 int64 中
+		return math.MinInt64, math.MaxInt64
+	}
+	if t.Unsigned {
+		return 0, int64(uint64(1)<<bits - 1)
+	}
+	return -int64(uint64(1) << (bits - 1)), int64(uint64(1)<<(bits-1) - 1)
+}
+
+func isInt(t *parser.VarType) bool {
+	switch t.Type {
+	case lex.TkTBool, lex.TkTByte, lex.TkTShort, lex.TkTInt, lex.TkTLong:
+		return true
+	}
+	return false
+}
+
+// 能否作为 JSON 对象的 key
+func isScalarKey(p *parser.Parser, t *parser.VarType) bool {
+	switch t.Type {
+	case lex.TkTString, lex.TkTByte, lex.TkTShort, lex.TkTInt, lex.TkTLong, lex.TkTBool:
+		return true
+	case lex.TkName:
+		return t.CType == lex.TkEnum
+	}
+	return false
+}
+
+// 枚举取值和名字的映射，名字重复时取第一个
+type enumValues struct {
+	byName  map[string]int32
+	byValue map[int32]string
+}
+
+func newEnumValues(en *parser.EnumInfo) *enumValues {
+	ev := &enumValues{byName: en.Values(), byValue: map[int32]string{}}
+	for _, mb := range en.Member {
+		if mb.Type == parser.EnumTypeComment {
+			continue
+		}
+		if _, ok := ev.byValue[ev.byName[mb.Key]]; !ok {
+			ev.byValue[ev.byName[mb.Key]] = mb.Key
+		}
+	}
+	return ev
+}
+
+func (ev *enumValues) name(v int32) interface{} {
+	if name, ok := ev.byValue[v]; ok {
+		return name
+	}
+	return int64(v)
+}
+
+// defaultValue 成员没有出现在数据中时的取值：有默认值取默认值，否则为零值
+func defaultValue(p *parser.Parser, mb *parser.StructMember) (interface{}, error) {
+	if mb.OriginDefault == "" {
+		return zeroValue(p, mb.Type)
+	}
+
+	def := mb.OriginDefault
+	switch {
+	case mb.DefType == lex.TkString:
+		return strconv.Unquote(def)
+	case mb.DefType == lex.TkTrue || mb.DefType == lex.TkFalse:
+		return def == "true", nil
+	case mb.DefType == lex.TkName:
+		if i := strings.LastIndex(def, "::"); i >= 0 {
+			def = def[i+2:]
+		}
+		return def, nil
+	case mb.Type.Type == lex.TkTFloat:
+		f, err := strconv.ParseFloat(def, 32)
+		return float32(f), err
+	case mb.Type.Type == lex.TkTDouble:
+		return strconv.ParseFloat(def, 64)
+	case mb.Type.Type == lex.TkName:
+		// 枚举使用数字作为默认值
+		v, err := strconv.ParseInt(def, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+		_, en := p.FindEnum(mb.Type.TypeSt)
+		if en == nil {
+			return v, nil
+		}
+		return newEnumValues(en).name(int32(v)), nil
+	}
+	return strconv.ParseInt(def, 0, 64)
+}
+
+// zeroValue 类型的零值
+func zeroValue(p *parser.Parser, t *parser.VarType) (interface{}, error) {
+	switch t.Type {
+	case lex.TkTBool:
+		return false, nil
+	case lex.TkTByte, lex.TkTShort, lex.TkTInt, lex.TkTLong:
+		return int64(0), nil
+	case lex.TkTFloat:
+		return float32(0), nil
+	case lex.TkTDouble:
+		return float64(0), nil
+	case lex.TkTString:
+		return "", nil
+	case lex.TkTVector, lex.TkTArray:
+		if t.TypeK.Type == lex.TkTByte && t.TypeK.Unsigned {
+			return []byte{}, nil
+		}
+		return []interface{}{}, nil
+	case lex.TkTMap:
+		if isScalarKey(p, t.TypeK) {
+			return Object{}, nil
+		}
+		return []Entry{}, nil
+	case lex.TkName:
+		if t.CType == lex.TkEnum {
+			_, en := p.FindEnum(t.TypeSt)
+			if en == nil {
+				return nil, fmt.Errorf("enum %s not found", t.TypeSt)
+			}
+			return newEnumValues(en).name(0), nil
+		}
+		fp, st := p.FindStruct(t.TypeSt)
+		if st == nil {
+			return nil, fmt.Errorf("struct %s not found", t.TypeSt)
+		}
+		obj := Object{}
+		for i := range st.Member {
+			mb := &st.Member[i]
+			if mb.CommentType != "" {
+				continue
+			}
+			v, err := defaultValue(fp, mb)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, Field{Name: memberName(mb), Value: v})
+		}
+		return obj, nil
+	}
+	return nil, fmt.Errorf("unknown type %s", t.String())
+}
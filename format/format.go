@@ -0,0 +1,368 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+)
+
+// jce 源码格式化
+// 1. 基于词法分析的 token 流重新排版，注释原样保留
+// 2. 每层 {} 缩进 4 个空格，连续的空行最多保留一行
+// 3. struct 成员按 tag、require/optional、类型、名字分列对齐，行尾注释对齐
+
+const indent = "    "
+
+// 输出的一行
+type line struct {
+	depth   int
+	tokens  []lex.Token
+	texts   []string
+	comment string // 行尾注释
+	member  bool   // 是否为 struct 成员
+	blank   bool   // 空行
+}
+
+// 块的类型
+const (
+	blockModule = iota
+	blockStruct
+	blockEnum
+	blockOther
+)
+
+type printer struct {
+	src    []byte
+	lines  []*line
+	cur    *line
+	depth  int
+	blocks []int // 当前嵌套的块类型
+	next   int   // 下一个 { 开启的块类型
+}
+
+// Source 格式化 jce 源码，返回格式化后的内容，源码存在词法错误时返回 *lex.Error
+func Source(filename string, src []byte) ([]byte, error) {
+	tokens, err := lex.Tokenize(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &printer{src: src, next: blockOther}
+	p.print(tokens)
+
+	return p.render(), nil
+}
+
+func (p *printer) text(tk lex.Token) string {
+	text := string(p.src[tk.Offset:tk.End])
+	if tk.Type == lex.TkComment {
+		text = strings.TrimRight(text, " \t\r")
+	}
+	return text
+}
+
+// 统计两个 token 之间的换行数
+func (p *printer) newlines(from, to int) int {
+	if from < 0 {
+		return 0
+	}
+	n := 0
+	gap := p.src[from:to]
+	for i := 0; i < len(gap); i++ {
+		if gap[i] == '\n' || (gap[i] == '\r' && (i+1 == len(gap) || gap[i+1] != '\n')) {
+			n++
+		}
+	}
+	return n
+}
+
+func (p *printer) print(tokens []lex.Token) {
+	prevEnd := -1
+	var prev *lex.Token
+
+	for i := range tokens {
+		tk := tokens[i]
+		nl := p.newlines(prevEnd, tk.Offset)
+		prevEnd = tk.End
+
+		if tk.Type == lex.TkComment && p.cur != nil && nl == 0 {
+			// 行尾注释，// 注释后必须换行
+			text := p.text(tk)
+			if strings.HasPrefix(text, "//") || p.cur.comment != "" {
+				p.cur.comment = joinComment(p.cur.comment, text)
+			} else if i+1 < len(tokens) && p.newlines(tk.End, tokens[i+1].Offset) == 0 {
+				p.append(tk)
+			} else {
+				p.cur.comment = text
+			}
+			prev = &tokens[i]
+			continue
+		}
+
+		if p.breakBefore(prev, tk, nl) {
+			p.endLine()
+			if nl > 1 && len(p.lines) > 0 && !p.lastOpensBlock() && tk.Type != lex.TkBraceRight {
+				p.lines = append(p.lines, &line{blank: true})
+			}
+		}
+
+		switch tk.Type {
+		case lex.TkBraceRight:
+			if p.depth > 0 {
+				p.depth--
+			}
+			if len(p.blocks) > 0 {
+				p.blocks = p.blocks[:len(p.blocks)-1]
+			}
+			p.trimBlank()
+		case lex.TkModule:
+			p.next = blockModule
+		case lex.TkStruct:
+			p.next = blockStruct
+		case lex.TkEnum:
+			p.next = blockEnum
+		case lex.TkInterface:
+			p.next = blockOther
+		}
+
+		p.append(tk)
+
+		if tk.Type == lex.TkBraceLeft {
+			p.depth++
+			p.blocks = append(p.blocks, p.next)
+			p.next = blockOther
+		}
+		prev = &tokens[i]
+	}
+	p.endLine()
+}
+
+// 判断 token 前是否需要换行
+func (p *printer) breakBefore(prev *lex.Token, tk lex.Token, nl int) bool {
+	if p.cur == nil {
+		return false
+	}
+	if p.cur.comment != "" {
+		return true
+	}
+	switch tk.Type {
+	case lex.TkSemi, lex.TkComma:
+		return false
+	case lex.TkBraceRight, lex.TkInclude:
+		return true
+	}
+	if nl > 0 {
+		return true
+	}
+	if prev == nil {
+		return false
+	}
+	switch prev.Type {
+	case lex.TkBraceLeft, lex.TkSemi:
+		return true
+	case lex.TkComment:
+		return strings.HasPrefix(p.text(*prev), "//")
+	}
+	return false
+}
+
+func (p *printer) append(tk lex.Token) {
+	if p.cur == nil {
+		p.cur = &line{depth: p.depth}
+		if len(p.blocks) > 0 && p.blocks[len(p.blocks)-1] == blockStruct && tk.Type == lex.TkInteger {
+			p.cur.member = true
+		}
+	}
+	p.cur.tokens = append(p.cur.tokens, tk)
+	p.cur.texts = append(p.cur.texts, p.text(tk))
+}
+
+func (p *printer) endLine() {
+	if p.cur != nil {
+		p.lines = append(p.lines, p.cur)
+		p.cur = nil
+	}
+}
+
+// 上一行是否以 { 结尾，块开头不保留空行
+func (p *printer) lastOpensBlock() bool {
+	last := p.lines[len(p.lines)-1]
+	if last.blank || len(last.tokens) == 0 || last.comment != "" {
+		return last.blank
+	}
+	return last.tokens[len(last.tokens)-1].Type == lex.TkBraceLeft
+}
+
+// 去掉块结尾的空行
+func (p *printer) trimBlank() {
+	for len(p.lines) > 0 && p.lines[len(p.lines)-1].blank {
+		p.lines = p.lines[:len(p.lines)-1]
+	}
+}
+
+func joinComment(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
+
+// 判断两个相邻 token 之间是否需要空格
+func space(prev, cur lex.Token) bool {
+	switch cur.Type {
+	case lex.TkSemi, lex.TkComma, lex.TkShr, lex.TkSquarerRight, lex.TkPtr, lex.TkSquareLeft, lex.TkPtl:
+		return false
+	case lex.TkShl:
+		return prev.Type != lex.TkTVector && prev.Type != lex.TkTMap
+	}
+	switch prev.Type {
+	case lex.TkShl, lex.TkSquareLeft, lex.TkPtl:
+		return false
+	}
+	return true
+}
+
+func join(tokens []lex.Token, texts []string) string {
+	var b strings.Builder
+	for i := range tokens {
+		if i > 0 && space(tokens[i-1], tokens[i]) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(texts[i])
+	}
+	return b.String()
+}
+
+// 把 struct 成员拆成 tag、require、类型、剩余部分四列
+func (l *line) columns() []string {
+	toks, texts := l.tokens, l.texts
+	if len(toks) < 4 {
+		return []string{join(toks, texts)}
+	}
+
+	end := 2
+	if toks[end].Type == lex.TkUnsigned && end+1 < len(toks) {
+		end++
+	}
+	if end+1 < len(toks) && toks[end+1].Type == lex.TkShl {
+		level := 0
+		for end++; end < len(toks); end++ {
+			if toks[end].Type == lex.TkShl {
+				level++
+			} else if toks[end].Type == lex.TkShr {
+				level--
+				if level == 0 {
+					break
+				}
+			}
+		}
+	}
+	if end+1 >= len(toks) {
+		return []string{join(toks, texts)}
+	}
+
+	return []string{
+		texts[0],
+		texts[1],
+		join(toks[2:end+1], texts[2:end+1]),
+		join(toks[end+1:], texts[end+1:]),
+	}
+}
+
+func (p *printer) render() []byte {
+	var out bytes.Buffer
+
+	for i := 0; i < len(p.lines); {
+		l := p.lines[i]
+		if !l.member {
+			p.writeLine(&out, l, nil, 0)
+			i++
+			continue
+		}
+
+		// 连续的成员行（中间可以穿插注释行）一起对齐
+		j := i
+		var widths []int
+		for ; j < len(p.lines); j++ {
+			m := p.lines[j]
+			if m.blank || (!m.member && !isCommentLine(m)) {
+				break
+			}
+			if !m.member {
+				continue
+			}
+			cols := m.columns()
+			if len(cols) != 4 {
+				continue
+			}
+			if widths == nil {
+				widths = make([]int, 3)
+			}
+			for k := 0; k < 3; k++ {
+				if len(cols[k]) > widths[k] {
+					widths[k] = len(cols[k])
+				}
+			}
+		}
+		for j > i && !p.lines[j-1].member {
+			j--
+		}
+
+		codeWidth := 0
+		for k := i; k < j; k++ {
+			if s := memberCode(p.lines[k], widths); p.lines[k].member && len(s) > codeWidth {
+				codeWidth = len(s)
+			}
+		}
+		for k := i; k < j; k++ {
+			p.writeLine(&out, p.lines[k], widths, codeWidth)
+		}
+		i = j
+	}
+
+	return out.Bytes()
+}
+
+func isCommentLine(l *line) bool {
+	return len(l.tokens) == 1 && l.tokens[0].Type == lex.TkComment
+}
+
+func memberCode(l *line, widths []int) string {
+	cols := l.columns()
+	if len(cols) != 4 || widths == nil {
+		return join(l.tokens, l.texts)
+	}
+	var b strings.Builder
+	for k := 0; k < 3; k++ {
+		b.WriteString(cols[k])
+		b.WriteString(strings.Repeat(" ", widths[k]-len(cols[k])+1))
+	}
+	b.WriteString(cols[3])
+	return b.String()
+}
+
+func (p *printer) writeLine(out *bytes.Buffer, l *line, widths []int, codeWidth int) {
+	if l.blank {
+		out.WriteByte('\n')
+		return
+	}
+
+	out.WriteString(strings.Repeat(indent, l.depth))
+
+	code := join(l.tokens, l.texts)
+	if l.member {
+		code = memberCode(l, widths)
+	}
+	out.WriteString(code)
+
+	if l.comment != "" {
+		pad := 1
+		if l.member && codeWidth > len(code) {
+			pad += codeWidth - len(code)
+		}
+		out.WriteString(strings.Repeat(" ", pad))
+		out.WriteString(l.comment)
+	}
+	out.WriteByte('\n')
+}
@@ -0,0 +1,52 @@
+package format
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	src := `module test{
+struct a {
+1 require int i; // i
+2  optional   vector<map<int,string>> list;
+
+
+12 optional string s="x";
+};
+};
+`
+	want := `module test {
+    struct a {
+        1 require  int                      i;    // i
+        2 optional vector<map<int, string>> list;
+
+        12 optional string s = "x";
+    };
+};
+`
+	got, err := Source("test.jce", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSourceIdempotent(t *testing.T) {
+	for _, filename := range []string{"../demo/base.jce", "../demo/test.jce"} {
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		once, err := Source(filename, src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		twice, _ := Source(filename, once)
+		if string(once) != string(twice) {
+			t.Errorf("%s: format is not idempotent:\n%s\n%s", filename, once, twice)
+		}
+	}
+}
@@ -0,0 +1,623 @@
+// Package fromgo 从 go 的类型生成 jce 文件，是 jce2go 生成 go 代码的反向过程
+//
+// 一个 go package 对应一个 jce module，只转换有 `tag:"N"` 的 struct：
+//
+//	int8/int16/int32/int64     byte/short/int/long
+//	uint8/uint16/uint32        unsigned byte/short/int
+//	float32/float64            float/double
+//	[]T、[N]T、map[K]V         vector<T>、T name[N]、map<K, V>
+//	底层为 int32 的命名类型     enum，同一个 package 中该类型的常量为成员，去掉类型名前缀
+//
+// 成员名取 json tag 中的名字。go 的类型中没有 require 和默认值：jce2go -descriptor 生成的代码
+// 在 init 中注册了 jceschema 的描述，从中恢复 require 和默认值；其他的 struct 转换是有损的，
+// 所有成员都是 optional 并且没有默认值。int、uint64、指针、interface、
+// 嵌入的字段、没有 tag 的字段等 jce 不能表示的内容直接报错
+package fromgo
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/format"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// Options 转换的参数
+type Options struct {
+	// Module module 名，默认为 package 名
+	Module string
+}
+
+// Convert 加载 dir 中的 go package，生成 <module>.jce
+func Convert(dir string, opts Options) (plugin.File, error) {
+	c, err := load(dir)
+	if err != nil {
+		return plugin.File{}, err
+	}
+	c.module = opts.Module
+	if c.module == "" {
+		c.module = c.pkg.Name()
+	}
+	src, err := c.gen()
+	if err != nil {
+		return plugin.File{}, err
+	}
+	name := c.module + ".jce"
+	out, err := format.Source(name, src)
+	if err != nil {
+		return plugin.File{}, fmt.Errorf("%s: %v\n%s", name, err, src)
+	}
+	return plugin.File{Name: name, Content: string(out)}, nil
+}
+
+type converter struct {
+	fset   *token.FileSet
+	pkg    *types.Package
+	files  []*ast.File
+	module string
+	source string // package 的导入路径，不在 go module 中时为目录
+	// 类型检查的错误，引用的类型无效时给出
+	errs []error
+
+	docs     map[token.Pos]string         // 类型、字段、常量的注释，按名字的位置
+	fields   map[string]map[int]fieldInfo // jceschema 描述中的成员信息，按 go 的 struct 名、tag 索引
+	includes map[string]bool
+	refs     map[*types.TypeName]bool // 当前 struct 引用的同一 package 中的 struct
+}
+
+// 解析 package 并做类型检查，依赖的 package 从源码加载，加载失败时只影响用到它们的类型
+func load(dir string) (*converter, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	c := &converter{fset: token.NewFileSet(), source: bp.ImportPath, docs: map[token.Pos]string{}}
+	if c.source == "." {
+		c.source = dir
+	}
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(c.fset, filepath.Join(bp.Dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		c.files = append(c.files, f)
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(c.fset, "source", nil),
+		Error:    func(err error) { c.errs = append(c.errs, err) },
+	}
+	c.pkg, _ = conf.Check(bp.ImportPath, c.fset, c.files, nil)
+	c.collectDocs()
+	c.collectFields()
+	return c, nil
+}
+
+// jceschema 描述中成员的 require 和默认值，默认值为 jce 源码中的写法
+type fieldInfo struct {
+	require bool
+	def     string
+}
+
+// 从生成代码 init 中的 jceschema.RegisterStruct(&jceschema.StructDescriptor{...}) 收集成员信息，
+// 只识别字面量，其他写法忽略
+func (c *converter) collectFields() {
+	c.fields = map[string]map[int]fieldInfo{}
+	for _, f := range c.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "RegisterStruct" {
+				return true
+			}
+			ref, ok := call.Args[0].(*ast.UnaryExpr)
+			if !ok || ref.Op != token.AND {
+				return true
+			}
+			desc, ok := ref.X.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			name, fields := "", map[int]fieldInfo{}
+			for k, v := range keyValues(desc) {
+				switch k {
+				case "Name":
+					name = stringLit(v)
+				case "Fields":
+					list, _ := v.(*ast.CompositeLit)
+					if list == nil {
+						continue
+					}
+					for _, elt := range list.Elts {
+						fd, _ := elt.(*ast.CompositeLit)
+						if fd == nil {
+							continue
+						}
+						kv := keyValues(fd)
+						lit, _ := kv["Tag"].(*ast.BasicLit)
+						if lit == nil {
+							continue
+						}
+						tag, err := strconv.Atoi(lit.Value)
+						if err != nil {
+							continue
+						}
+						req, _ := kv["Require"].(*ast.Ident)
+						fields[tag] = fieldInfo{require: req != nil && req.Name == "true", def: stringLit(kv["Default"])}
+					}
+				}
+			}
+			if name != "" {
+				// 生成的 go 类型名首字母大写
+				c.fields[strings.ToUpper(name[:1])+name[1:]] = fields
+			}
+			return false
+		})
+	}
+}
+
+// 复合字面量中 key: value 形式的元素
+func keyValues(lit *ast.CompositeLit) map[string]ast.Expr {
+	ret := map[string]ast.Expr{}
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if id, ok := kv.Key.(*ast.Ident); ok {
+				ret[id.Name] = kv.Value
+			}
+		}
+	}
+	return ret
+}
+
+// 字符串字面量的值，不是字符串字面量时为空
+func stringLit(e ast.Expr) string {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return ""
+	}
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return ""
+	}
+	return s
+}
+
+func (c *converter) collectDocs() {
+	text := func(groups ...*ast.CommentGroup) string {
+		var lines []string
+		for _, g := range groups {
+			if s := strings.TrimSpace(g.Text()); s != "" {
+				lines = append(lines, s)
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	for _, f := range c.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.GenDecl:
+				for _, spec := range n.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						doc := s.Doc
+						if doc == nil && len(n.Specs) == 1 {
+							doc = n.Doc
+						}
+						c.docs[s.Name.Pos()] = text(doc)
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							c.docs[name.Pos()] = text(s.Doc, s.Comment)
+						}
+					}
+				}
+			case *ast.Field:
+				for _, name := range n.Names {
+					c.docs[name.Pos()] = text(n.Doc, n.Comment)
+				}
+			}
+			return true
+		})
+	}
+}
+
+func (c *converter) errorf(pos token.Pos, format string, args ...interface{}) error {
+	return fmt.Errorf("%s: %s", c.fset.Position(pos), fmt.Sprintf(format, args...))
+}
+
+// jce 的注释，去掉 go 注释开头的名字
+func comment(doc, name string) string {
+	doc = strings.TrimPrefix(doc, name+" ")
+	if doc == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(doc, "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// 是否有 tag:"N" 的字段
+func tagged(st *types.Struct) bool {
+	for i := 0; i < st.NumFields(); i++ {
+		if _, ok := reflect.StructTag(st.Tag(i)).Lookup("tag"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// enum 的成员
+type enumValue struct {
+	name  string
+	value int64
+	pos   token.Pos
+}
+
+// 底层为 int32 并且有常量的命名类型是 enum，返回 package 中该类型的常量，按取值和定义的顺序
+func enumValues(tn *types.TypeName) []enumValue {
+	named, ok := tn.Type().(*types.Named)
+	if !ok || tn.Pkg() == nil {
+		return nil
+	}
+	if b, ok := named.Underlying().(*types.Basic); !ok || b.Kind() != types.Int32 {
+		return nil
+	}
+	var values []enumValue
+	scope := tn.Pkg().Scope()
+	for _, name := range scope.Names() {
+		cst, ok := scope.Lookup(name).(*types.Const)
+		if !ok || !cst.Exported() || !types.Identical(cst.Type(), named) {
+			continue
+		}
+		v, _ := constant.Int64Val(cst.Val())
+		values = append(values, enumValue{name: strings.TrimPrefix(strings.TrimPrefix(name, tn.Name()), "_"), value: v, pos: cst.Pos()})
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].pos < values[j].pos })
+	return values
+}
+
+func (c *converter) gen() ([]byte, error) {
+	scope := c.pkg.Scope()
+	var enums, structs []*types.TypeName
+	var consts []*types.Const
+	for _, name := range scope.Names() {
+		switch obj := scope.Lookup(name).(type) {
+		case *types.TypeName:
+			if !obj.Exported() {
+				continue
+			}
+			if st, ok := obj.Type().Underlying().(*types.Struct); ok && tagged(st) {
+				structs = append(structs, obj)
+			} else if len(enumValues(obj)) > 0 {
+				enums = append(enums, obj)
+			}
+		case *types.Const:
+			consts = append(consts, obj)
+		}
+	}
+	byPos := func(objs []*types.TypeName) {
+		sort.Slice(objs, func(i, j int) bool { return objs[i].Pos() < objs[j].Pos() })
+	}
+	byPos(enums)
+	byPos(structs)
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+
+	var body bytes.Buffer
+	c.includes = map[string]bool{}
+	enumSet := map[*types.TypeName]bool{}
+	for _, en := range enums {
+		enumSet[en] = true
+		body.WriteString(comment(c.docs[en.Pos()], en.Name()))
+		fmt.Fprintf(&body, "enum %s\n{\n", en.Name())
+		for _, v := range enumValues(en) {
+			body.WriteString(comment(c.docs[v.pos], en.Name()+v.name))
+			fmt.Fprintf(&body, "%s = %d,\n", v.name, v.value)
+		}
+		body.WriteString("};\n\n")
+	}
+	for _, cst := range consts {
+		if named, ok := cst.Type().(*types.Named); ok && enumSet[named.Obj()] {
+			continue
+		}
+		if line, ok := c.constant(cst); ok {
+			body.WriteString(comment(c.docs[cst.Pos()], cst.Name()))
+			body.WriteString(line)
+		}
+	}
+
+	// jce 的类型需要先定义后使用，struct 按依赖排序
+	defs := map[*types.TypeName]string{}
+	deps := map[*types.TypeName]map[*types.TypeName]bool{}
+	for _, tn := range structs {
+		c.refs = map[*types.TypeName]bool{}
+		def, err := c.structDef(tn)
+		if err != nil {
+			return nil, err
+		}
+		defs[tn], deps[tn] = def, c.refs
+	}
+	done := map[*types.TypeName]bool{}
+	visiting := map[*types.TypeName]bool{}
+	var emit func(tn *types.TypeName) error
+	emit = func(tn *types.TypeName) error {
+		if done[tn] {
+			return nil
+		}
+		if visiting[tn] {
+			return c.errorf(tn.Pos(), "%s is recursive, which jce does not support", tn.Name())
+		}
+		visiting[tn] = true
+		var refs []*types.TypeName
+		for ref := range deps[tn] {
+			refs = append(refs, ref)
+		}
+		byPos(refs)
+		for _, ref := range refs {
+			if err := emit(ref); err != nil {
+				return err
+			}
+		}
+		done[tn] = true
+		body.WriteString(defs[tn])
+		return nil
+	}
+	for _, tn := range structs {
+		if err := emit(tn); err != nil {
+			return nil, err
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// code generated by jce2go %s.\n// source: %s\n\n", version.VERSION, c.source)
+	var incs []string
+	for inc := range c.includes {
+		incs = append(incs, inc)
+	}
+	sort.Strings(incs)
+	for _, inc := range incs {
+		fmt.Fprintf(&b, "#include \"%s.jce\"\n", inc)
+	}
+	if len(incs) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "module %s\n{\n", c.module)
+	b.Write(body.Bytes())
+	b.WriteString("};\n")
+	return b.Bytes(), nil
+}
+
+// 常量，只转换 jce 能表示的基本类型
+func (c *converter) constant(cst *types.Const) (string, bool) {
+	if !cst.Exported() {
+		return "", false
+	}
+	b, ok := cst.Type().Underlying().(*types.Basic)
+	if !ok {
+		return "", false
+	}
+	typ, ok := basics[b.Kind()]
+	val := cst.Val()
+	switch b.Kind() {
+	case types.UntypedInt, types.UntypedRune:
+		typ, ok = "int", true
+		if v, exact := constant.Int64Val(val); !exact || v != int64(int32(v)) {
+			typ = "long"
+		}
+	case types.UntypedFloat:
+		typ, ok = "double", true
+	case types.UntypedString:
+		typ, ok = "string", true
+	case types.UntypedBool:
+		typ, ok = "bool", true
+	}
+	if !ok {
+		return "", false
+	}
+
+	var s string
+	switch val.Kind() {
+	case constant.String:
+		s = strconv.Quote(constant.StringVal(val))
+	case constant.Float:
+		f, _ := constant.Float64Val(val)
+		s = strconv.FormatFloat(f, 'g', -1, 64)
+	case constant.Int:
+		if _, exact := constant.Int64Val(val); !exact {
+			return "", false
+		}
+		s = val.ExactString()
+	default:
+		s = val.ExactString()
+	}
+	return fmt.Sprintf("const %s %s = %s;\n\n", typ, cst.Name(), s), true
+}
+
+// go 的基本类型对应的 jce 类型
+var basics = map[types.BasicKind]string{
+	types.Bool:    "bool",
+	types.Int8:    "byte",
+	types.Uint8:   "unsigned byte",
+	types.Int16:   "short",
+	types.Uint16:  "unsigned short",
+	types.Int32:   "int",
+	types.Uint32:  "unsigned int",
+	types.Int64:   "long",
+	types.Float32: "float",
+	types.Float64: "double",
+	types.String:  "string",
+}
+
+func (c *converter) structDef(tn *types.TypeName) (string, error) {
+	st := tn.Type().Underlying().(*types.Struct)
+	var b bytes.Buffer
+	b.WriteString(comment(c.docs[tn.Pos()], tn.Name()))
+	fmt.Fprintf(&b, "struct %s\n{\n", tn.Name())
+	tags := map[int]string{}
+	names := map[string]bool{}
+	for i := 0; i < st.NumFields(); i++ {
+		fd := st.Field(i)
+		where := tn.Name() + "." + fd.Name()
+		tag := reflect.StructTag(st.Tag(i))
+		s, ok := tag.Lookup("tag")
+		if !ok {
+			return "", c.errorf(fd.Pos(), "%s: missing tag:\"N\"", where)
+		}
+		if fd.Embedded() {
+			return "", c.errorf(fd.Pos(), "%s: embedded field is not supported", where)
+		}
+		if !fd.Exported() {
+			return "", c.errorf(fd.Pos(), "%s: unexported field is not supported", where)
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 255 {
+			return "", c.errorf(fd.Pos(), "%s: invalid tag %q, expect 0-255", where, s)
+		}
+		if prev, ok := tags[n]; ok {
+			return "", c.errorf(fd.Pos(), "%s: tag %d already used by %s", where, n, prev)
+		}
+		tags[n] = fd.Name()
+
+		name := fd.Name()
+		if js := strings.Split(tag.Get("json"), ",")[0]; js != "" && js != "-" {
+			name = js
+		}
+		if names[name] {
+			return "", c.errorf(fd.Pos(), "%s: name %s already used", where, name)
+		}
+		names[name] = true
+
+		suffix := ""
+		t := fd.Type()
+		if arr, ok := t.(*types.Array); ok {
+			suffix = fmt.Sprintf("[%d]", arr.Len())
+			t = arr.Elem()
+		}
+		typ, err := c.typ(t)
+		if err != nil {
+			if strings.HasPrefix(err.Error(), "invalid type") {
+				err = fmt.Errorf("invalid type: %v", c.errorAt(fd.Pos()))
+			}
+			return "", c.errorf(fd.Pos(), "%s: %v", where, err)
+		}
+		info := c.fields[tn.Name()][n]
+		req, def := "optional", ""
+		if info.require {
+			req = "require"
+		}
+		if info.def != "" {
+			def = " = " + info.def
+			// enum 的成员名去掉了类型名前缀，首字母大写
+			if named, ok := t.(*types.Named); ok && len(enumValues(named.Obj())) > 0 {
+				def = " = " + strings.ToUpper(info.def[:1]) + info.def[1:]
+			}
+		}
+		b.WriteString(comment(c.docs[fd.Pos()], fd.Name()))
+		fmt.Fprintf(&b, "%d %s %s %s%s%s;\n", n, req, typ, name, suffix, def)
+	}
+	b.WriteString("};\n\n")
+	return b.String(), nil
+}
+
+// 类型的 jce 写法
+func (c *converter) typ(t types.Type) (string, error) {
+	switch t := t.(type) {
+	case *types.Basic:
+		if t.Kind() == types.Invalid {
+			return "", fmt.Errorf("invalid type")
+		}
+		if s, ok := basics[t.Kind()]; ok {
+			return s, nil
+		}
+		switch t.Kind() {
+		case types.Int, types.Uint:
+			return "", fmt.Errorf("%s has platform dependent size, use int32 or int64", t)
+		case types.Uint64:
+			return "", fmt.Errorf("jce has no unsigned long")
+		}
+	case *types.Slice:
+		elem, err := c.typ(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "vector<" + elem + ">", nil
+	case *types.Map:
+		key, err := c.typ(t.Key())
+		if err != nil {
+			return "", err
+		}
+		val, err := c.typ(t.Elem())
+		if err != nil {
+			return "", err
+		}
+		return "map<" + key + ", " + val + ">", nil
+	case *types.Array:
+		return "", fmt.Errorf("array is only supported as field type")
+	case *types.Named:
+		return c.named(t)
+	}
+	return "", fmt.Errorf("%s has no jce equivalent", t)
+}
+
+func (c *converter) named(t *types.Named) (string, error) {
+	tn := t.Obj()
+	if tn.Pkg() == nil {
+		return "", fmt.Errorf("%s has no jce equivalent", t)
+	}
+	name := tn.Name()
+	if tn.Pkg() != c.pkg {
+		name = tn.Pkg().Name() + "::" + name
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		if !tagged(u) {
+			return "", fmt.Errorf("struct %s has no tag:\"N\" field", t)
+		}
+		if tn.Pkg() == c.pkg {
+			c.refs[tn] = true
+		} else {
+			c.includes[tn.Pkg().Name()] = true
+		}
+		return name, nil
+	case *types.Basic:
+		if len(enumValues(tn)) > 0 {
+			if tn.Pkg() != c.pkg {
+				c.includes[tn.Pkg().Name()] = true
+			}
+			return name, nil
+		}
+	}
+	// 其他命名类型按底层类型转换
+	return c.typ(t.Underlying())
+}
+
+// 类型检查时同一行的错误，说明类型为什么无效
+func (c *converter) errorAt(pos token.Pos) error {
+	p := c.fset.Position(pos)
+	for _, err := range c.errs {
+		if te, ok := err.(types.Error); ok {
+			if q := c.fset.Position(te.Pos); q.Filename == p.Filename && q.Line == p.Line {
+				return err
+			}
+		}
+	}
+	if len(c.errs) > 0 {
+		return c.errs[0]
+	}
+	return fmt.Errorf("unknown error")
+}
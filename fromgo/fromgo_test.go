@@ -0,0 +1,146 @@
+package fromgo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/parser"
+)
+
+const src = `package shop
+
+// Order 订单
+type Order struct {
+	Items []Item             ` + "`json:\"items\" tag:\"0\"`" + `
+	Color Color              ` + "`json:\"color,omitempty\" tag:\"1\"`" + `
+	Raw   []uint8            ` + "`json:\"raw\" tag:\"2\"`" + `
+	Fixed [4]int16           ` + "`json:\"fixed\" tag:\"3\"`" + `
+	Attrs map[string]float64 ` + "`tag:\"4\"`" + ` // 属性
+	Sum   uint32             ` + "`json:\"sum\" tag:\"5\"`" + `
+}
+
+// Color 颜色
+type Color int32
+
+const (
+	ColorRed   Color = 1
+	ColorGreen Color = 2
+)
+
+const MaxItems = 10
+
+const Name = "shop"
+
+// Item 商品
+type Item struct {
+	ID   int64    ` + "`json:\"id\" tag:\"0\"`" + `
+	Tags []string ` + "`json:\"tags\" tag:\"1\"`" + `
+}
+
+// 没有 tag 的 struct 被忽略
+type Other struct {
+	N int
+}
+`
+
+func convert(t *testing.T, code string) (string, error) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "shop.go"), []byte(code), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Convert(dir, Options{})
+	return f.Content, err
+}
+
+func TestConvert(t *testing.T) {
+	code, err := convert(t, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseSource("shop.jce", []byte(code)); err != nil {
+		t.Fatalf("%v:\n%s", err, code)
+	}
+	for _, s := range []string{
+		"module shop",
+		"// 颜色\n    enum Color\n    {\n        Red = 1,\n        Green = 2,",
+		"const int MaxItems = 10;",
+		`const string Name = "shop";`,
+		"// 订单\n    struct Order",
+		"0 optional vector<Item>",
+		"1 optional Color",
+		"color;",
+		"2 optional vector<unsigned byte>",
+		"3 optional short",
+		"fixed[4];",
+		"// 属性\n        4 optional map<string, double>",
+		"Attrs;",
+		"5 optional unsigned int",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+	if strings.Index(code, "struct Item") > strings.Index(code, "struct Order") || strings.Contains(code, "Other") {
+		t.Errorf("unexpected structs:\n%s", code)
+	}
+
+	// jce2go 生成的代码中注册的描述
+	code, err = convert(t, `package base
+
+import "github.com/erpc-go/jce2go/jceschema"
+
+type Item struct {
+	Id   int32  `+"`json:\"id\" tag:\"0\"`"+`
+	Name string `+"`json:\"name\" tag:\"1\"`"+`
+	Num  int32  `+"`json:\"num\" tag:\"2\"`"+`
+	Kind Kind   `+"`json:\"kind\" tag:\"3\"`"+`
+}
+
+type Kind int32
+
+const (
+	KindEBig   Kind = 1
+	KindESmall Kind = 2
+)
+
+func init() {
+	jceschema.RegisterStruct(&jceschema.StructDescriptor{
+		Module: "base",
+		Name:   "item",
+		Fields: []jceschema.Field{
+			{Tag: 0, Name: "id", Require: true},
+			{Tag: 1, Name: "name", Default: "\"none\""},
+			{Tag: 2, Name: "num"},
+			{Tag: 3, Name: "kind", Default: "eSmall"},
+		},
+	})
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"0 require  int    id;", `1 optional string name = "none";`, "2 optional int    num;", "3 optional Kind   kind = ESmall;"} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+
+	for _, c := range []struct{ field, err string }{
+		{"N int `tag:\"0\"`", "platform dependent"},
+		{"N uint64 `tag:\"0\"`", "unsigned long"},
+		{"N *int32 `tag:\"0\"`", "no jce equivalent"},
+		{"N int32 `tag:\"0\"`\n\tM int32", "missing tag"},
+		{"N int32 `tag:\"0\"`\n\tM int32 `tag:\"0\"`", "tag 0 already used"},
+		{"N int32 `tag:\"256\"`", "invalid tag"},
+		{"N []A `tag:\"0\"`", "recursive"},
+		{"N [][2]int32 `tag:\"0\"`", "array"},
+		{"N undefined.T `tag:\"0\"`", "undefined"},
+	} {
+		_, err := convert(t, "package bad\n\ntype A struct {\n\t"+c.field+"\n}\n")
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expect error %q, got %v", c.field, c.err, err)
+		}
+	}
+}
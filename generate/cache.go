@@ -0,0 +1,210 @@
+package generate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// CacheEntry 一个输入文件上一次生成时的状态，所有的 hash 都是 sha256
+type CacheEntry struct {
+	Version string            `json:"version"` // jce2go 版本
+	Options string            `json:"options"` // 影响生成结果的参数
+	Sources map[string]string `json:"sources"` // 输入文件以及它直接、间接 include 的文件
+	Outputs map[string]string `json:"outputs"` // 生成的文件
+}
+
+// Cache 增量生成的缓存清单，按输入文件记录。版本、参数、源文件都没有变化，
+// 并且生成的文件没有被修改或者删除时，跳过这个输入文件
+type Cache struct {
+	path    string
+	Entries map[string]*CacheEntry `json:"entries"`
+}
+
+// LoadCache 读取缓存清单，文件不存在时返回空的清单
+func LoadCache(path string) (*Cache, error) {
+	c := &Cache{path: path, Entries: map[string]*CacheEntry{}}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid cache %s: %v", path, err)
+	}
+	if c.Entries == nil {
+		c.Entries = map[string]*CacheEntry{}
+	}
+	return c, nil
+}
+
+// Save 写入缓存清单
+func (c *Cache) Save() error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, append(b, '\n'), 0o666)
+}
+
+// 与上一次生成时相比是否没有变化
+func (c *Cache) fresh(input string, e *CacheEntry) bool {
+	old := c.Entries[filepath.Clean(input)]
+	if old == nil || old.Version != e.Version || old.Options != e.Options || !equalHashes(old.Sources, e.Sources) || len(old.Outputs) == 0 {
+		return false
+	}
+	for name, sum := range old.Outputs {
+		if h, err := hashFile(name); err != nil || h != sum {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cache) record(input string, e *CacheEntry) {
+	c.Entries[filepath.Clean(input)] = e
+}
+
+func equalHashes(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func hashBytes(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFile(name string) (string, error) {
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(b), nil
+}
+
+// 文件以及它直接、间接 include 的文件的 hash，按文件名索引
+func sourceHashes(p *parser.Parser) (map[string]string, error) {
+	sums := map[string]string{}
+	var visit func(p *parser.Parser) error
+	visit = func(p *parser.Parser) error {
+		name := filepath.Clean(p.Filepath)
+		if _, ok := sums[name]; ok {
+			return nil
+		}
+		h, err := hashFile(p.Filepath)
+		if err != nil {
+			return err
+		}
+		sums[name] = h
+		for _, inc := range p.IncParse {
+			if err := visit(inc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return sums, visit(p)
+}
+
+// SourceHash 文件以及它 include 的所有文件内容的 hash，写在生成的文件头中，用于检查生成的文件是否过期。
+// 只与内容有关，与文件所在的目录无关
+func SourceHash(p *parser.Parser) (string, error) {
+	sums := map[string]bool{}
+	h := sha256.New()
+	var visit func(p *parser.Parser) error
+	visit = func(p *parser.Parser) error {
+		name := filepath.Clean(p.Filepath)
+		if sums[name] {
+			return nil
+		}
+		sums[name] = true
+		b, err := ioutil.ReadFile(p.Filepath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\n", hashBytes(b))
+		for _, inc := range p.IncParse {
+			if err := visit(inc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(p); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 内置模板的 hash，模板修改后版本号不一定改变，也需要重新生成
+func builtinTemplatesHash() (string, error) {
+	entries, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, e := range entries {
+		b, err := templateFS.ReadFile("templates/" + e.Name())
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s=%s\n", e.Name(), hashBytes(b))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 影响生成结果的参数，内置的模板和覆盖的模板都按内容计算
+func (gen *Generate) optionsKey() (string, error) {
+	builtin, err := builtinTemplatesHash()
+	if err != nil {
+		return "", err
+	}
+	key := fmt.Sprintf("mod=%s out=%s json=%t codec=%s schema=%s templates=%s", gen.module, gen.prefix, gen.jsonOmitEmpty, gen.codecPath, gen.schemaPath, builtin)
+	if gen.templateDir == "" {
+		return key, nil
+	}
+	files, err := filepath.Glob(filepath.Join(gen.templateDir, "*.tmpl"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		h, err := hashFile(f)
+		if err != nil {
+			return "", err
+		}
+		key += fmt.Sprintf(" %s=%s", filepath.Base(f), h)
+	}
+	return key, nil
+}
+
+// 当前的状态，Outputs 在生成后填写
+func (gen *Generate) cacheEntry() (*CacheEntry, error) {
+	opts, err := gen.optionsKey()
+	if err != nil {
+		return nil, err
+	}
+	sources, err := sourceHashes(gen.p)
+	if err != nil {
+		return nil, err
+	}
+	return &CacheEntry{Version: version.VERSION, Options: opts, Sources: sources, Outputs: map[string]string{}}, nil
+}
@@ -0,0 +1,329 @@
+package generate
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/utils"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// 模板的数据模型
+//
+// 生成一个 go 文件时执行 file.tmpl，数据为 *File，其余模板由它调用：
+//
+//	file.tmpl        *File    文件头、package、import，依次调用下面的模板
+//	enum.tmpl        *Enum    枚举类型和常量
+//	const.tmpl       *File    所有常量（.Consts）
+//	struct.tmpl      *Struct  结构体定义和 resetDefault
+//	reader.tmpl      *Struct  ReadFrom，成员的读取由其中定义的 readVar 模板递归生成
+//	writer.tmpl      *Struct  WriteTo，成员的写入由其中定义的 writeVar 模板递归生成
+//	descriptor.tmpl  *File    在 init 中注册 jceschema 结构描述，SchemaPath 为空时不注册
+//
+// 除了数据的字段和方法，模板中还可以使用以下函数：
+//
+//	goType      *parser.VarType 对应的 go 类型，如 map[string]base.Request
+//	upper       首字母大写
+//	quote       strconv.Quote
+//	schemaType  *parser.VarType 对应的 jceschema.Type 字面量
+//	trimComment 去掉注释的 //、/* */ 和首尾空白
+
+// File 一个 jce 文件
+type File struct {
+	Version        string // jce2go 版本
+	Source         string // jce 文件名，不含目录
+	SourceHash     string // jce 文件以及它 include 的文件内容的 hash，见 SourceHash
+	Module         string // 包名
+	ModuleComment  string // module 前的注释，原样输出
+	IncludeComment string // #include 前的注释，原样输出
+	CodecPath      string // 编解码包的导入路径
+	SchemaPath     string // jceschema 的导入路径，没有指定 -descriptor 或者没有 struct、enum 时为空
+	Imports        []string
+	Enums          []*Enum
+	Consts         []*Const
+	Structs        []*Struct
+}
+
+// Enum 枚举，没有成员时 file.tmpl 不生成类型定义，只注册结构描述
+type Enum struct {
+	Name        string // go 类型名
+	OriginName  string // jce 中的名字
+	Module      string
+	TypeComment string // 枚举名前的注释，原样输出
+	Comment     string // 枚举名和 { 之间的注释，原样输出
+	Members     []*EnumMember
+}
+
+// EnumMember 枚举成员，IsComment 为 true 时只是成员之间单独的一行注释
+type EnumMember struct {
+	Name       string // go 常量名，枚举名 + 成员名
+	OriginName string // jce 中的名字
+	Value      string // 常量的值：数字，或者引用的其他成员的常量名
+	Number     int32  // 计算后的取值
+	Comment    string // 行尾注释，原样输出
+	IsComment  bool
+}
+
+// Const 常量
+type Const struct {
+	Name       string // go 常量名
+	OriginName string
+	Type       string // go 类型
+	Value      string
+	PreComment string // 原样输出
+	Comment    string // 行尾注释，原样输出
+}
+
+// Struct 结构体
+type Struct struct {
+	Name          string // go 类型名
+	OriginName    string // jce 中的名字
+	Module        string
+	Comment       string // 原样输出
+	JSONOmitEmpty bool   // json tag 是否使用 omitempty
+	Members       []*Member
+
+	vc *counter
+}
+
+// Member 结构体成员，CommentLine 不为空时只是成员之间单独的一行注释
+type Member struct {
+	Name          string // go 字段名
+	OriginName    string // jce 中的名字，用作 json tag
+	Tag           int32
+	Require       bool
+	Type          *parser.VarType
+	GoType        string
+	Default       string // 默认值的 go 表达式，没有默认值时为空
+	OriginDefault string // 源码中的默认值
+	Comment       string // 行尾注释，原样输出
+	CommentLine   string
+
+	vc *counter
+}
+
+// 生成唯一变量名的计数器，每个结构体从 0 开始
+type counter struct {
+	n int
+}
+
+// Var 读写的一个变量：结构体成员，或者 vector、map 的元素
+type Var struct {
+	Type    *parser.VarType
+	Key     string // 成员名或者元素的表达式，如 Arr2[i0]、k3
+	Prefix  string // 结构体成员为 st.，元素为空
+	Tag     int32
+	Require bool
+
+	vc *counter
+}
+
+// Var 成员作为读写的变量
+func (m *Member) Var() *Var {
+	return &Var{Type: m.Type, Key: m.Name, Prefix: "st.", Tag: m.Tag, Require: m.Require, vc: m.vc}
+}
+
+// Fields 不包括注释行的成员
+func (st *Struct) Fields() []*Member {
+	var ret []*Member
+	for _, m := range st.Members {
+		if m.CommentLine == "" {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+// NewVar 分配一个新的变量编号
+func (v *Var) NewVar() string {
+	n := v.vc.n
+	v.vc.n++
+	return strconv.Itoa(n)
+}
+
+// Ref 引用变量的表达式，如 st.B、k3
+func (v *Var) Ref() string {
+	return v.Prefix + v.Key
+}
+
+// Name 变量名，元素变量会去掉括号
+func (v *Var) Name() string {
+	if v.Prefix != "" {
+		return v.Prefix + v.Key
+	}
+	return strings.Trim(v.Key, "()")
+}
+
+// Kind 变量的种类：vector、array、map、enum、struct、basic
+func (v *Var) Kind() string {
+	switch v.Type.Type {
+	case lex.TkTVector:
+		return "vector"
+	case lex.TkTArray:
+		return "array"
+	case lex.TkTMap:
+		return "map"
+	case lex.TkName:
+		if v.Type.CType == lex.TkEnum {
+			return "enum"
+		}
+		return "struct"
+	}
+	return "basic"
+}
+
+// IsSimpleList 是否是 vector<byte>，使用 SimpleList 编码
+func (v *Var) IsSimpleList() bool {
+	return v.Type.TypeK != nil && v.Type.TypeK.Type == lex.TkTByte
+}
+
+// RequireString require 的 go 字面量
+func (v *Var) RequireString() string {
+	return strconv.FormatBool(v.Require)
+}
+
+// ElemAt vector 第 i 个元素，读取时使用
+func (v *Var) ElemAt(i string) *Var {
+	return &Var{Type: v.Type.TypeK, Key: v.Key + "[i" + i + "]", Prefix: v.Prefix, vc: v.vc}
+}
+
+// Elem vector 的元素变量，写入时使用
+func (v *Var) Elem(name string) *Var {
+	return &Var{Type: v.Type.TypeK, Key: name, vc: v.vc}
+}
+
+// MapKey map 的 key 变量，tag 为 0
+func (v *Var) MapKey(name string) *Var {
+	return &Var{Type: v.Type.TypeK, Key: name, vc: v.vc}
+}
+
+// MapValue map 的 value 变量，tag 为 1
+func (v *Var) MapValue(name string) *Var {
+	return &Var{Type: v.Type.TypeV, Key: name, Tag: 1, vc: v.vc}
+}
+
+// buildFile 从语法分析树构建模板数据，不修改语法分析树
+func (gen *Generate) buildFile() *File {
+	p := gen.p
+	f := &File{
+		Version:        version.VERSION,
+		Source:         filepath.Base(gen.filepath),
+		SourceHash:     gen.sourceHash,
+		Module:         p.Module,
+		ModuleComment:  p.ModuleComment,
+		IncludeComment: p.IncludeComment,
+		CodecPath:      gen.codecPath,
+		Imports:        gen.structImports(),
+	}
+	if len(p.Enums) > 0 || len(p.Structs) > 0 {
+		f.SchemaPath = gen.schemaPath
+	}
+
+	for i := range p.Enums {
+		f.Enums = append(f.Enums, buildEnum(p.Module, &p.Enums[i]))
+	}
+
+	for _, cst := range p.Consts {
+		f.Consts = append(f.Consts, &Const{
+			Name:       utils.UpperFirstLetter(cst.Name),
+			OriginName: cst.Name,
+			Type:       gen.genType(cst.Type),
+			Value:      cst.Value,
+			PreComment: cst.PreComment,
+			Comment:    cst.Comment,
+		})
+	}
+
+	for i := range p.Structs {
+		f.Structs = append(f.Structs, gen.buildStruct(&p.Structs[i]))
+	}
+	return f
+}
+
+func buildEnum(module string, en *parser.EnumInfo) *Enum {
+	name := utils.UpperFirstLetter(en.Name)
+	constName := func(key string) string {
+		return name + utils.UpperFirstLetter(key)
+	}
+
+	values := en.Values()
+	defined := map[string]bool{}
+	ret := &Enum{Name: name, OriginName: en.Name, Module: module, TypeComment: en.TypeComment, Comment: en.Comment}
+	for _, mb := range en.Member {
+		if mb.Type == parser.EnumTypeComment {
+			ret.Members = append(ret.Members, &EnumMember{Comment: mb.Comment, IsComment: true})
+			continue
+		}
+
+		m := &EnumMember{
+			Name:       constName(mb.Key),
+			OriginName: mb.Key,
+			Value:      strconv.Itoa(int(values[mb.Key])),
+			Number:     values[mb.Key],
+			Comment:    mb.Comment,
+		}
+		if mb.Type == parser.EnumTypeName {
+			if _, ok := defined[mb.Name]; !ok {
+				panic(mb.Name + " not define before use.")
+			}
+			m.Value = constName(mb.Name)
+		}
+		defined[mb.Key] = true
+		ret.Members = append(ret.Members, m)
+	}
+	return ret
+}
+
+func (gen *Generate) buildStruct(st *parser.StructInfo) *Struct {
+	ret := &Struct{
+		Name:          utils.UpperFirstLetter(st.Name),
+		OriginName:    st.Name,
+		Module:        gen.p.Module,
+		Comment:       st.Comment,
+		JSONOmitEmpty: gen.jsonOmitEmpty,
+		vc:            &counter{},
+	}
+
+	for _, mb := range st.Member {
+		if mb.CommentType != "" {
+			ret.Members = append(ret.Members, &Member{CommentLine: mb.CommentType})
+			continue
+		}
+		ret.Members = append(ret.Members, &Member{
+			Name:          utils.UpperFirstLetter(mb.Key),
+			OriginName:    mb.Key,
+			Tag:           mb.Tag,
+			Require:       mb.Require,
+			Type:          mb.Type,
+			GoType:        gen.genType(mb.Type),
+			Default:       mb.Default,
+			OriginDefault: mb.OriginDefault,
+			Comment:       mb.Comment,
+			vc:            ret.vc,
+		})
+	}
+	return ret
+}
+
+// struct 依赖的其他 module 的包，去重并排序
+func (gen *Generate) structImports() []string {
+	set := map[string]bool{}
+	for _, st := range gen.p.Structs {
+		for k := range st.DependModule {
+			if imp := gen.genStructImport(k); imp != "" {
+				set[imp] = true
+			}
+		}
+	}
+
+	ret := make([]string, 0, len(set))
+	for k := range set {
+		ret = append(ret, k)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,56 @@
+package generate
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// 生成 jceschema.Type 的字面量
+func (gen *Generate) genDescriptorType(ty *parser.VarType) string {
+	kind := ""
+	switch ty.Type {
+	case lex.TkTBool:
+		kind = "Bool"
+	case lex.TkTByte:
+		kind = "Byte"
+	case lex.TkTShort:
+		kind = "Short"
+	case lex.TkTInt:
+		kind = "Int"
+	case lex.TkTLong:
+		kind = "Long"
+	case lex.TkTFloat:
+		kind = "Float"
+	case lex.TkTDouble:
+		kind = "Double"
+	case lex.TkTString:
+		kind = "String"
+	case lex.TkTVector:
+		return "&jceschema.Type{Kind: jceschema.Vector, Elem: " + gen.genDescriptorType(ty.TypeK) + "}"
+	case lex.TkTArray:
+		return "&jceschema.Type{Kind: jceschema.Array, Elem: " + gen.genDescriptorType(ty.TypeK) + ", Len: " + strconv.Itoa(int(ty.TypeL)) + "}"
+	case lex.TkTMap:
+		return "&jceschema.Type{Kind: jceschema.Map, Key: " + gen.genDescriptorType(ty.TypeK) + ", Elem: " + gen.genDescriptorType(ty.TypeV) + "}"
+	case lex.TkName:
+		kind = "Struct"
+		if ty.CType == lex.TkEnum {
+			kind = "Enum"
+		}
+		// 同 module 的类型名没有 module 前缀
+		name := ty.TypeSt
+		if !strings.Contains(name, "::") {
+			name = gen.p.Module + "::" + name
+		}
+		return "&jceschema.Type{Kind: jceschema." + kind + ", Name: " + strconv.Quote(strings.Replace(name, "::", ".", 1)) + "}"
+	default:
+		panic("Unknown Type " + lex.TokenMap[ty.Type])
+	}
+
+	if ty.Unsigned {
+		return "&jceschema.Type{Kind: jceschema." + kind + ", Unsigned: true}"
+	}
+	return "&jceschema.Type{Kind: jceschema." + kind + "}"
+}
@@ -8,19 +8,20 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
+	"sort"
 	"strings"
 
 	"github.com/erpc-go/jce2go/lex"
 	"github.com/erpc-go/jce2go/log"
 	"github.com/erpc-go/jce2go/parser"
 	"github.com/erpc-go/jce2go/utils"
-	"github.com/erpc-go/jce2go/version"
 )
 
 // 全局 map 避免重复生成
 var (
 	fileMap = make(map[string]bool, 0)
+	// 已经生成的文件写入的文件及其 hash，被多个输入文件 include 时都记录到它们的缓存中
+	fileOutputs = make(map[string]map[string]string, 0)
 )
 
 // Generate record go code information.
@@ -28,13 +29,18 @@ var (
 type Generate struct {
 	I             []string       // imports with path
 	code          bytes.Buffer   // 最终生成的代码
-	vc            int            // var count. Used to generate unique variable names
 	filepath      string         // 当前解析的 jce 文件
 	codecPath     string         // 生成后的代码依赖的基础 codec 代码
+	schemaPath    string         // 生成后的代码注册结构描述的包，为空时不注册，见 WithDescriptor
 	module        string         // 包名
 	prefix        string         // 最终的生成目录
 	p             *parser.Parser // 当前文件生成的语法分析树
 	jsonOmitEmpty bool
+	templateDir   string            // 覆盖内置模板的目录
+	cache         *Cache            // 增量生成的缓存清单，为空时总是生成
+	entry         *CacheEntry       // 本次生成的状态，记录到 cache 中
+	sourceHash    string            // 源文件以及 include 的文件的 hash，见 SourceHash
+	outputs       map[string][]byte // 不为空时只在内存中生成，不写入文件，见 Check
 }
 
 // NewGenerate build up a new path
@@ -50,7 +56,6 @@ func NewGenerate(path string, module string, outdir string, jsonOmitEmpty bool)
 	return &Generate{
 		I:        []string{},
 		code:     bytes.Buffer{},
-		vc:       0,
 		filepath: path,
 
 		codecPath:     "github.com/erpc-go/jce-codec",
@@ -61,640 +66,190 @@ func NewGenerate(path string, module string, outdir string, jsonOmitEmpty bool)
 	}
 }
 
-// Gen to parser file.
-func (gen *Generate) Gen() {
-	// recover  panic
-	defer func() {
-		if err := recover(); err != nil {
-			log.Raw("%s", err)
-			os.Exit(1)
-		}
-	}()
-
-	// 解析文件
-	gen.p = parser.ParseFile(gen.filepath, make([]string, 0))
-
-	log.Debug("begin generate file:%s", gen.filepath)
-
-	// 开始代码生成
-	gen.genAll()
+// WithTemplates 使用 dir 中的同名模板覆盖内置的模板，见 data.go
+func (gen *Generate) WithTemplates(dir string) *Generate {
+	gen.templateDir = dir
+	return gen
 }
 
-func (gen *Generate) genAll() {
-	if fileMap[gen.filepath] {
-		return
-	}
-
-	if len(gen.p.Enums) == 0 && len(gen.p.Consts) == 0 && len(gen.p.Structs) == 0 {
-		return
-	}
-	log.Debug("hhh")
-
-	gen.genIncludeFiles()
-	gen.genFileComment()
-	gen.genPackage()
-	gen.genEnums()
-	gen.genConst()
-	gen.genStructs()
-	gen.saveFiles()
-
-	fileMap[gen.filepath] = true
-}
-
-// 先生成依赖的其他文件，即 include 的其他文件
-func (gen *Generate) genIncludeFiles() {
-	for _, v := range gen.p.IncParse {
-		NewGenerate(v.Filepath, gen.module, gen.prefix, gen.jsonOmitEmpty).genAll()
+// WithDescriptor 生成的代码在 init 中把 struct、enum 的描述注册到 jceschema，
+// 生成的代码会依赖 github.com/erpc-go/jce2go/jceschema
+func (gen *Generate) WithDescriptor(on bool) *Generate {
+	gen.schemaPath = ""
+	if on {
+		gen.schemaPath = "github.com/erpc-go/jce2go/jceschema"
 	}
+	return gen
 }
 
-// genFileComment 写文件注释
-func (gen *Generate) genFileComment() {
-	gen.writeString(`// DO NOT EDIT IT.` + ` 
-// code generated by jce2go ` + version.VERSION + `. 
-// source: ` + filepath.Base(gen.filepath) + `
-
-`)
+// WithCache 使用缓存清单，版本、参数、源文件以及生成的文件都没有变化时跳过生成
+func (gen *Generate) WithCache(c *Cache) *Generate {
+	gen.cache = c
+	return gen
 }
 
-// genPackage 写包名、导第三方包
-func (gen *Generate) genPackage() {
-	// [setp 1] 包名
-	gen.writeString(gen.p.ModuleComment)
-	gen.writeString("package " + gen.p.Module + "\n\n")
-
-	// [step 2] 导基础包
-	gen.writeString(gen.p.IncludeComment)
-	gen.writeString(`
-import (
-	"fmt"
-    "io"
-
-`)
-
-	// [step 3] 包依赖的第三方包
-	gen.genImports()
-
-	// [step 4] 写占位
-	gen.writeString(`)
-
-// 占位使用，避免导入的这些包没有被使用
-var _ = fmt.Errorf
-var _ = io.ReadFull
-var _ = jce.Int1
-
-`)
+// Mismatch 生成的结果与磁盘上的文件不一致
+type Mismatch struct {
+	Path string
+	Diff string // unified diff，a 为磁盘上的文件，b 为生成的结果
 }
 
-// 导第三方包
-func (gen *Generate) genImports() {
-	// [step 1] 导 jce 编码包
-	gen.writeString("\"" + gen.codecPath + "\"\n")
-
-	// [step 2] 导 struct 依赖的包
-	for _, st := range gen.p.Structs {
-		for k := range st.DependModule {
-			gen.genStructImport(k)
-		}
+// Check 在内存中生成，与磁盘上已有的文件比较，返回不一致的文件，不写入任何文件，也不使用缓存
+func (gen *Generate) Check() ([]Mismatch, error) {
+	gen.cache = nil
+	gen.outputs = map[string][]byte{}
+	if err := gen.Run(); err != nil {
+		return nil, err
 	}
-}
-
-// 导 struct 依赖的包
-func (gen *Generate) genStructImport(module string) {
-	moduleStr := module
 
-	for _, p := range gen.I {
-		if strings.HasSuffix(p, "/"+moduleStr) {
-			gen.writeString(`"` + moduleStr + `"` + "\n")
-			return
-		}
-	}
-
-	if gen.module == "" {
-		return
-	}
-
-	mf := filepath.Clean(filepath.Join(gen.module, gen.prefix))
-
-	if runtime.GOOS == "windows" {
-		mf = strings.ReplaceAll(mf, string(os.PathSeparator), string('/'))
-	}
-
-	moduleStr = fmt.Sprintf("%s/%s", mf, moduleStr)
-
-	gen.writeString(`"` + moduleStr + `"` + "\n")
-
-	return
-}
-
-// 写枚举
-func (gen *Generate) genEnums() {
-	for _, v := range gen.p.Enums {
-		gen.genEnum(&v)
-	}
-}
-
-func (gen *Generate) genEnum(en *parser.EnumInfo) {
-	if len(en.Member) == 0 {
-		return
+	paths := make([]string, 0, len(gen.outputs))
+	for path := range gen.outputs {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
-	en.Rename()
-
-	gen.writeString(en.TypeComment)
-	gen.writeString("type " + en.Name + " int32\n")
-	gen.writeString(en.Comment + "\n")
-	gen.writeString("const (\n")
-
-	var it int32
-
-	for _, v := range en.Member {
-		if v.Type == parser.EnumTypeValue {
-			// use value
-			gen.writeString(gen.makeEnumName(en, &v) + " " + en.Name + ` = ` + strconv.Itoa(int(v.Value)) + v.Comment + "\n")
-			it = v.Value + 1
-			continue
+	var mismatches []Mismatch
+	for _, path := range paths {
+		aName := "a/" + path
+		old, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			aName = "/dev/null"
+		} else if err != nil {
+			return nil, err
 		}
-
-		if v.Type == parser.EnumTypeName {
-			// use name
-			find := false
-
-			for _, ref := range en.Member {
-				if ref.Key == v.Name {
-					find = true
-					gen.writeString(gen.makeEnumName(en, &v) + " " + en.Name + ` = ` + gen.makeEnumName(en, &ref) + v.Comment + "\n")
-					it = ref.Value + 1
-					break
-				}
-
-				if ref.Key == v.Key {
-					break
-				}
-			}
-
-			if !find {
-				panic(v.Name + " not define before use.")
-			}
-			continue
-		}
-		if v.Type == parser.EnumTypeComment {
-			gen.writeString("    " + v.Comment + "\n")
-			continue
+		if diff := utils.UnifiedDiff(aName, "b/"+path, old, gen.outputs[path]); diff != "" {
+			mismatches = append(mismatches, Mismatch{Path: path, Diff: diff})
 		}
-
-		// use auto add
-		gen.writeString(gen.makeEnumName(en, &v) + " " + en.Name + ` = ` + strconv.Itoa(int(it)) + v.Comment + "\n")
-		it++
 	}
-
-	gen.writeString(")\n\n")
-}
-
-// typeName + constName
-// 首字母大写
-func (gen *Generate) makeEnumName(en *parser.EnumInfo, mb *parser.EnumMember) string {
-	return utils.UpperFirstLetter(en.Name) + utils.UpperFirstLetter(mb.Key)
+	return mismatches, nil
 }
 
-// 生成 const
-func (gen *Generate) genConst() {
-	if len(gen.p.Consts) == 0 {
-		return
-	}
-
-	gen.writeString("\nconst (\n")
-
-	for _, v := range gen.p.Consts {
-		v.Rename()
-		gen.writeString(v.PreComment)
-		gen.writeString(v.Name + " " + gen.genType(v.Type) + " = " + v.Value + v.Comment + "\n")
-	}
-
-	gen.writeString(")\n")
+// Reset 清空已经生成的文件的记录，watch 模式每次重新生成前调用
+func Reset() {
+	fileMap = make(map[string]bool, 0)
+	fileOutputs = make(map[string]map[string]string, 0)
 }
 
-// 生成 struct
-func (gen *Generate) genStructs() {
-	for _, v := range gen.p.Structs {
-		gen.genStruct(&v)
+// Gen to parser file.
+func (gen *Generate) Gen() {
+	if err := gen.Run(); err != nil {
+		log.Raw("%s", err)
+		os.Exit(1)
 	}
 }
 
-func (gen *Generate) genStruct(st *parser.StructInfo) {
-	log.Debug("begin genStruct")
-	gen.vc = 0
-	st.Rename()
-
-	gen.genStructDefine(st)
-	gen.genFunResetDefault(st)
-
-	gen.genFunReadFrom(st)
-	gen.genFunWriteTo(st)
-}
-
-// 生成 struct 的定义
-// 默认生成 json、tag
-func (gen *Generate) genStructDefine(st *parser.StructInfo) {
-	log.Debug("begin genStructDefine")
-	gen.writeString(st.Comment)
-	gen.writeString("type " + st.Name + " struct {\n")
-
-	for _, v := range st.Member {
-		if v.CommentType != "" {
-			gen.writeString(v.CommentType + "\n")
-			continue
-		}
-		if gen.jsonOmitEmpty {
-			gen.writeString("\t" + v.Key + " " + gen.genType(v.Type) + " `json:\"" + v.OriginKey + ",omitempty" + "\"`" + v.Comment + "\n")
-			continue
+// Run 与 Gen 相同，出错时返回 error 而不是退出
+func (gen *Generate) Run() (err error) {
+	// recover  panic
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
 		}
+	}()
 
-		gen.writeString("\t" + v.Key + " " + gen.genType(v.Type) + " `json:\"" + v.OriginKey + `"` + ` tag:"` + strconv.Itoa(int(v.Tag)) + "\"`" + v.Comment + "\n")
+	// 解析文件
+	gen.p = parser.ParseFile(gen.filepath, make([]string, 0))
+	if gen.sourceHash, err = SourceHash(gen.p); err != nil {
+		return err
 	}
-
-	gen.writeString("}\n")
-}
-
-// 生成 struct optional 成员的默认赋值
-func (gen *Generate) genFunResetDefault(st *parser.StructInfo) {
-	log.Debug("begin genFunResetDefault")
-	gen.writeString("\nfunc (st *" + st.Name + ") resetDefault() {\n")
-
-	for _, v := range st.Member {
-		if v.CommentType != "" {
-			continue
-		}
-		if v.Default == "" {
-			continue
+	if gen.cache != nil {
+		if gen.entry, err = gen.cacheEntry(); err != nil {
+			return err
 		}
-		gen.writeString("st." + v.Key + " = " + v.Default + "\n")
-	}
-
-	gen.writeString("}\n")
-}
-
-// 实现反序列化
-func (gen *Generate) genFunReadFrom(st *parser.StructInfo) {
-	gen.writeString("\n" + `// ReadFrom reads from io.Reader and put into struct.
-func (st *` + st.Name + `) ReadFrom(r io.Reader) (n int64, err error) {
-	var (
-		have bool
-		ty jce.JceEncodeType
-	)
-
-    decoder := jce.NewDecoder(r)
-	st.resetDefault()
-    
-    if err = decoder.ReadStructBegin(); err != nil {
-        return
-    }
-
-`)
-
-	for _, v := range st.Member {
-		if v.CommentType != "" {
-			continue
+		if !fileMap[gen.filepath] && gen.cache.fresh(gen.filepath, gen.entry) {
+			log.Raw("[skip]generate %s, unchanged\n", gen.filepath)
+			return nil
 		}
-		gen.genReadVar(&v, "st.")
 	}
 
-	gen.code.WriteString(`
-    if err = decoder.ReadStructEnd(); err != nil {
-        return
-    }
-
-	_ = err
-	_ = have
-	_ = ty
-	return 
-}
-`)
-}
-
-// 生成 struct 的成员
-func (gen *Generate) genReadVar(v *parser.StructMember, prefix string) {
-	gen.writeString("    // [step " + strconv.Itoa(int(v.Tag)) + "] read " + v.Key)
-
-	require := "false"
-	if v.Require {
-		require = "true"
-	}
-
-	switch v.Type.Type {
-	case lex.TkTVector:
-		gen.genReadVector(v, prefix)
-	case lex.TkTArray:
-		gen.genReadVector(v, prefix)
-	case lex.TkTMap:
-		gen.genReadMap(v, prefix)
-	case lex.TkName:
-		if v.Type.CType == lex.TkEnum {
-			require := "false"
-			if v.Require {
-				require = "true"
-			}
-
-			gen.writeString(`
-    if err = decoder.ReadInt32((*int32)(&` + prefix + v.Key + `),` + strconv.Itoa(int(v.Tag)) + `, ` + require + `); err !=nil {
-        return
-    }
-`)
-			return
-
-		}
+	log.Debug("begin generate file:%s", gen.filepath)
 
-		gen.writeString(`
-    if _, err = ` + prefix + v.Key + `.ReadFrom(decoder.Reader()); err !=nil {
-        return
-    }
-`)
-
-	default: // 默认基础类型，即非 list、map、
-		gen.writeString(`
-    if err = decoder.Read` + utils.UpperFirstLetter(gen.genType(v.Type)) + `(&` + prefix + v.Key + `, ` + strconv.Itoa(int(v.Tag)) + `, ` + require + `); err != nil {
-        return         
-    }
-`)
+	// 开始代码生成
+	gen.genAll()
+	if gen.entry != nil && len(gen.entry.Outputs) > 0 {
+		gen.cache.record(gen.filepath, gen.entry)
 	}
+	return nil
 }
 
-// 序列化 vector
-func (gen *Generate) genReadVector(mb *parser.StructMember, prefix string) {
-	tag := strconv.Itoa(int(mb.Tag))
-	vc := strconv.Itoa(gen.vc)
-	gen.vc++
-
-	require := "false"
-	if mb.Require {
-		require = "true"
-	}
-
-	// SimpleList
-	if mb.Type.TypeK.Type == lex.TkTByte {
-		if mb.Type.TypeK.Unsigned {
-			gen.writeString(`
-    if err = decoder.ReadSliceUint8(&` + gen.genVariableName(prefix, mb.Key) + `,` + tag + `,` + require + `); err != nil {
-        return
-    }
-`)
-			return
-		}
-
-		gen.writeString(`
-    if err = decoder.ReadSliceInt8(&` + gen.genVariableName(prefix, mb.Key) + `,` + tag + `,` + require + `); err != nil {
-        return
-    }
-`)
+func (gen *Generate) genAll() {
+	if fileMap[gen.filepath] {
+		gen.recordOutputs(fileOutputs[gen.filepath])
 		return
 	}
 
-	// List
-	gen.writeString(`
-    var length` + vc + ` uint32
-
-    // [step ` + strconv.Itoa(int(mb.Tag)) + `.1] read type、tag        
-    if ty, have, err = decoder.ReadHead(` + tag + `,` + require + ` );err != nil || !have {
-        return
-    } 
-    // [step ` + strconv.Itoa(int(mb.Tag)) + `.2] read list length        
-    if length` + vc + `, err = decoder.ReadLength(); err !=nil {
-        return
-    }
-    // [step ` + strconv.Itoa(int(mb.Tag)) + `.3] read data        
-    ` + gen.genVariableName(prefix, mb.Key) + ` = make(` + gen.genType(mb.Type) + `, length` + vc + `)`)
-
-	gen.writeString(`  
-    for i` + vc + `:= uint32(0); i` + vc + `< length` + vc + `; i` + vc + `++ {
-`)
-
-	dummy := &parser.StructMember{
-		Type: mb.Type.TypeK,
-		Key:  mb.Key + "[i" + vc + "]",
+	if len(gen.p.Enums) == 0 && len(gen.p.Consts) == 0 && len(gen.p.Structs) == 0 {
+		return
 	}
+	log.Debug("hhh")
 
-	gen.genReadVar(dummy, prefix)
-
-	gen.writeString(`
-	}
-	`)
-}
+	gen.genIncludeFiles()
 
-// 反序列化 map
-func (gen *Generate) genReadMap(mb *parser.StructMember, prefix string) {
-	require := "false"
-	if mb.Require {
-		require = "true"
-	}
-	vc := strconv.Itoa(gen.vc)
-	gen.vc++
-
-	gen.writeString(`
-    var length` + vc + ` uint32
-
-    // [step ` + strconv.Itoa(int(mb.Tag)) + `.1] read type、tag
-    if ty, have, err = decoder.ReadHead(` + strconv.Itoa(int(mb.Tag)) + "," + require + `); err != nil {
-        return
-    }
-    // [step ` + strconv.Itoa(int(mb.Tag)) + `.2] read length
-    if length` + vc + `, err = decoder.ReadLength(); err != nil {
-        return
-    }        
-    // [step ` + strconv.Itoa(int(mb.Tag)) + `.3] read data
-    ` + gen.genVariableName(prefix, mb.Key) + ` = make(` + gen.genType(mb.Type) + `, 0)` + `
-	var k` + vc + ` ` + gen.genType(mb.Type.TypeK) + `
-	var v` + vc + ` ` + gen.genType(mb.Type.TypeV) + `        
-    for i := uint32(0);i < length` + vc + `; i++ {
-`)
-
-	dummy := &parser.StructMember{
-		Type: mb.Type.TypeK,
-		Key:  "k" + vc,
+	t, err := gen.loadTemplates(gen.templateDir)
+	if err != nil {
+		panic(err.Error())
 	}
-	gen.genReadVar(dummy, "")
-
-	dummy = &parser.StructMember{
-		Type: mb.Type.TypeV,
-		Key:  "v" + vc,
-		Tag:  1,
+	if err = t.Execute(&gen.code, gen.buildFile()); err != nil {
+		panic(err.Error())
 	}
-	gen.genReadVar(dummy, "")
+	gen.saveFiles()
 
-	gen.writeString(`
-	` + prefix + mb.Key + `[k` + vc + `] = v` + vc + `
-}
-`)
+	fileMap[gen.filepath] = true
 }
 
-// 生成序列化函数
-// tips: 这里注意，如果 optional 有默认值，那么序列化的时候写不写默认值呢？
-// 1. 写：优点方便维护，缺点增大带宽
-// 2. 不写：优点节约带宽，缺点维护不方便
-// 最后综合考虑，其实带宽开销并不大，而维护更加重要，故默认写
-func (gen *Generate) genFunWriteTo(st *parser.StructInfo) {
-	gen.writeString(`// WriteTo encode struct to io.Writer 
-func (st *` + st.Name + `) WriteTo(w io.Writer) (n int64, err error) {
-    encoder := jce.NewEncoder(w)
-	st.resetDefault()
-
-    if err = encoder.WriteStructBegin(); err != nil {
-        return
-    }  
-
-`)
-
-	for _, v := range st.Member {
-		if v.CommentType != "" {
-			continue
+// 先生成依赖的其他文件，即 include 的其他文件
+func (gen *Generate) genIncludeFiles() {
+	for _, v := range gen.p.IncParse {
+		inc := NewGenerate(v.Filepath, gen.module, gen.prefix, gen.jsonOmitEmpty)
+		inc.p = v
+		inc.templateDir = gen.templateDir
+		inc.schemaPath = gen.schemaPath
+		// check 模式下同样只在内存中生成
+		inc.outputs = gen.outputs
+		// include 的文件生成的文件也记录到当前输入文件的缓存中，它们被修改、删除时重新生成
+		inc.cache = gen.cache
+		inc.entry = gen.entry
+		// 文件头中是 include 的文件自己的 source hash，和直接生成它时相同
+		sum, err := SourceHash(v)
+		if err != nil {
+			panic(err.Error())
 		}
-		gen.genWriteVar(&v, "st.", false)
+		inc.sourceHash = sum
+		inc.genAll()
 	}
-
-	gen.writeString(`
-    if err = encoder.WriteStructEnd(); err != nil {
-        return
-    }
-
-    // flush to io.Writer        
-    err = encoder.Flush()
-    return
 }
-`)
-}
-
-// 序列化 struct 成员
-func (gen *Generate) genWriteVar(v *parser.StructMember, prefix string, hasRet bool) {
-	gen.writeString("// [step " + strconv.Itoa(int(v.Tag)) + "] write " + v.Key)
-
-	switch v.Type.Type {
-	case lex.TkTVector:
-		gen.genWriteVector(v, prefix, hasRet)
-	case lex.TkTArray:
-		gen.genWriteVector(v, prefix, hasRet)
-	case lex.TkTMap:
-		gen.genWriteMap(v, prefix, hasRet)
-	case lex.TkName:
-		if v.Type.CType == lex.TkEnum {
-			gen.writeString(`
-            if err = encoder.WriteInt32(int32(` + gen.genVariableName(prefix, v.Key) + `),` + strconv.Itoa(int(v.Tag)) + `); err !=nil {
-                return
-            }
-`)
-			return
-		}
 
-		gen.writeString(`
-        if _, err = ` + prefix + v.Key + `.WriteTo(encoder.Writer()); err != nil {
-            return
-        }
-`)
-
-	default: // 默认基础类型
-		gen.writeString(`
-if err = encoder.Write` + utils.UpperFirstLetter(gen.genType(v.Type)) + `(` + gen.genVariableName(prefix, v.Key) + `, ` + strconv.Itoa(int(v.Tag)) + `); err != nil {
-    return
-} 
-`)
+// 把写入的文件记录到缓存中
+func (gen *Generate) recordOutputs(outputs map[string]string) {
+	if gen.entry == nil {
+		return
+	}
+	for name, sum := range outputs {
+		gen.entry.Outputs[name] = sum
 	}
 }
 
-// 序列化数组
-func (gen *Generate) genWriteVector(mb *parser.StructMember, prefix string, hasRet bool) {
-	vc := strconv.Itoa(gen.vc)
-	gen.vc++
-
-	// SimpleList
-	if mb.Type.TypeK.Type == lex.TkTByte {
-		tag := strconv.Itoa(int(mb.Tag))
+// struct 依赖的包的导入路径，没有指定 go module 时为空
+func (gen *Generate) genStructImport(module string) string {
+	moduleStr := module
 
-		if mb.Type.TypeK.Unsigned {
-			gen.writeString(`
-if err = encoder.WriteSliceUint8(` + gen.genVariableName(prefix, mb.Key) + `,` + tag + `,` + `); err != nil {
-    return
-}
-`)
-			return
+	for _, p := range gen.I {
+		if strings.HasSuffix(p, "/"+moduleStr) {
+			return moduleStr
 		}
-
-		gen.writeString(`
-if err = encoder.WriteSliceInt8(` + gen.genVariableName(prefix, mb.Key) + `,` + tag + `,` + `); err != nil {
-    return
-}
-`)
-		return
 	}
 
-	// LIST
-	// ---------------------------------------------
-	// | head(type、tag) |   length  |     data    |
-	// |    1 or 2 B     |     4B    |       ?     |
-	// ---------------------------------------------
-	// data 可以是任何 type
-
-	gen.writeString(`
-// [step ` + strconv.Itoa(int(mb.Tag)) + `.1] write type、tag
-if err = encoder.WriteHead(jce.List, ` + strconv.Itoa(int(mb.Tag)) + `); err != nil {
-    return
-}
-// [step ` + strconv.Itoa(int(mb.Tag)) + `.2] write list length
-if err = encoder.WriteLength(uint32(len(` + gen.genVariableName(prefix, mb.Key) + `))); err != nil {
-    return
-}
-// [step ` + strconv.Itoa(int(mb.Tag)) + `.3] write data 
-    for _, v` + vc + ` := range ` + gen.genVariableName(prefix, mb.Key) + ` {
-`)
-
-	dummy := &parser.StructMember{
-		Type: mb.Type.TypeK,
-		Key:  "v" + vc,
+	if gen.module == "" {
+		return ""
 	}
 
-	gen.genWriteVar(dummy, "", hasRet)
-
-	gen.writeString("}\n")
-}
-
-// 序列化 map
-func (gen *Generate) genWriteMap(mb *parser.StructMember, prefix string, hasRet bool) {
-	vc := strconv.Itoa(gen.vc)
-	gen.vc++
-
-	// map
-	// --------------------------------------------------------------
-	// | head(type、tag) |   length  |     data (key、value list)   |
-	// |    1 or 2 B     |     4B    |           ?                  |
-	// --------------------------------------------------------------
-	// data 可以是任何 type
-
-	gen.writeString(`
-// [step ` + strconv.Itoa(int(mb.Tag)) + `.1] write type、tag
-if err = encoder.WriteHead(jce.Map, ` + strconv.Itoa(int(mb.Tag)) + `); err != nil {
-    return
-}
-// [step ` + strconv.Itoa(int(mb.Tag)) + `.2] write length
-if err = encoder.WriteLength(uint32(len(` + gen.genVariableName(prefix, mb.Key) + `))); err != nil {
-    return
-}
-// [step ` + strconv.Itoa(int(mb.Tag)) + `.3] write data
-for k` + vc + `, v` + vc + ` := range ` + gen.genVariableName(prefix, mb.Key) + ` {
-`)
-
-	// write key
-	dummy := &parser.StructMember{
-		Type: mb.Type.TypeK,
-		Key:  "k" + vc,
-	}
-	gen.genWriteVar(dummy, "", hasRet)
+	mf := filepath.Clean(filepath.Join(gen.module, gen.prefix))
 
-	// write value
-	dummy = &parser.StructMember{
-		Type: mb.Type.TypeV,
-		Key:  "v" + vc,
-		Tag:  1,
+	if runtime.GOOS == "windows" {
+		mf = strings.ReplaceAll(mf, string(os.PathSeparator), string('/'))
 	}
-	gen.genWriteVar(dummy, "", hasRet)
 
-	gen.writeString("}\n")
+	return fmt.Sprintf("%s/%s", mf, moduleStr)
 }
 
 // 保存文件
@@ -704,11 +259,18 @@ func (gen *Generate) saveFiles() {
 	// 格式化文件
 	beauty, err := format.Source(gen.code.Bytes())
 	if err != nil {
+		if gen.outputs != nil {
+			panic("go fmt fail. " + filename + " " + err.Error())
+		}
 		log.Error("go fmt fail. " + filename + " " + err.Error())
 		return
 	}
 
 	mkPath := gen.prefix + gen.p.Module
+	if gen.outputs != nil {
+		gen.outputs[mkPath+"/"+filename] = beauty
+		return
+	}
 
 	if err = os.MkdirAll(mkPath, 0o766); err != nil {
 		panic(err.Error())
@@ -717,19 +279,13 @@ func (gen *Generate) saveFiles() {
 	if err = ioutil.WriteFile(mkPath+"/"+filename, beauty, 0o666); err != nil {
 		panic(err.Error())
 	}
+	outputs := map[string]string{mkPath + "/" + filename: hashBytes(beauty)}
+	fileOutputs[gen.filepath] = outputs
+	gen.recordOutputs(outputs)
 
 	log.Raw("[ok]generate %s -> %s\n", gen.filepath, mkPath+"/"+filename)
 }
 
-// 生成变量名
-func (gen *Generate) genVariableName(prefix, name string) string {
-	if prefix != "" {
-		return prefix + name
-	} else {
-		return strings.Trim(name, "()")
-	}
-}
-
 // 生成对应的类型字符串
 func (gen *Generate) genType(ty *parser.VarType) string {
 	ret := ""
@@ -788,8 +344,3 @@ func (gen *Generate) genType(ty *parser.VarType) string {
 
 	return ret
 }
-
-func (gen *Generate) writeString(s string) (err error) {
-	_, err = gen.code.WriteString(s)
-	return
-}
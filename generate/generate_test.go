@@ -1,8 +1,372 @@
 package generate
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/erpc-go/jce2go/parser"
 )
 
 func TestNewGenerate(t *testing.T) {
 }
+
+func TestTemplates(t *testing.T) {
+	p, err := parser.ParseSource("test.jce", []byte(`module test
+{
+    enum Color { Red, Green = 3, Blue = Red };
+    struct Item
+    {
+        0 require int id;
+        1 optional map<string, vector<int>> tags;
+    };
+};`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	enum := `{{.Name}}:{{range .Members}} {{.OriginName}}={{.Value}}({{.Number}}){{end}}` + "\n"
+	if err = ioutil.WriteFile(filepath.Join(dir, "enum.tmpl"), []byte(enum), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerate("test.jce", "", "", false).WithTemplates(dir).WithDescriptor(true)
+	gen.p = p
+	tpl, err := gen.loadTemplates(gen.templateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = tpl.Execute(&b, gen.buildFile()); err != nil {
+		t.Fatal(err)
+	}
+
+	code := b.String()
+	for _, s := range []string{
+		"Color: Red=0(0) Green=3(3) Blue=ColorRed(0)",
+		"func (st *Item) ReadFrom(r io.Reader)",
+		"for k2, v2 := range st.Tags {",
+		`jceschema.RegisterStruct(`,
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(dir, "unknown.tmpl"), nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = gen.loadTemplates(dir); err == nil || !strings.Contains(err.Error(), "unknown template") {
+		t.Fatalf("got %v", err)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	p, err := parser.ParseSource("test.jce", []byte(`module test
+{
+    enum Color { Red = 1, Green };
+    struct Item
+    {
+        0 optional Color c = Green;
+        1 optional string s = "none";
+    };
+};`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := NewGenerate("test.jce", "", "", false)
+	gen.p = p
+	tpl, err := gen.loadTemplates("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = tpl.Execute(&b, gen.buildFile()); err != nil {
+		t.Fatal(err)
+	}
+
+	code := b.String()
+	// 默认值引用生成的枚举常量
+	if !strings.Contains(code, "st.C = ColorGreen") {
+		t.Errorf("missing enum default in:\n%s", code)
+	}
+	// WriteTo 写入成员当前的值，不能重置为默认值
+	i := strings.Index(code, ") WriteTo(")
+	if i < 0 {
+		t.Fatalf("missing WriteTo in:\n%s", code)
+	}
+	if writeTo := code[i:]; strings.Contains(writeTo[:strings.Index(writeTo, "\n}\n")], "resetDefault") {
+		t.Errorf("WriteTo resets fields:\n%s", writeTo)
+	}
+}
+
+// 只有指定 WithDescriptor 时生成的代码才依赖 jceschema
+func TestDescriptor(t *testing.T) {
+	p, err := parser.ParseSource("test.jce", []byte("module test\n{\n    struct A\n    {\n        0 require int id;\n    };\n};\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, on := range []bool{false, true} {
+		gen := NewGenerate("test.jce", "", "", false).WithDescriptor(on)
+		gen.p = p
+		tpl, err := gen.loadTemplates("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b bytes.Buffer
+		if err = tpl.Execute(&b, gen.buildFile()); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Contains(b.String(), "jceschema"); got != on {
+			t.Errorf("descriptor %t: jceschema in code %t:\n%s", on, got, b.String())
+		}
+	}
+}
+
+func TestRunReset(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "run.jce")
+	if err := ioutil.WriteFile(src, []byte("module run\n{\n    struct A\n    {\n        0 require int id;\n    };\n};\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	target := filepath.Join(out, "run", "run.jce.go")
+
+	Reset()
+	if err := NewGenerate(src, "", out, false).Run(); err != nil {
+		t.Fatal(err)
+	}
+	// 已经生成过的文件跳过，Reset 之后重新生成
+	if err := ioutil.WriteFile(target, nil, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewGenerate(src, "", out, false).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(target); len(b) != 0 {
+		t.Fatal("generated twice without Reset")
+	}
+	Reset()
+	if err := NewGenerate(src, "", out, false).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(target); !bytes.Contains(b, []byte("type A struct")) {
+		t.Fatalf("not regenerated after Reset:\n%s", b)
+	}
+
+	// 出错时返回 error 而不是退出
+	if err := ioutil.WriteFile(src, []byte("module run {"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	Reset()
+	if err := NewGenerate(src, "", out, false).Run(); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cache.jce")
+	write := func(content string) {
+		if err := ioutil.WriteFile(src, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("module cache\n{\n    struct A\n    {\n        0 require int id;\n    };\n};\n")
+	out := filepath.Join(dir, "out")
+	target := filepath.Join(out, "cache", "cache.jce.go")
+	manifest := filepath.Join(dir, "cache.json")
+
+	// 生成一次，返回是否写入了 target
+	old := time.Unix(1, 0)
+	run := func(jsonOmitEmpty bool) bool {
+		t.Helper()
+		os.Chtimes(target, old, old)
+		c, err := LoadCache(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Reset()
+		if err := NewGenerate(src, "", out, jsonOmitEmpty).WithCache(c).Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := os.Stat(target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return !fi.ModTime().Equal(old)
+	}
+
+	if !run(false) {
+		t.Fatal("first run should generate")
+	}
+	if run(false) {
+		t.Error("unchanged input should be skipped")
+	}
+	if !run(true) {
+		t.Error("changed options should regenerate")
+	}
+	write("module cache\n{\n    struct A\n    {\n        0 require long id;\n    };\n};\n")
+	if !run(true) {
+		t.Error("changed source should regenerate")
+	}
+	if err := ioutil.WriteFile(target, []byte("edited"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if !run(true) {
+		t.Error("edited output should regenerate")
+	}
+
+	sum, err := SourceHash(parser.ParseFile(src, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := ioutil.ReadFile(target); !bytes.Contains(b, []byte("// source hash: sha256:"+sum+"\n")) {
+		t.Errorf("missing source hash %s in:\n%s", sum, b)
+	}
+}
+
+// include 的文件生成的文件也记录在缓存中，被删除时重新生成
+func TestCacheIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.jce": "module base\n{\n    struct B\n    {\n        0 require int id;\n    };\n};\n",
+		"top.jce":  "#include \"base.jce\"\n\nmodule top\n{\n    struct T\n    {\n        0 require base::B b;\n    };\n};\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := filepath.Join(dir, "top.jce")
+	out := filepath.Join(dir, "out")
+	target := filepath.Join(out, "base", "base.jce.go")
+	manifest := filepath.Join(dir, "cache.json")
+
+	run := func() {
+		t.Helper()
+		c, err := LoadCache(manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Reset()
+		if err := NewGenerate(src, "", out, false).WithCache(c).Run(); err != nil {
+			t.Fatal(err)
+		}
+		if err := c.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run()
+	c, err := LoadCache(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := c.Entries[filepath.Clean(src)]
+	if e == nil || len(e.Outputs) != 2 || e.Outputs[filepath.ToSlash(out)+"/base/base.jce.go"] == "" {
+		t.Fatalf("include output not recorded: %+v", e)
+	}
+	if !strings.Contains(e.Options, " templates=") {
+		t.Errorf("builtin templates not in options %q", e.Options)
+	}
+
+	if err := os.Remove(target); err != nil {
+		t.Fatal(err)
+	}
+	run()
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("deleted include output should be regenerated: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "check.jce")
+	if err := ioutil.WriteFile(src, []byte("module check\n{\n    struct A\n    {\n        0 require int id;\n    };\n};\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	target := filepath.Join(out, "check", "check.jce.go")
+
+	// 没有生成过时与空文件比较，并且不写入文件
+	Reset()
+	ms, err := NewGenerate(src, "", out, false).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 1 || !strings.HasPrefix(ms[0].Diff, "--- /dev/null\n") {
+		t.Fatalf("unexpected mismatches %+v", ms)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("check should not write files, got %v", err)
+	}
+
+	Reset()
+	if err := NewGenerate(src, "", out, false).Run(); err != nil {
+		t.Fatal(err)
+	}
+	Reset()
+	if ms, err = NewGenerate(src, "", out, false).Check(); err != nil || len(ms) != 0 {
+		t.Fatalf("expected up to date, got %+v %v", ms, err)
+	}
+
+	// 只有格式不同也是不一致
+	b, _ := ioutil.ReadFile(target)
+	if err := ioutil.WriteFile(target, bytes.Replace(b, []byte("package check"), []byte("package  check"), 1), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	Reset()
+	if ms, err = NewGenerate(src, "", out, false).Check(); err != nil || len(ms) != 1 {
+		t.Fatalf("expected one mismatch, got %+v %v", ms, err)
+	}
+	if !strings.Contains(ms[0].Diff, "-package  check\n+package check\n") {
+		t.Errorf("unexpected diff:\n%s", ms[0].Diff)
+	}
+}
+
+// include 的文件在 check 模式下同样不写入文件，文件头中是它自己的 source hash
+func TestCheckIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.jce": "module base\n{\n    struct B\n    {\n        0 require int id;\n    };\n};\n",
+		"top.jce":  "#include \"base.jce\"\n\nmodule top\n{\n    struct T\n    {\n        0 require base::B b;\n    };\n};\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(dir, "out")
+
+	Reset()
+	ms, err := NewGenerate(filepath.Join(dir, "top.jce"), "", out, false).Check()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ms) != 2 {
+		t.Fatalf("expected mismatches of both files, got %+v", ms)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Fatalf("check should not write files, got %v", err)
+	}
+
+	sum, err := SourceHash(parser.ParseFile(filepath.Join(dir, "base.jce"), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range ms {
+		if strings.HasSuffix(m.Path, "/base/base.jce.go") && !strings.Contains(m.Diff, "+// source hash: sha256:"+sum+"\n") {
+			t.Errorf("missing source hash %s in:\n%s", sum, m.Diff)
+		}
+	}
+}
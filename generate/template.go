@@ -0,0 +1,66 @@
+package generate
+
+import (
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"text/template"
+
+	"github.com/erpc-go/jce2go/utils"
+)
+
+// 内置的模板，数据模型见 data.go
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// 加载模板，dir 不为空时用其中的同名文件覆盖内置的模板
+func (gen *Generate) loadTemplates(dir string) (*template.Template, error) {
+	t := template.New("file.tmpl").Funcs(template.FuncMap{
+		"goType":      gen.genType,
+		"upper":       utils.UpperFirstLetter,
+		"quote":       strconv.Quote,
+		"schemaType":  gen.genDescriptorType,
+		"trimComment": utils.TrimComment,
+	})
+
+	t, err := t.ParseFS(templateFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return t, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		name := filepath.Base(f)
+		if t.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown template %s, expect one of %s", f, TemplateNames())
+		}
+
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = t.New(name).Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// TemplateNames 可以覆盖的模板文件名
+func TemplateNames() []string {
+	entries, _ := templateFS.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
@@ -0,0 +1,490 @@
+// Package graph 生成 jce 文件的依赖图，输出为 Graphviz DOT 或者 Mermaid
+//
+// 两种粒度：
+//
+//	Files  文件之间的 #include，没有用到被 include 的文件（以及它 include 的文件）中任何类型的为 unused；
+//	       无法解析时（如 include 有环）使用 Includes，只根据 #include 指令建图
+//	Types  struct、interface 到它们引用的 struct、enum
+//
+// 环上的节点和边高亮显示
+package graph
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lex"
+)
+
+// Node 节点
+type Node struct {
+	ID      string // 文件名或者类型的全名（module.name）
+	Label   string
+	Kind    string // file、struct、enum、interface
+	InCycle bool
+}
+
+// Edge 边
+type Edge struct {
+	From, To string
+	InCycle  bool
+	Unused   bool // 没有用到的 include
+}
+
+// Graph 依赖图，节点、边按加入的顺序输出
+type Graph struct {
+	Nodes []*Node
+	Edges []*Edge
+	nodes map[string]*Node
+	comp  map[string]int // 节点所在的强连通分量
+}
+
+func newGraph() *Graph {
+	return &Graph{nodes: map[string]*Node{}}
+}
+
+func (g *Graph) addNode(n *Node) {
+	if g.nodes[n.ID] == nil {
+		g.nodes[n.ID] = n
+		g.Nodes = append(g.Nodes, n)
+	}
+}
+
+func (g *Graph) addEdge(from, to string) *Edge {
+	for _, e := range g.Edges {
+		if e.From == from && e.To == to {
+			return e
+		}
+	}
+	e := &Edge{From: from, To: to}
+	g.Edges = append(g.Edges, e)
+	return e
+}
+
+// Files 文件级的 include 图，depth 大于 0 时只保留距离没有被 include 的文件 depth 层以内的文件
+func Files(set *descriptor.Set, depth int) *Graph {
+	g := newGraph()
+	defined := map[string]string{} // 类型全名所在的文件
+	files := map[string]*descriptor.File{}
+	for _, f := range set.Files {
+		files[f.Name] = f
+		g.addNode(&Node{ID: f.Name, Label: filepath.ToSlash(f.Name), Kind: "file"})
+		for _, st := range f.Structs {
+			defined[f.Module+"."+st.Name] = f.Name
+		}
+		for _, en := range f.Enums {
+			defined[f.Module+"."+en.Name] = f.Name
+		}
+	}
+
+	for _, f := range set.Files {
+		used := map[string]bool{}
+		for _, name := range refs(f) {
+			used[defined[name]] = true
+		}
+		for _, inc := range f.Includes {
+			e := g.addEdge(f.Name, inc)
+			e.Unused = !reachesUsed(files, inc, used, map[string]bool{})
+		}
+	}
+
+	g.markCycles()
+	if depth > 0 {
+		g.limit(g.roots(), depth)
+	}
+	return g
+}
+
+// Includes 只根据 #include 指令建立文件级的 include 图，不需要完整地解析文件，
+// 用于 include 有环等无法解析的情况，不检查 unused
+func Includes(files []string, depth int) (*Graph, error) {
+	g := newGraph()
+	var visit func(name string) error
+	visit = func(name string) error {
+		if g.nodes[name] != nil {
+			return nil
+		}
+		g.addNode(&Node{ID: name, Label: filepath.ToSlash(name), Kind: "file"})
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		tokens, err := lex.Tokenize(name, src)
+		if err != nil {
+			return err
+		}
+		for i := 0; i+1 < len(tokens); i++ {
+			if tokens[i].Type != lex.TkInclude || tokens[i+1].Type != lex.TkString {
+				continue
+			}
+			// 与 parser 相同，相对于 include 所在的文件
+			inc := filepath.Clean(path.Dir(name) + "/" + tokens[i+1].Value.String)
+			g.addEdge(name, inc)
+			if err := visit(inc); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, f := range files {
+		if path.Ext(f) != ".jce" {
+			continue
+		}
+		if err := visit(filepath.Clean(f)); err != nil {
+			return nil, err
+		}
+	}
+
+	g.markCycles()
+	if depth > 0 {
+		g.limit(g.roots(), depth)
+	}
+	return g, nil
+}
+
+// 从 name 开始沿 include 能否到达用到的文件：include 的文件中的类型也可以通过它引用
+func reachesUsed(files map[string]*descriptor.File, name string, used, seen map[string]bool) bool {
+	if used[name] {
+		return true
+	}
+	if seen[name] || files[name] == nil {
+		return false
+	}
+	seen[name] = true
+	for _, inc := range files[name].Includes {
+		if reachesUsed(files, inc, used, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// Types 类型级的依赖图。root 不为空时只保留从 root（全名，如 test.Req 或 test::Req）可以到达的类型，
+// depth 大于 0 时只保留 depth 层以内的类型
+func Types(set *descriptor.Set, root string, depth int) (*Graph, error) {
+	g := newGraph()
+	for _, f := range set.Files {
+		for _, en := range f.Enums {
+			g.addNode(&Node{ID: f.Module + "." + en.Name, Label: f.Module + "::" + en.Name, Kind: "enum"})
+		}
+		for _, st := range f.Structs {
+			g.addNode(&Node{ID: f.Module + "." + st.Name, Label: f.Module + "::" + st.Name, Kind: "struct"})
+		}
+		for _, itf := range f.Interfaces {
+			g.addNode(&Node{ID: f.Module + "." + itf.Name, Label: f.Module + "::" + itf.Name, Kind: "interface"})
+		}
+	}
+
+	for _, f := range set.Files {
+		for _, st := range f.Structs {
+			for _, mb := range st.Members {
+				g.addTypeEdges(f.Module+"."+st.Name, mb.Type)
+			}
+		}
+		for _, itf := range f.Interfaces {
+			from := f.Module + "." + itf.Name
+			for _, m := range itf.Methods {
+				g.addTypeEdges(from, m.Return)
+				for _, a := range m.Args {
+					g.addTypeEdges(from, a.Type)
+				}
+			}
+		}
+	}
+
+	g.markCycles()
+	if root != "" {
+		root = strings.Replace(root, "::", ".", 1)
+		if g.nodes[root] == nil {
+			return nil, fmt.Errorf("type %s not found", strings.Replace(root, ".", "::", 1))
+		}
+		g.limit([]string{root}, depth)
+	} else if depth > 0 {
+		g.limit(g.roots(), depth)
+	}
+	return g, nil
+}
+
+func (g *Graph) addTypeEdges(from string, t *descriptor.Type) {
+	if t.Name != "" {
+		g.addEdge(from, t.Name)
+	}
+	for _, p := range t.Params {
+		g.addTypeEdges(from, p)
+	}
+}
+
+// 文件中所有引用的类型的全名
+func refs(f *descriptor.File) []string {
+	var names []string
+	var visit func(t *descriptor.Type)
+	visit = func(t *descriptor.Type) {
+		if t.Name != "" {
+			names = append(names, t.Name)
+		}
+		for _, p := range t.Params {
+			visit(p)
+		}
+	}
+	for _, st := range f.Structs {
+		for _, mb := range st.Members {
+			visit(mb.Type)
+		}
+	}
+	for _, c := range f.Consts {
+		visit(c.Type)
+	}
+	for _, itf := range f.Interfaces {
+		for _, m := range itf.Methods {
+			visit(m.Return)
+			for _, a := range m.Args {
+				visit(a.Type)
+			}
+		}
+	}
+	return names
+}
+
+// 没有入边的节点
+func (g *Graph) roots() []string {
+	hasIn := map[string]bool{}
+	for _, e := range g.Edges {
+		if e.From != e.To {
+			hasIn[e.To] = true
+		}
+	}
+	var roots []string
+	for _, n := range g.Nodes {
+		if !hasIn[n.ID] {
+			roots = append(roots, n.ID)
+		}
+	}
+	return roots
+}
+
+// 只保留从 roots 出发 depth 层以内（depth 为 0 时不限制）的节点以及它们之间的边
+func (g *Graph) limit(roots []string, depth int) {
+	dist := map[string]int{}
+	queue := roots
+	for _, r := range roots {
+		dist[r] = 0
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		if depth > 0 && dist[cur] >= depth {
+			continue
+		}
+		for _, e := range g.Edges {
+			if _, ok := dist[e.To]; e.From == cur && !ok {
+				dist[e.To] = dist[cur] + 1
+				queue = append(queue, e.To)
+			}
+		}
+	}
+
+	var nodes []*Node
+	for _, n := range g.Nodes {
+		if _, ok := dist[n.ID]; ok {
+			nodes = append(nodes, n)
+		} else {
+			delete(g.nodes, n.ID)
+		}
+	}
+	var edges []*Edge
+	for _, e := range g.Edges {
+		if g.nodes[e.From] != nil && g.nodes[e.To] != nil {
+			edges = append(edges, e)
+		}
+	}
+	g.Nodes, g.Edges = nodes, edges
+}
+
+// 用 Tarjan 算法求强连通分量，节点数大于 1 或者有自环的分量为环
+func (g *Graph) markCycles() {
+	index := map[string]int{}
+	low := map[string]int{}
+	onStack := map[string]bool{}
+	comp := map[string]int{}
+	g.comp = comp
+	var stack []string
+	next, ncomp := 0, 0
+
+	var connect func(v string)
+	connect = func(v string) {
+		index[v], low[v] = next, next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, e := range g.Edges {
+			if e.From != v {
+				continue
+			}
+			if _, ok := index[e.To]; !ok {
+				connect(e.To)
+				if low[e.To] < low[v] {
+					low[v] = low[e.To]
+				}
+			} else if onStack[e.To] && index[e.To] < low[v] {
+				low[v] = index[e.To]
+			}
+		}
+		if low[v] == index[v] {
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp[w] = ncomp
+				if w == v {
+					break
+				}
+			}
+			ncomp++
+		}
+	}
+	for _, n := range g.Nodes {
+		if _, ok := index[n.ID]; !ok {
+			connect(n.ID)
+		}
+	}
+
+	size := map[int]int{}
+	for _, c := range comp {
+		size[c]++
+	}
+	for _, e := range g.Edges {
+		if comp[e.From] == comp[e.To] && (size[comp[e.From]] > 1 || e.From == e.To) {
+			e.InCycle = true
+			g.nodes[e.From].InCycle = true
+			g.nodes[e.To].InCycle = true
+		}
+	}
+}
+
+// Cycles 所有的环，每个环的节点按名字排序
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	index := map[int]int{}
+	for _, n := range g.Nodes {
+		if !n.InCycle {
+			continue
+		}
+		c := g.comp[n.ID]
+		if _, ok := index[c]; !ok {
+			index[c] = len(cycles)
+			cycles = append(cycles, nil)
+		}
+		cycles[index[c]] = append(cycles[index[c]], n.Label)
+	}
+	for _, c := range cycles {
+		sort.Strings(c)
+	}
+	return cycles
+}
+
+// DOT 输出为 Graphviz DOT
+func (g *Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph jce {\n")
+	b.WriteString("    rankdir=LR;\n")
+	b.WriteString("    node [fontname=\"Helvetica\"];\n")
+	for _, n := range g.Nodes {
+		attrs := []string{"label=" + dotQuote(n.Label), "shape=" + dotShapes[n.Kind]}
+		if n.InCycle {
+			attrs = append(attrs, "color=red", "fontcolor=red")
+		}
+		fmt.Fprintf(&b, "    %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.InCycle {
+			attrs = append(attrs, "color=red")
+		}
+		if e.Unused {
+			attrs = append(attrs, "style=dashed", "color=gray", `label="unused"`)
+		}
+		fmt.Fprintf(&b, "    %s -> %s", dotQuote(e.From), dotQuote(e.To))
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+var dotShapes = map[string]string{
+	"file":      "note",
+	"struct":    "box",
+	"enum":      "ellipse",
+	"interface": "component",
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// Mermaid 输出为 Mermaid 的 flowchart
+func (g *Graph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	ids := map[string]string{}
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		shape := mermaidShapes[n.Kind]
+		fmt.Fprintf(&b, "    %s%s\"%s\"%s\n", ids[n.ID], shape[0], mermaidEscape(n.Label), shape[1])
+	}
+	var cycleEdges []string
+	for i, e := range g.Edges {
+		arrow := "-->"
+		if e.Unused {
+			arrow = "-. unused .->"
+		}
+		fmt.Fprintf(&b, "    %s %s %s\n", ids[e.From], arrow, ids[e.To])
+		if e.InCycle {
+			cycleEdges = append(cycleEdges, fmt.Sprint(i))
+		}
+	}
+
+	var cycleNodes []string
+	for _, n := range g.Nodes {
+		if n.InCycle {
+			cycleNodes = append(cycleNodes, ids[n.ID])
+		}
+	}
+	if len(cycleNodes) > 0 {
+		b.WriteString("    classDef cycle stroke:red,color:red\n")
+		fmt.Fprintf(&b, "    class %s cycle\n", strings.Join(cycleNodes, ","))
+	}
+	if len(cycleEdges) > 0 {
+		fmt.Fprintf(&b, "    linkStyle %s stroke:red\n", strings.Join(cycleEdges, ","))
+	}
+	return b.String()
+}
+
+var mermaidShapes = map[string][2]string{
+	"file":      {"[", "]"},
+	"struct":    {"[", "]"},
+	"enum":      {"([", "])"},
+	"interface": {"[[", "]]"},
+}
+
+func mermaidEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+// Unused 没有用到的 include
+func (g *Graph) Unused() []*Edge {
+	var edges []*Edge
+	for _, e := range g.Edges {
+		if e.Unused {
+			edges = append(edges, e)
+		}
+	}
+	return edges
+}
@@ -0,0 +1,147 @@
+package graph
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+var sources = map[string]string{
+	"base.jce": `module base
+{
+    enum Kind { kA, kB };
+    struct Item { 0 require Kind kind; };
+};
+`,
+	"extra.jce": `module extra
+{
+    struct Unused { 0 require int a; };
+};
+`,
+	"svc.jce": `#include "base.jce"
+#include "extra.jce"
+
+module svc
+{
+    struct Node
+    {
+        0 optional vector<Node>  children;
+        1 optional base::Item    item;
+    };
+
+    struct Req
+    {
+        0 require Node root;
+    };
+};
+`,
+}
+
+func buildSet(t *testing.T) *descriptor.Set {
+	dir := t.TempDir()
+	for name, src := range sources {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := parser.ParseSource(filepath.Join(dir, "svc.jce"), []byte(sources["svc.jce"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return descriptor.Build(p)
+}
+
+func TestFiles(t *testing.T) {
+	g := Files(buildSet(t), 0)
+	if len(g.Nodes) != 3 || len(g.Edges) != 2 {
+		t.Fatalf("unexpected graph %s", g.DOT())
+	}
+	unused := g.Unused()
+	if len(unused) != 1 || filepath.Base(unused[0].To) != "extra.jce" {
+		t.Errorf("unexpected unused includes %+v", unused)
+	}
+	dot := g.DOT()
+	if !strings.Contains(dot, `extra.jce" [style=dashed, color=gray, label="unused"];`) {
+		t.Errorf("unused include not highlighted:\n%s", dot)
+	}
+	if m := g.Mermaid(); !strings.Contains(m, "n2 -. unused .-> n1") {
+		t.Errorf("unused include not highlighted:\n%s", m)
+	}
+}
+
+func TestTypes(t *testing.T) {
+	set := buildSet(t)
+	g, err := Types(set, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cycles := g.Cycles(); len(cycles) != 1 || len(cycles[0]) != 1 || cycles[0][0] != "svc::Node" {
+		t.Errorf("unexpected cycles %v", cycles)
+	}
+	dot := g.DOT()
+	for _, want := range []string{
+		`"svc.Node" [label="svc::Node", shape=box, color=red, fontcolor=red];`,
+		`"svc.Node" -> "svc.Node" [color=red];`,
+		`"svc.Node" -> "base.Item";`,
+		`"base.Item" -> "base.Kind";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("missing %q in:\n%s", want, dot)
+		}
+	}
+	if m := g.Mermaid(); !strings.Contains(m, "class ") || !strings.Contains(m, "linkStyle ") {
+		t.Errorf("cycle not highlighted:\n%s", m)
+	}
+
+	// 从 Req 出发两层以内：Req、Node、base::Item
+	g, err = Types(set, "svc::Req", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, n := range g.Nodes {
+		labels = append(labels, n.Label)
+	}
+	if got := strings.Join(labels, ","); got != "base::Item,svc::Node,svc::Req" {
+		t.Errorf("unexpected nodes %s", got)
+	}
+
+	if _, err := Types(set, "svc::Missing", 0); err == nil {
+		t.Error("expected error for unknown root")
+	}
+}
+
+func TestIncludesCycle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.jce": "#include \"b.jce\"\nmodule a\n{\n    struct A { 0 require int id; };\n};\n",
+		"b.jce": "#include \"./c.jce\"\nmodule b\n{\n    struct B { 0 require int id; };\n};\n",
+		"c.jce": "#include \"a.jce\"\nmodule c\n{\n    struct C { 0 require int id; };\n};\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a := filepath.Join(dir, "a.jce")
+	if _, err := parser.ParseSource(a, []byte(files["a.jce"])); err == nil {
+		t.Fatal("expected parse error for include cycle")
+	}
+
+	g, err := Includes([]string{a}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cycles := g.Cycles()
+	if len(cycles) != 1 || len(cycles[0]) != 3 {
+		t.Fatalf("unexpected cycles %v", cycles)
+	}
+	dot := g.DOT()
+	if strings.Count(dot, " [color=red];") != 3 || strings.Count(dot, "fontcolor=red];") != 3 {
+		t.Errorf("cycle not highlighted:\n%s", dot)
+	}
+}
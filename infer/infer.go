@@ -0,0 +1,303 @@
+// Package infer 从多个 jce 二进制样本推断 struct 的定义
+//
+// 不依赖 schema 解析每个样本（见 wire.Inspect），合并所有样本中同一 tag 的类型：
+//
+//	Int1/Int2/Int4/Int8  int，取值超出 int 范围时为 long
+//	Float/Double         float/double，同时出现时为 double
+//	ZeroTag              取值为 0 的整数或浮点，只出现 ZeroTag 时为 int
+//	String1/String4      string
+//	SimpleList           vector<byte>
+//	List/Map             vector、map，元素的类型由所有样本的元素合并
+//	StructBegin          struct，命名为 上层名_F<tag>
+//
+// 所有样本中都出现的字段为 require，否则为 optional，注释记录出现的次数、整数的取值范围以及类型冲突。
+// 样本的最外层只有一个 tag 为 0 的 struct 时（dynamic.Encode 的输出）取它的成员
+package infer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/format"
+	"github.com/erpc-go/jce2go/version"
+	"github.com/erpc-go/jce2go/wire"
+)
+
+// Sample 一个样本
+type Sample struct {
+	Name string
+	Data []byte
+}
+
+// Warning 无法解析而被跳过的样本
+type Warning struct {
+	File    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.File, w.Message)
+}
+
+// Options 推断的参数
+type Options struct {
+	Module string // module 名，默认为 infer
+	Name   string // 最外层 struct 的名字，默认为 Packet
+}
+
+// Infer 推断 samples 的 struct 定义，返回格式化后的 jce 源码
+func Infer(samples []Sample, opts Options) ([]byte, []Warning, error) {
+	if opts.Module == "" {
+		opts.Module = "infer"
+	}
+	if opts.Name == "" {
+		opts.Name = "Packet"
+	}
+
+	root := newStruct()
+	var warnings []Warning
+	for _, s := range samples {
+		nodes, err := wire.Inspect(s.Data)
+		if err != nil {
+			warnings = append(warnings, Warning{File: s.Name, Message: "skipped, " + err.Error()})
+			continue
+		}
+		if len(nodes) == 1 && nodes[0].Type == wire.StructBegin && nodes[0].Tag == 0 {
+			nodes = nodes[0].Children
+		}
+		root.add(nodes)
+	}
+	if root.count == 0 {
+		return nil, warnings, fmt.Errorf("no valid sample")
+	}
+
+	g := &generator{}
+	g.structDef(opts.Name, root, "samples")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// code generated by jce2go %s.\n// inferred from %d samples, review the types before use.\n\n", version.VERSION, root.count)
+	fmt.Fprintf(&b, "module %s\n{\n", opts.Module)
+	b.Write(g.b.Bytes())
+	b.WriteString("};\n")
+	src, err := format.Source(opts.Module+".jce", b.Bytes())
+	return src, warnings, err
+}
+
+// 一个 struct 所有实例的成员
+type structShape struct {
+	count  int // 实例的个数
+	fields map[int]*shape
+}
+
+func newStruct() *structShape {
+	return &structShape{fields: map[int]*shape{}}
+}
+
+func (st *structShape) add(nodes []*wire.Node) {
+	st.count++
+	seen := map[int]bool{}
+	for _, n := range nodes {
+		if n.Type == wire.StructEnd {
+			continue
+		}
+		f := st.fields[n.Tag]
+		if f == nil {
+			f = newShape()
+			st.fields[n.Tag] = f
+		}
+		// 同一个实例中重复的 tag 只计一次
+		if !seen[n.Tag] {
+			seen[n.Tag] = true
+			f.present++
+		}
+		f.add(n)
+	}
+}
+
+// 一个位置（字段、元素、key、value）上所有的取值
+type shape struct {
+	present  int            // 出现在多少个 struct 实例中，只用于字段
+	kinds    map[string]int // 每种类型出现的次数
+	min, max int64          // 整数的取值范围
+	elem     *shape         // List 的元素
+	key      *shape         // Map 的 key
+	value    *shape         // Map 的 value
+	st       *structShape   // StructBegin 的成员
+}
+
+func newShape() *shape {
+	return &shape{kinds: map[string]int{}, min: math.MaxInt64, max: math.MinInt64}
+}
+
+// 编码类型对应的推断类型
+func kindOf(t wire.Type) string {
+	switch t {
+	case wire.Int1, wire.Int2, wire.Int4, wire.Int8:
+		return "int"
+	case wire.ZeroTag:
+		return "zero"
+	case wire.Float:
+		return "float"
+	case wire.Double:
+		return "double"
+	case wire.String1, wire.String4:
+		return "string"
+	case wire.SimpleList:
+		return "bytes"
+	case wire.List:
+		return "list"
+	case wire.Map:
+		return "map"
+	}
+	return "struct"
+}
+
+func (s *shape) add(n *wire.Node) {
+	kind := kindOf(n.Type)
+	s.kinds[kind]++
+	switch kind {
+	case "int", "zero":
+		v, _ := n.Value.(int64)
+		if v < s.min {
+			s.min = v
+		}
+		if v > s.max {
+			s.max = v
+		}
+	case "list":
+		if s.elem == nil {
+			s.elem = newShape()
+		}
+		for _, c := range n.Children {
+			s.elem.add(c)
+		}
+	case "map":
+		if s.key == nil {
+			s.key, s.value = newShape(), newShape()
+		}
+		for i, c := range n.Children {
+			if i%2 == 0 {
+				s.key.add(c)
+			} else {
+				s.value.add(c)
+			}
+		}
+	case "struct":
+		if s.st == nil {
+			s.st = newStruct()
+		}
+		s.st.add(n.Children)
+	}
+}
+
+// 出现最多的类型，ZeroTag 可以是任意数字类型，只在没有其他类型时使用
+func (s *shape) main() (string, []string) {
+	var kinds []string
+	for k := range s.kinds {
+		if k != "zero" {
+			kinds = append(kinds, k)
+		}
+	}
+	if len(kinds) == 0 {
+		return "zero", nil
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if s.kinds[kinds[i]] != s.kinds[kinds[j]] {
+			return s.kinds[kinds[i]] > s.kinds[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	// float、double 同时出现时为 double
+	if len(kinds) == 2 && (kinds[0] == "float" && kinds[1] == "double" || kinds[0] == "double" && kinds[1] == "float") {
+		return "double", nil
+	}
+	return kinds[0], kinds[1:]
+}
+
+type generator struct {
+	b bytes.Buffer
+}
+
+// 生成 struct 的定义，嵌套的 struct 先定义；unit 为注释中计数的单位
+func (g *generator) structDef(name string, st *structShape, unit string) {
+	var tags []int
+	for tag := range st.fields {
+		tags = append(tags, tag)
+	}
+	sort.Ints(tags)
+
+	var body bytes.Buffer
+	for _, tag := range tags {
+		f := st.fields[tag]
+		typ, notes := g.typ(name, fmt.Sprintf("F%d", tag), f)
+		req := "optional"
+		if f.present == st.count {
+			req = "require"
+		}
+		notes = append([]string{fmt.Sprintf("seen in %d/%d %s", f.present, st.count, unit)}, notes...)
+		fmt.Fprintf(&body, "// %s\n", strings.Join(notes, ", "))
+		fmt.Fprintf(&body, "%d %s %s f%d;\n", tag, req, typ, tag)
+	}
+
+	fmt.Fprintf(&g.b, "struct %s\n{\n", name)
+	g.b.Write(body.Bytes())
+	g.b.WriteString("};\n\n")
+}
+
+// 推断的类型和注释，owner、name 用于嵌套的 struct 的命名
+func (g *generator) typ(owner, name string, s *shape) (string, []string) {
+	kind, others := s.main()
+	var notes []string
+	if len(others) > 0 {
+		var seen []string
+		for _, k := range append([]string{kind}, others...) {
+			seen = append(seen, fmt.Sprintf("%s x%d", k, s.kinds[k]))
+		}
+		notes = append(notes, "conflicting types: "+strings.Join(seen, ", "))
+	}
+
+	switch kind {
+	case "zero", "int":
+		typ := "int"
+		if s.min < math.MinInt32 || s.max > math.MaxInt32 {
+			typ = "long"
+		}
+		if s.min == s.max {
+			notes = append(notes, fmt.Sprintf("always %d", s.min))
+		} else {
+			notes = append(notes, fmt.Sprintf("values %d..%d", s.min, s.max))
+		}
+		return typ, notes
+	case "float", "double", "string":
+		return kind, notes
+	case "bytes":
+		return "vector<byte>", notes
+	case "list":
+		if len(s.elem.kinds) == 0 {
+			return "vector<int>", append(notes, "always empty, element type unknown")
+		}
+		elem, sub := g.typ(owner, name, s.elem)
+		return "vector<" + elem + ">", append(notes, prefix("element ", sub)...)
+	case "map":
+		if len(s.key.kinds) == 0 {
+			return "map<string, string>", append(notes, "always empty, key and value types unknown")
+		}
+		key, ksub := g.typ(owner, name+"Key", s.key)
+		val, vsub := g.typ(owner, name+"Value", s.value)
+		notes = append(notes, prefix("key ", ksub)...)
+		return "map<" + key + ", " + val + ">", append(notes, prefix("value ", vsub)...)
+	}
+	full := owner + "_" + name
+	g.structDef(full, s.st, "instances")
+	return full, notes
+}
+
+func prefix(p string, notes []string) []string {
+	ret := make([]string, len(notes))
+	for i, n := range notes {
+		ret[i] = p + n
+	}
+	return ret
+}
@@ -0,0 +1,67 @@
+package infer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/wire"
+)
+
+func sample(name string, id int64, withName bool, items []int64) Sample {
+	w := wire.NewWriter()
+	w.WriteInt(id, 0)
+	if withName {
+		w.WriteString("alice", 1)
+	}
+	w.WriteHead(wire.List, 2)
+	w.WriteLength(uint32(len(items)))
+	for _, v := range items {
+		w.WriteInt(v, 0)
+	}
+	w.WriteHead(wire.StructBegin, 3)
+	w.WriteDouble(1.5, 0)
+	w.WriteHead(wire.StructEnd, 0)
+	return Sample{Name: name, Data: w.Bytes()}
+}
+
+func TestInfer(t *testing.T) {
+	samples := []Sample{
+		sample("a.bin", 1, true, []int64{1, 2}),
+		sample("b.bin", 1<<40, false, nil),
+		sample("c.bin", 7, true, []int64{3}),
+		{Name: "bad.bin", Data: []byte{0x06, 0xff}},
+	}
+	src, warnings, err := Infer(samples, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || warnings[0].File != "bad.bin" {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	out := string(src)
+	for _, want := range []string{
+		"module infer",
+		"// inferred from 3 samples",
+		"// seen in 3/3 samples, values 1..1099511627776\n        0 require  long        f0;",
+		"// seen in 2/3 samples\n        1 optional string      f1;",
+		"// seen in 3/3 samples, element values 1..3\n        2 require  vector<int> f2;",
+		"// seen in 3/3 instances\n        0 require double f0;",
+		"3 require  Packet_F3   f3;",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "struct Packet_F3") > strings.Index(out, "struct Packet\n") {
+		t.Errorf("nested struct should be defined first:\n%s", out)
+	}
+	if _, err := parser.ParseSource("infer.jce", src); err != nil {
+		t.Errorf("parse output: %v\n%s", err, out)
+	}
+
+	if _, _, err := Infer(samples[3:], Options{}); err == nil {
+		t.Error("expected error without valid sample")
+	}
+}
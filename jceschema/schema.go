@@ -0,0 +1,238 @@
+// Package jceschema 生成代码在运行时的结构描述
+//
+// jce2go 生成的每个包都会在 init 中注册其中所有 struct、enum 的描述，
+// 通用的工具（日志、管理后台、网关等）可以通过全名查找，不需要依赖具体的生成代码：
+//
+//	desc, ok := jceschema.FindStruct("test.RequestPacket")
+package jceschema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Kind 类型的种类
+type Kind int
+
+const (
+	Bool Kind = iota
+	Byte
+	Short
+	Int
+	Long
+	Float
+	Double
+	String
+	Vector
+	Array
+	Map
+	Struct
+	Enum
+)
+
+var kindNames = [...]string{
+	Bool:   "bool",
+	Byte:   "byte",
+	Short:  "short",
+	Int:    "int",
+	Long:   "long",
+	Float:  "float",
+	Double: "double",
+	String: "string",
+	Vector: "vector",
+	Array:  "array",
+	Map:    "map",
+	Struct: "struct",
+	Enum:   "enum",
+}
+
+func (k Kind) String() string {
+	if k >= 0 && int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Type 成员的类型
+type Type struct {
+	Kind     Kind
+	Unsigned bool   // byte、short、int、long 是否为 unsigned
+	Name     string // Struct、Enum 的全名，如 base.request
+	Key      *Type  // Map 的 key
+	Elem     *Type  // Vector、Array 的元素，Map 的 value
+	Len      int    // Array 的长度
+}
+
+// String 返回 jce 中的写法，如 map<string, base::request>
+func (t *Type) String() string {
+	switch t.Kind {
+	case Vector:
+		return "vector<" + t.Elem.String() + ">"
+	case Array:
+		return fmt.Sprintf("%s[%d]", t.Elem.String(), t.Len)
+	case Map:
+		return "map<" + t.Key.String() + ", " + t.Elem.String() + ">"
+	case Struct, Enum:
+		return strings.Replace(t.Name, ".", "::", 1)
+	}
+	if t.Unsigned {
+		return "unsigned " + t.Kind.String()
+	}
+	return t.Kind.String()
+}
+
+// Field struct 的成员
+type Field struct {
+	Tag     int32
+	Name    string // jce 中的名字
+	GoName  string // 生成代码中的字段名
+	Type    *Type
+	Require bool
+	Default string // 源码中的默认值，如 "test"、1、eSendTypeOnline，没有默认值时为空
+	Comment string
+}
+
+// StructDescriptor struct 的描述
+type StructDescriptor struct {
+	Module  string
+	Name    string
+	Comment string
+	Fields  []Field // 按定义顺序
+}
+
+// FullName 全名，如 test.RequestPacket
+func (d *StructDescriptor) FullName() string {
+	return d.Module + "." + d.Name
+}
+
+// FieldByTag 按 tag 查找成员
+func (d *StructDescriptor) FieldByTag(tag int32) (*Field, bool) {
+	for i := range d.Fields {
+		if d.Fields[i].Tag == tag {
+			return &d.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// FieldByName 按 jce 中的名字查找成员
+func (d *StructDescriptor) FieldByName(name string) (*Field, bool) {
+	for i := range d.Fields {
+		if d.Fields[i].Name == name {
+			return &d.Fields[i], true
+		}
+	}
+	return nil, false
+}
+
+// EnumValue 枚举成员
+type EnumValue struct {
+	Name    string
+	Value   int32
+	Comment string
+}
+
+// EnumDescriptor enum 的描述
+type EnumDescriptor struct {
+	Module  string
+	Name    string
+	Comment string
+	Values  []EnumValue // 按定义顺序
+}
+
+// FullName 全名，如 base.EMsgSendType
+func (d *EnumDescriptor) FullName() string {
+	return d.Module + "." + d.Name
+}
+
+// ValueName 取值对应的成员名，多个成员取值相同时返回第一个
+func (d *EnumDescriptor) ValueName(v int32) (string, bool) {
+	for _, ev := range d.Values {
+		if ev.Value == v {
+			return ev.Name, true
+		}
+	}
+	return "", false
+}
+
+var registry = struct {
+	sync.RWMutex
+	structs map[string]*StructDescriptor
+	enums   map[string]*EnumDescriptor
+}{
+	structs: map[string]*StructDescriptor{},
+	enums:   map[string]*EnumDescriptor{},
+}
+
+// RegisterStruct 注册 struct 的描述，由生成代码在 init 中调用。
+// 同一个 jce 文件可能被生成到多个包中，重复注册同一个全名时保留第一次注册的描述
+func RegisterStruct(d *StructDescriptor) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.structs[d.FullName()]; !ok {
+		registry.structs[d.FullName()] = d
+	}
+}
+
+// RegisterEnum 注册 enum 的描述，由生成代码在 init 中调用。
+// 同一个 jce 文件可能被生成到多个包中，重复注册同一个全名时保留第一次注册的描述
+func RegisterEnum(d *EnumDescriptor) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, ok := registry.enums[d.FullName()]; !ok {
+		registry.enums[d.FullName()] = d
+	}
+}
+
+// 全名可以写成 test.RequestPacket 或 test::RequestPacket
+func normalize(name string) string {
+	return strings.Replace(name, "::", ".", 1)
+}
+
+// FindStruct 按全名查找 struct
+func FindStruct(name string) (*StructDescriptor, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	d, ok := registry.structs[normalize(name)]
+	return d, ok
+}
+
+// FindEnum 按全名查找 enum
+func FindEnum(name string) (*EnumDescriptor, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	d, ok := registry.enums[normalize(name)]
+	return d, ok
+}
+
+// Structs 所有已注册的 struct，按全名排序
+func Structs() []*StructDescriptor {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	ret := make([]*StructDescriptor, 0, len(registry.structs))
+	for _, d := range registry.structs {
+		ret = append(ret, d)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].FullName() < ret[j].FullName() })
+	return ret
+}
+
+// Enums 所有已注册的 enum，按全名排序
+func Enums() []*EnumDescriptor {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	ret := make([]*EnumDescriptor, 0, len(registry.enums))
+	for _, d := range registry.enums {
+		ret = append(ret, d)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].FullName() < ret[j].FullName() })
+	return ret
+}
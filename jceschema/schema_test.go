@@ -0,0 +1,69 @@
+package jceschema
+
+import "testing"
+
+func init() {
+	RegisterEnum(&EnumDescriptor{
+		Module: "base",
+		Name:   "EMsgSendType",
+		Values: []EnumValue{{Name: "eSendTypeOnline", Value: 1}, {Name: "eSendTypeOffline", Value: 2}},
+	})
+	RegisterStruct(&StructDescriptor{
+		Module: "test",
+		Name:   "RequestPacket",
+		Fields: []Field{
+			{Tag: 1, Name: "b", GoName: "B", Type: &Type{Kind: Byte}, Require: true},
+			{Tag: 2, Name: "m", GoName: "M", Type: &Type{Kind: Map, Key: &Type{Kind: String}, Elem: &Type{Kind: Struct, Name: "base.request"}}},
+			{Tag: 3, Name: "t", GoName: "T", Type: &Type{Kind: Enum, Name: "base.EMsgSendType"}, Default: "eSendTypeOnline"},
+			{Tag: 4, Name: "a", GoName: "A", Type: &Type{Kind: Array, Elem: &Type{Kind: Int, Unsigned: true}, Len: 3}},
+		},
+	})
+}
+
+func TestFind(t *testing.T) {
+	for _, name := range []string{"test.RequestPacket", "test::RequestPacket"} {
+		d, ok := FindStruct(name)
+		if !ok || d.FullName() != "test.RequestPacket" {
+			t.Fatalf("FindStruct(%s) = %v, %v", name, d, ok)
+		}
+	}
+	if _, ok := FindStruct("test.Unknown"); ok {
+		t.Fatal("unexpected struct test.Unknown")
+	}
+
+	d, _ := FindStruct("test.RequestPacket")
+	want := map[int32]string{1: "byte", 2: "map<string, base::request>", 3: "base::EMsgSendType", 4: "unsigned int[3]"}
+	for tag, typ := range want {
+		f, ok := d.FieldByTag(tag)
+		if !ok || f.Type.String() != typ {
+			t.Errorf("tag %d: got %v, want %s", tag, f, typ)
+		}
+	}
+	if f, ok := d.FieldByName("t"); !ok || f.Default != "eSendTypeOnline" {
+		t.Errorf("FieldByName(t) = %v, %v", f, ok)
+	}
+
+	en, ok := FindEnum("base::EMsgSendType")
+	if !ok {
+		t.Fatal("enum base.EMsgSendType not found")
+	}
+	if name, _ := en.ValueName(2); name != "eSendTypeOffline" {
+		t.Errorf("ValueName(2) = %s", name)
+	}
+	if len(Structs()) != 1 || len(Enums()) != 1 {
+		t.Errorf("unexpected registry %v %v", Structs(), Enums())
+	}
+}
+
+// 重复注册时保留第一次注册的描述
+func TestRegisterTwice(t *testing.T) {
+	RegisterStruct(&StructDescriptor{Module: "test", Name: "RequestPacket"})
+	RegisterEnum(&EnumDescriptor{Module: "base", Name: "EMsgSendType"})
+
+	if d, _ := FindStruct("test.RequestPacket"); len(d.Fields) != 4 {
+		t.Errorf("struct replaced by second registration: %+v", d)
+	}
+	if en, _ := FindEnum("base.EMsgSendType"); len(en.Values) != 2 {
+		t.Errorf("enum replaced by second registration: %+v", en)
+	}
+}
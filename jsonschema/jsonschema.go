@@ -0,0 +1,266 @@
+// Package jsonschema 把 jce 文件转换为 JSON Schema（Draft 2020-12）
+//
+// 校验的是生成的 go 代码（encoding/json）输出的 json：
+//
+//	struct           object，属性名为 jce 中的名字，require 成员在 required 中
+//	enum             integer，anyOf 列出每个成员的取值（const）和名字（title）
+//	byte/short/int   integer，minimum、maximum 为对应类型（包括 unsigned）的范围
+//	vector<T>        array，可以为 null；vector<unsigned byte> 为 base64 的 string
+//	T[N]             array，长度为 N
+//	map<K, V>        object，整数 key 为十进制的字符串
+//
+// 每个 jce 文件生成一个 test.schema.json，所有类型在 $defs 中，引用其他文件的类型时使用相对的 $ref
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// Draft schema 的版本
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Options 转换的参数
+type Options struct {
+	// BaseURI $id 的前缀，如 https://example.com/schemas/
+	BaseURI string
+}
+
+// Export 把 set 中所有文件转换为 .schema.json 文件
+func Export(set *descriptor.Set, opts Options) ([]plugin.File, error) {
+	e := &exporter{index: lang.NewIndex(set), files: map[string]string{}}
+	seen := map[string]string{}
+	for _, f := range set.Files {
+		name := FileName(f.Name)
+		if prev, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s and %s both generate %s", prev, f.Name, name)
+		}
+		seen[name] = f.Name
+		for _, st := range f.Structs {
+			e.files[f.Module+"."+st.Name] = name
+		}
+		for _, en := range f.Enums {
+			e.files[f.Module+"."+en.Name] = name
+		}
+	}
+
+	var files []plugin.File
+	for _, f := range set.Files {
+		content, err := e.file(f, opts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, plugin.File{Name: FileName(f.Name), Content: content})
+	}
+	return files, nil
+}
+
+// FileName test.jce 对应的 test.schema.json
+func FileName(name string) string {
+	base := name[strings.LastIndexAny(name, `/\`)+1:]
+	return strings.TrimSuffix(base, ".jce") + ".schema.json"
+}
+
+type exporter struct {
+	index *lang.Index
+	files map[string]string // 类型全名所在的 schema 文件
+	cur   string            // 正在生成的 schema 文件
+}
+
+// object 保持属性顺序的 json object
+type object []field
+
+type field struct {
+	key   string
+	value interface{}
+}
+
+func (o object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, f := range o {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		k, _ := json.Marshal(f.key)
+		v, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteString(":")
+		b.Write(v)
+	}
+	b.WriteString("}")
+	return b.Bytes(), nil
+}
+
+func (e *exporter) file(f *descriptor.File, opts Options) (string, error) {
+	e.cur = FileName(f.Name)
+	var defs object
+	for _, en := range f.Enums {
+		defs = append(defs, field{en.Name, e.enum(en)})
+	}
+	for _, st := range f.Structs {
+		s, err := e.object(f, st)
+		if err != nil {
+			return "", err
+		}
+		defs = append(defs, field{st.Name, s})
+	}
+
+	doc := object{
+		{"$schema", Draft},
+		{"$id", opts.BaseURI + e.cur},
+		{"title", f.Module},
+	}
+	if len(defs) > 0 {
+		doc = append(doc, field{"$defs", defs})
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b) + "\n", nil
+}
+
+func (e *exporter) enum(en *descriptor.Enum) object {
+	var values []interface{}
+	for _, v := range en.Values {
+		o := object{{"const", v.Value}, {"title", v.Name}}
+		if v.Comment != "" {
+			o = append(o, field{"description", v.Comment})
+		}
+		values = append(values, o)
+	}
+	s := object{{"type", "integer"}}
+	if en.Comment != "" {
+		s = append(s, field{"description", en.Comment})
+	}
+	// 有别名时取值相同的成员有多个，使用 anyOf
+	return append(s, field{"anyOf", values})
+}
+
+func (e *exporter) object(f *descriptor.File, st *descriptor.Struct) (object, error) {
+	s := object{{"type", "object"}}
+	if st.Comment != "" {
+		s = append(s, field{"description", st.Comment})
+	}
+	var props object
+	required := []string{}
+	for _, mb := range st.Members {
+		p := e.typ(mb.Type)
+		if mb.Comment != "" {
+			p = append(p, field{"description", mb.Comment})
+		}
+		if mb.Default != "" {
+			def, err := e.defaultValue(mb)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s.%s: %v", f.Name, st.Name, mb.Name, err)
+			}
+			p = append(p, field{"default", def})
+		}
+		props = append(props, field{mb.Name, p})
+		if mb.Required {
+			required = append(required, mb.Name)
+		}
+	}
+	if len(props) > 0 {
+		s = append(s, field{"properties", props})
+	}
+	if len(required) > 0 {
+		s = append(s, field{"required", required})
+	}
+	return s, nil
+}
+
+// 整数类型的范围
+var ranges = map[string][2]string{
+	"byte":           {"-128", "127"},
+	"unsigned byte":  {"0", "255"},
+	"short":          {"-32768", "32767"},
+	"unsigned short": {"0", "65535"},
+	"int":            {"-2147483648", "2147483647"},
+	"unsigned int":   {"0", "4294967295"},
+	"long":           {"-9223372036854775808", "9223372036854775807"},
+}
+
+func (e *exporter) typ(t *descriptor.Type) object {
+	switch t.Kind {
+	case "bool":
+		return object{{"type", "boolean"}}
+	case "float", "double":
+		return object{{"type", "number"}}
+	case "string":
+		return object{{"type", "string"}}
+	case "byte", "short", "int", "long":
+		name := t.Kind
+		if t.IsUnsigned {
+			name = "unsigned " + name
+		}
+		r := ranges[name]
+		return object{{"type", "integer"}, {"minimum", json.Number(r[0])}, {"maximum", json.Number(r[1])}}
+	case "vector":
+		// go 中 []uint8 编码为 base64，nil 的 slice 编码为 null
+		if elem := t.Params[0]; elem.Kind == "byte" && elem.IsUnsigned {
+			return object{{"type", []string{"string", "null"}}, {"contentEncoding", "base64"}}
+		}
+		return object{{"type", []string{"array", "null"}}, {"items", e.typ(t.Params[0])}}
+	case "array":
+		return object{{"type", "array"}, {"items", e.typ(t.Params[0])}, {"minItems", t.Len}, {"maxItems", t.Len}}
+	case "map":
+		s := object{{"type", []string{"object", "null"}}}
+		switch key := t.Params[0]; key.Kind {
+		case "string":
+		case "byte", "short", "int", "long", "enum":
+			s = append(s, field{"propertyNames", object{{"pattern", "^-?[0-9]+$"}}})
+		default:
+			s = append(s, field{"$comment", "encoding/json does not support map key " + key.Kind})
+		}
+		return append(s, field{"additionalProperties", e.typ(t.Params[1])})
+	}
+	return object{{"$ref", e.ref(t.Name)}}
+}
+
+// 引用 $defs 中的类型，其他文件中的类型使用相对路径
+func (e *exporter) ref(name string) string {
+	_, short := lang.SplitName(name)
+	if file := e.files[name]; file != e.cur {
+		return file + "#/$defs/" + short
+	}
+	return "#/$defs/" + short
+}
+
+// 默认值对应的 json 值
+func (e *exporter) defaultValue(mb *descriptor.Member) (interface{}, error) {
+	switch mb.Type.Kind {
+	case "enum":
+		v, err := e.index.EnumDefault(mb.Type, mb.Default)
+		if err != nil {
+			return nil, err
+		}
+		return v.Value, nil
+	case "string":
+		if s, err := strconv.Unquote(mb.Default); err == nil {
+			return s, nil
+		}
+		return strings.Trim(mb.Default, `"`), nil
+	case "bool":
+		return strconv.ParseBool(mb.Default)
+	}
+	if n, err := strconv.ParseInt(mb.Default, 0, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(mb.Default, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid default value %s", mb.Default)
+	}
+	return f, nil
+}
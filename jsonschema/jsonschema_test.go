@@ -0,0 +1,130 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+const baseSchema = `module base
+{
+    enum Color
+    {
+        Red = 1,
+        Green,
+        Verde = 2,
+    };
+
+    struct Item
+    {
+        0 require int id;
+    };
+};
+`
+
+const schema = `#include "base.jce"
+
+module test
+{
+    // 测试的结构体
+    struct Packet
+    {
+        0 require  byte                   b;
+        1 optional string                 s = "hi";
+        2 optional unsigned short         us;
+        3 optional base::Color            c = base::Green;
+        4 optional vector<unsigned byte>  raw;
+        5 optional vector<base::Item>     items;
+        6 optional map<int, string>       m;
+        7 optional int                    fixed[2];
+        8 require  long                   l;
+    };
+};
+`
+
+func export(t *testing.T) (string, []string) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{"base.jce": baseSchema, "test.jce": schema} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := parser.ParseSource(filepath.Join(dir, "test.jce"), []byte(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Export(descriptor.Build(p), Options{BaseURI: "https://example.com/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		if !json.Valid([]byte(f.Content)) {
+			t.Fatalf("invalid json %s:\n%s", f.Name, f.Content)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, f.Name), []byte(f.Content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, f.Name)
+	}
+	return dir, names
+}
+
+func TestExport(t *testing.T) {
+	dir, names := export(t)
+	if strings.Join(names, ",") != "base.schema.json,test.schema.json" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	b, _ := ioutil.ReadFile(filepath.Join(dir, "test.schema.json"))
+	var doc struct {
+		Schema string `json:"$schema"`
+		ID     string `json:"$id"`
+		Defs   map[string]struct {
+			Description string                     `json:"description"`
+			Properties  map[string]json.RawMessage `json:"properties"`
+			Required    []string                   `json:"required"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Schema != Draft || doc.ID != "https://example.com/test.schema.json" {
+		t.Errorf("unexpected header %s %s", doc.Schema, doc.ID)
+	}
+	packet := doc.Defs["Packet"]
+	if packet.Description != "测试的结构体" || strings.Join(packet.Required, ",") != "b,l" {
+		t.Errorf("unexpected Packet %+v", packet)
+	}
+	for name, want := range map[string]string{
+		"b":     `"minimum":-128,"maximum":127`,
+		"s":     `"default":"hi"`,
+		"us":    `"minimum":0,"maximum":65535`,
+		"c":     `{"$ref":"base.schema.json#/$defs/Color","default":2}`,
+		"raw":   `"contentEncoding":"base64"`,
+		"items": `"items":{"$ref":"base.schema.json#/$defs/Item"}`,
+		"m":     `"propertyNames":{"pattern":"^-?[0-9]+$"}`,
+		"fixed": `"minItems":2,"maxItems":2`,
+		"l":     `"maximum":9223372036854775807`,
+	} {
+		if got := compact(packet.Properties[name]); !strings.Contains(got, want) {
+			t.Errorf("%s: missing %s in %s", name, want, got)
+		}
+	}
+
+	b, _ = ioutil.ReadFile(filepath.Join(dir, "base.schema.json"))
+	if got := compact(b); !strings.Contains(got, `"anyOf":[{"const":1,"title":"Red"},{"const":2,"title":"Green"},{"const":2,"title":"Verde"}]`) {
+		t.Errorf("unexpected enum in %s", got)
+	}
+}
+
+func compact(b []byte) string {
+	var buf bytes.Buffer
+	json.Compact(&buf, b)
+	return buf.String()
+}
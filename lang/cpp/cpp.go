@@ -0,0 +1,317 @@
+// Package cpp 生成只有头文件的 C++ 代码（C++11）：struct、enum class、constexpr 常量
+//
+// 每个 jce 文件生成一个同名的 .h 文件，#include 关系和 jce 文件相同，module 对应 namespace。
+// struct 的 writeTo、readFrom 是模板成员函数，可以使用任何提供 write(v, tag)、
+// read(v, tag, required) 的 writer、reader，一起生成的运行时 jce.h 提供了默认的实现。
+// 类型的对应关系：
+//
+//	bool                       bool
+//	byte、short、int、long     int8_t、int16_t、int32_t、int64_t（unsigned 对应 uintN_t）
+//	float、double              float、double
+//	string                     std::string
+//	vector<T>、array<T>        std::vector<T>
+//	map<K, V>                  std::map<K, V>
+//	enum                       enum class : int32_t
+package cpp
+
+import (
+	_ "embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+//go:embed runtime/jce.h
+var runtime string
+
+// RuntimeName 运行时的文件名
+const RuntimeName = "jce.h"
+
+// C++ 的关键字，不能作为成员名
+var keywords = map[string]bool{
+	"alignas": true, "alignof": true, "and": true, "asm": true, "auto": true, "bool": true, "break": true,
+	"case": true, "catch": true, "char": true, "class": true, "const": true, "constexpr": true,
+	"continue": true, "decltype": true, "default": true, "delete": true, "do": true, "double": true,
+	"else": true, "enum": true, "explicit": true, "export": true, "extern": true, "false": true,
+	"float": true, "for": true, "friend": true, "goto": true, "if": true, "inline": true, "int": true,
+	"long": true, "mutable": true, "namespace": true, "new": true, "noexcept": true, "not": true,
+	"nullptr": true, "operator": true, "or": true, "private": true, "protected": true, "public": true,
+	"register": true, "return": true, "short": true, "signed": true, "sizeof": true, "static": true,
+	"struct": true, "switch": true, "template": true, "this": true, "throw": true, "true": true,
+	"try": true, "typedef": true, "typename": true, "union": true, "unsigned": true, "using": true,
+	"virtual": true, "void": true, "volatile": true, "while": true, "xor": true,
+}
+
+// Generate 生成 set 中所有文件的头文件以及运行时，没有支持的参数
+func Generate(set *descriptor.Set, param string) ([]plugin.File, error) {
+	opts, err := lang.ParseParam(param)
+	if err != nil {
+		return nil, err
+	}
+	for k := range opts {
+		return nil, fmt.Errorf("unknown cpp option %q", k)
+	}
+
+	g := &generator{x: lang.NewIndex(set)}
+	files := []plugin.File{{Name: RuntimeName, Content: runtime}}
+	seen := map[string]string{RuntimeName: ""}
+	for _, f := range set.Files {
+		name := fileName(f.Name)
+		if prev, ok := seen[name]; ok {
+			if prev == "" {
+				return nil, fmt.Errorf("%s generates %s, which conflicts with the runtime", f.Name, name)
+			}
+			return nil, fmt.Errorf("%s and %s both generate %s", prev, f.Name, name)
+		}
+		seen[name] = f.Name
+
+		code, err := g.genFile(f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, plugin.File{Name: name, Content: code})
+	}
+	return files, nil
+}
+
+// test.jce 生成 test.h
+func fileName(jce string) string {
+	base := path.Base(strings.ReplaceAll(jce, "\\", "/"))
+	return strings.TrimSuffix(base, path.Ext(base)) + ".h"
+}
+
+// test.h 的 include guard 为 JCE2GO_TEST_H_
+func guard(name string) string {
+	b := []byte(strings.ToUpper(name))
+	for i, c := range b {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return "JCE2GO_" + string(b) + "_"
+}
+
+type generator struct {
+	x *lang.Index
+	f *descriptor.File
+	p *lang.Printer
+}
+
+type genError string
+
+func fail(format string, args ...interface{}) {
+	panic(genError(fmt.Sprintf(format, args...)))
+}
+
+func (g *generator) genFile(f *descriptor.File) (code string, err error) {
+	// 类型错误在深层的递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if msg, ok := e.(genError); ok {
+				err = fmt.Errorf("%s: %s", f.Name, string(msg))
+				return
+			}
+			panic(e)
+		}
+	}()
+
+	g.f, g.p = f, lang.NewPrinter("    ")
+	p := g.p
+	name := fileName(f.Name)
+	p.P("// DO NOT EDIT IT.")
+	p.P("// code generated by jce2go %s.", version.VERSION)
+	p.P("// source: %s", path.Base(f.Name))
+	p.P("")
+	p.P("#ifndef %s", guard(name))
+	p.P("#define %s", guard(name))
+	p.P("")
+	p.P("#include <cstdint>")
+	p.P("#include <map>")
+	p.P("#include <string>")
+	p.P("#include <vector>")
+	p.P("")
+	p.P(`#include "%s"`, RuntimeName)
+	for _, inc := range f.Includes {
+		p.P(`#include "%s"`, fileName(inc))
+	}
+	p.P("")
+	p.P("namespace %s {", f.Module)
+
+	for _, en := range f.Enums {
+		g.genEnum(en)
+	}
+	if len(f.Consts) > 0 {
+		p.P("")
+		for _, c := range f.Consts {
+			g.doc(c.Comment)
+			p.P("constexpr %s %s = %s;", g.constType(c.Type), c.Name, g.literal(c.Type, c.Value))
+		}
+	}
+	for _, st := range f.Structs {
+		g.genStruct(st)
+	}
+
+	p.P("")
+	p.P("}  // namespace %s", f.Module)
+	p.P("")
+	p.P("#endif  // %s", guard(name))
+	return p.String(), nil
+}
+
+func (g *generator) doc(comment string) {
+	g.p.Raw(lang.Comment("// ", comment))
+}
+
+func (g *generator) genEnum(en *descriptor.Enum) {
+	p := g.p
+	p.P("")
+	g.doc(en.Comment)
+	p.P("enum class %s : int32_t {", en.Name)
+	p.In()
+	for _, v := range en.Values {
+		g.doc(v.Comment)
+		p.P("%s = %d,", v.Name, v.Value)
+	}
+	p.Out()
+	p.P("};")
+}
+
+// 成员名，和关键字冲突时加上 _ 后缀
+func field(name string) string {
+	if keywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *generator) genStruct(st *descriptor.Struct) {
+	p := g.p
+	p.P("")
+	g.doc(st.Comment)
+	p.P("struct %s {", st.Name)
+	p.In()
+	for _, mb := range st.Members {
+		g.doc(mb.Comment)
+		if v := g.defaultValue(mb); v != "" {
+			p.P("%s %s = %s;", g.typ(mb.Type), field(mb.Name), v)
+		} else {
+			p.P("%s %s;", g.typ(mb.Type), field(mb.Name))
+		}
+	}
+
+	p.P("")
+	p.P("template <typename WriterT>")
+	p.P("void writeTo(WriterT& _os) const {")
+	p.In()
+	for _, mb := range st.Members {
+		p.P("_os.write(%s, %d);", field(mb.Name), mb.Tag)
+	}
+	p.Out()
+	p.P("}")
+
+	// 和 Tars 相同，reader 按 tag 从小到大查找字段
+	members := append([]*descriptor.Member(nil), st.Members...)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].Tag < members[j].Tag })
+	p.P("")
+	p.P("template <typename ReaderT>")
+	p.P("void readFrom(ReaderT& _is) {")
+	p.In()
+	p.P("*this = %s();", st.Name)
+	for _, mb := range members {
+		p.P("_is.read(%s, %d, %t);", field(mb.Name), mb.Tag, mb.Required)
+	}
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("};")
+}
+
+// 类型名，其他 module 的类型带上 namespace
+func (g *generator) ref(name string) string {
+	m, short := lang.SplitName(name)
+	if m == g.f.Module {
+		return short
+	}
+	return m + "::" + short
+}
+
+func (g *generator) typ(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool", "float", "double":
+		return t.Kind
+	case "byte", "short", "int", "long":
+		bits := map[string]string{"byte": "8", "short": "16", "int": "32", "long": "64"}[t.Kind]
+		if t.IsUnsigned {
+			return "uint" + bits + "_t"
+		}
+		return "int" + bits + "_t"
+	case "string":
+		return "std::string"
+	case "vector", "array":
+		return "std::vector<" + g.typ(t.Params[0]) + ">"
+	case "map":
+		return "std::map<" + g.typ(t.Params[0]) + ", " + g.typ(t.Params[1]) + ">"
+	case "struct", "enum":
+		return g.ref(t.Name)
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 常量的类型，字符串使用 const char*
+func (g *generator) constType(t *descriptor.Type) string {
+	if t.Kind == "string" {
+		return "const char*"
+	}
+	return g.typ(t)
+}
+
+// 成员的初始值，string、容器、struct 使用默认构造，返回空
+func (g *generator) defaultValue(mb *descriptor.Member) string {
+	if mb.Default != "" {
+		return g.literal(mb.Type, mb.Default)
+	}
+	switch mb.Type.Kind {
+	case "bool":
+		return "false"
+	case "byte", "short", "int", "long", "float", "double":
+		return "0"
+	case "enum":
+		return g.typ(mb.Type) + "()"
+	}
+	return ""
+}
+
+// 源码中的常量、默认值转换为 C++ 的字面量
+func (g *generator) literal(t *descriptor.Type, v string) string {
+	switch t.Kind {
+	case "long":
+		return v + "LL"
+	case "int":
+		if t.IsUnsigned {
+			return v + "u"
+		}
+	case "float":
+		v = strings.TrimSuffix(v, "f")
+		if strings.ContainsAny(v, "xX") {
+			return v
+		}
+		if !strings.ContainsAny(v, ".eE") {
+			v += ".0"
+		}
+		return v + "f"
+	case "enum":
+		ev, err := g.x.EnumDefault(t, v)
+		if err != nil {
+			fail("%v", err)
+		}
+		return g.ref(t.Name) + "::" + ev.Name
+	}
+	return v
+}
@@ -0,0 +1,98 @@
+package cpp
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang/langtest"
+)
+
+func TestGenerate(t *testing.T) {
+	files := langtest.Generate(t, Generate, "")
+	var code string
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+		if f.Name == "test.h" {
+			code = f.Content
+		}
+	}
+	if strings.Join(names, ",") != "jce.h,base.h,test.h" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	for _, s := range []string{
+		"#ifndef JCE2GO_TEST_H_",
+		`#include "base.h"`,
+		"namespace test {",
+		"enum class Color : int32_t {",
+		"constexpr int16_t VERSION = 3;",
+		`constexpr const char* NAME = "test";`,
+		"    Color c = Color::Green;",
+		"    std::vector<uint8_t> raw;",
+		"    std::map<int32_t, base::Item> byId;",
+		"    float f = 1.0f;",
+		"    std::string default_;",
+		"        _os.write(default_, 15);",
+		"        _is.read(b, 0, true);",
+		"#endif  // JCE2GO_TEST_H_",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+
+	if _, err := Generate(&descriptor.Set{}, "std=c++17"); err == nil {
+		t.Fatal("expect error for unknown option")
+	}
+}
+
+// C++ 解码 fixtures.hex 中的数据后重新编码，结果和原来的数据相同
+func TestRoundTrip(t *testing.T) {
+	cxx, err := exec.LookPath("g++")
+	if err != nil {
+		t.Skip("g++ not found")
+	}
+	files := langtest.Generate(t, Generate, "")
+
+	dir := t.TempDir()
+	langtest.WriteFiles(t, dir, files, nil)
+	main := `#include <cstdio>
+#include <string>
+
+#include "test.h"
+
+int main(int argc, char** argv) {
+    for (int i = 1; i < argc; i++) {
+        std::string hex = argv[i], data;
+        for (size_t j = 0; j + 1 < hex.size(); j += 2) {
+            data.push_back(static_cast<char>(std::stoi(hex.substr(j, 2), nullptr, 16)));
+        }
+        test::Packet v;
+        jce::decode(data, v);
+        for (unsigned char c : jce::encode(v)) {
+            std::printf("%02x", c);
+        }
+        std::printf("\n");
+    }
+    return 0;
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.cpp"), []byte(main), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	bin := filepath.Join(dir, "main")
+	if out, err := exec.Command(cxx, "-std=c++11", "-Wall", "-Werror", "-o", bin, filepath.Join(dir, "main.cpp")).CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	fixtures := langtest.Fixtures(t)
+	out, err := exec.Command(bin, fixtures...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	langtest.Check(t, out, fixtures)
+}
@@ -0,0 +1,520 @@
+// DO NOT EDIT IT.
+// jce 编码的 C++ 运行时，由 jce2go --lang=cpp 生成，只依赖标准库（C++11）。
+//
+// 每个字段由 head + data 组成，head 第一个字节高 4 位是 tag、低 4 位是 type，
+// tag >= 15 时高 4 位为 15，第二个字节存 tag。整数、长度均为大端。
+//
+// 生成的结构体通过模板成员函数编解码：
+//
+//   template <typename Writer> void writeTo(Writer& _os) const;  // 调用 _os.write(value, tag)
+//   template <typename Reader> void readFrom(Reader& _is);       // 调用 _is.read(value, tag, required)
+//
+// 任何提供这两个接口的类型都可以使用，jce::Writer、jce::Reader 是默认的实现。
+// 和 Tars 相同，结构体的字段按 tag 从小到大读取。
+
+#ifndef JCE2GO_JCE_H_
+#define JCE2GO_JCE_H_
+
+#include <cstdint>
+#include <cstring>
+#include <limits>
+#include <map>
+#include <stdexcept>
+#include <string>
+#include <type_traits>
+#include <utility>
+#include <vector>
+
+namespace jce {
+
+enum Type : uint8_t {
+    INT1 = 0,
+    INT2 = 1,
+    INT4 = 2,
+    INT8 = 3,
+    FLOAT = 4,
+    DOUBLE = 5,
+    STRING1 = 6,
+    STRING4 = 7,
+    MAP = 8,
+    LIST = 9,
+    STRUCT_BEGIN = 10,
+    STRUCT_END = 11,
+    ZERO_TAG = 12,
+    SIMPLE_LIST = 13,
+};
+
+// 数据格式错误，offset 为出错位置的字节偏移
+class Exception : public std::runtime_error {
+public:
+    Exception(const std::string& message, size_t offset)
+        : std::runtime_error("offset " + std::to_string(offset) + ": " + message), offset_(offset) {}
+
+    size_t offset() const { return offset_; }
+
+private:
+    size_t offset_;
+};
+
+// 把 jce 数据写入内存
+class Writer {
+public:
+    // 已写入的数据
+    const std::string& bytes() const { return buf_; }
+
+    void writeHead(uint8_t type, uint8_t tag) {
+        if (tag < 15) {
+            buf_.push_back(static_cast<char>(tag << 4 | type));
+        } else {
+            buf_.push_back(static_cast<char>(0xF0 | type));
+            buf_.push_back(static_cast<char>(tag));
+        }
+    }
+
+    void writeLength(uint32_t n) { put(n, 4); }
+
+    // 整数按取值选择最小的编码类型
+    void writeInt(int64_t v, uint8_t tag) {
+        if (v == 0) {
+            writeHead(ZERO_TAG, tag);
+        } else if (v >= INT8_MIN && v <= INT8_MAX) {
+            writeHead(INT1, tag);
+            put(static_cast<uint64_t>(v), 1);
+        } else if (v >= INT16_MIN && v <= INT16_MAX) {
+            writeHead(INT2, tag);
+            put(static_cast<uint64_t>(v), 2);
+        } else if (v >= INT32_MIN && v <= INT32_MAX) {
+            writeHead(INT4, tag);
+            put(static_cast<uint64_t>(v), 4);
+        } else {
+            writeHead(INT8, tag);
+            put(static_cast<uint64_t>(v), 8);
+        }
+    }
+
+    void write(bool v, uint8_t tag) { writeInt(v ? 1 : 0, tag); }
+    void write(int8_t v, uint8_t tag) { writeInt(v, tag); }
+    void write(uint8_t v, uint8_t tag) { writeInt(v, tag); }
+    void write(int16_t v, uint8_t tag) { writeInt(v, tag); }
+    void write(uint16_t v, uint8_t tag) { writeInt(v, tag); }
+    void write(int32_t v, uint8_t tag) { writeInt(v, tag); }
+    void write(uint32_t v, uint8_t tag) { writeInt(v, tag); }
+    void write(int64_t v, uint8_t tag) { writeInt(v, tag); }
+
+    void write(float v, uint8_t tag) {
+        if (v == 0) {
+            writeHead(ZERO_TAG, tag);
+            return;
+        }
+        uint32_t bits;
+        std::memcpy(&bits, &v, 4);
+        writeHead(FLOAT, tag);
+        put(bits, 4);
+    }
+
+    void write(double v, uint8_t tag) {
+        if (v == 0) {
+            writeHead(ZERO_TAG, tag);
+            return;
+        }
+        uint64_t bits;
+        std::memcpy(&bits, &v, 8);
+        writeHead(DOUBLE, tag);
+        put(bits, 8);
+    }
+
+    // 长度不超过 255 时使用 String1
+    void write(const std::string& v, uint8_t tag) {
+        if (v.size() <= 0xFF) {
+            writeHead(STRING1, tag);
+            buf_.push_back(static_cast<char>(v.size()));
+        } else {
+            writeHead(STRING4, tag);
+            writeLength(static_cast<uint32_t>(v.size()));
+        }
+        buf_.append(v);
+    }
+
+    // vector<byte> 按 SimpleList 编码
+    void write(const std::vector<int8_t>& v, uint8_t tag) { writeSimpleList(v.data(), v.size(), tag); }
+    void write(const std::vector<uint8_t>& v, uint8_t tag) { writeSimpleList(v.data(), v.size(), tag); }
+
+    template <typename T>
+    void write(const std::vector<T>& v, uint8_t tag) {
+        writeHead(LIST, tag);
+        writeLength(static_cast<uint32_t>(v.size()));
+        for (typename std::vector<T>::const_iterator it = v.begin(); it != v.end(); ++it) {
+            T e = *it;
+            write(e, 0);
+        }
+    }
+
+    template <typename K, typename V>
+    void write(const std::map<K, V>& v, uint8_t tag) {
+        writeHead(MAP, tag);
+        writeLength(static_cast<uint32_t>(v.size()));
+        for (typename std::map<K, V>::const_iterator it = v.begin(); it != v.end(); ++it) {
+            write(it->first, 0);
+            write(it->second, 1);
+        }
+    }
+
+    // enum 按 int 编码
+    template <typename T>
+    typename std::enable_if<std::is_enum<T>::value>::type write(const T& v, uint8_t tag) {
+        writeInt(static_cast<int64_t>(v), tag);
+    }
+
+    // 结构体，和生成的 go 代码相同，StructBegin 的 tag 总是 0，不写入成员的 tag
+    template <typename T>
+    typename std::enable_if<std::is_class<T>::value>::type write(const T& v, uint8_t) {
+        writeHead(STRUCT_BEGIN, 0);
+        v.writeTo(*this);
+        writeHead(STRUCT_END, 0);
+    }
+
+private:
+    void put(uint64_t v, int n) {
+        for (int i = n - 1; i >= 0; i--) {
+            buf_.push_back(static_cast<char>(v >> (i * 8)));
+        }
+    }
+
+    void writeSimpleList(const void* data, size_t n, uint8_t tag) {
+        writeHead(SIMPLE_LIST, tag);
+        writeLength(static_cast<uint32_t>(n));
+        buf_.append(static_cast<const char*>(data), n);
+    }
+
+    std::string buf_;
+};
+
+class Reader;
+
+// 生成的结构体，即提供 readFrom 的类型
+template <typename T, typename = void>
+struct isStruct : std::false_type {};
+
+template <typename T>
+struct isStruct<T, decltype(std::declval<T&>().readFrom(std::declval<Reader&>()), void())> : std::true_type {};
+
+// 从内存中读取 jce 数据，不持有数据
+class Reader {
+public:
+    Reader(const void* data, size_t len) : buf_(static_cast<const uint8_t*>(data)), len_(len), off_(0) {}
+    explicit Reader(const std::string& data) : Reader(data.data(), data.size()) {}
+
+    // 当前读取位置
+    size_t offset() const { return off_; }
+
+    // 读取 head，返回 type，tag 写入 tag
+    uint8_t readHead(uint8_t& tag) {
+        size_t start = off_;
+        uint8_t b = next(1)[0];
+        uint8_t type = b & 0x0F;
+        tag = b >> 4;
+        if (tag == 15) {
+            tag = next(1)[0];
+        }
+        if (type > SIMPLE_LIST) {
+            throw Exception("invalid type " + std::to_string(type), start);
+        }
+        return type;
+    }
+
+    // 跳到当前结构体中 tag 对应的字段，找到时读取 head 并返回 true，type 写入 type；
+    // 遇到更大的 tag 或者 StructEnd 时不移动读取位置，返回 false。
+    // 生成的 go 代码写入结构体成员时 StructBegin 的 tag 总是 0，isStruct 为 true 时
+    // 按位置把 tag 更小的 StructBegin 对应到这个字段
+    bool skipToTag(uint8_t tag, uint8_t& type, bool isStruct = false) {
+        while (off_ < len_) {
+            size_t start = off_;
+            uint8_t t;
+            uint8_t ty = readHead(t);
+            if (ty == STRUCT_END || t > tag) {
+                off_ = start;
+                return false;
+            }
+            if (t == tag || (isStruct && ty == STRUCT_BEGIN)) {
+                type = ty;
+                return true;
+            }
+            skip(ty);
+        }
+        return false;
+    }
+
+    // 读取 tag 对应的字段，字段不存在时 required 为 true 报错，否则保持 v 不变
+    template <typename T>
+    void read(T& v, uint8_t tag, bool required) {
+        uint8_t type;
+        if (!skipToTag(tag, type, isStruct<T>::value)) {
+            if (required) {
+                throw Exception("require field tag " + std::to_string(tag) + " not found", off_);
+            }
+            return;
+        }
+        get(v, type);
+    }
+
+    // 跳过一个指定类型的 data
+    void skip(uint8_t type) {
+        switch (type) {
+        case ZERO_TAG:
+        case STRUCT_END:
+            return;
+        case INT1:
+        case INT2:
+        case INT4:
+        case INT8:
+            readInt(type);
+            return;
+        case FLOAT:
+            next(4);
+            return;
+        case DOUBLE:
+            next(8);
+            return;
+        case STRING1:
+            next(next(1)[0]);
+            return;
+        case STRING4:
+        case SIMPLE_LIST:
+            next(readLength());
+            return;
+        case LIST:
+        case MAP: {
+            uint64_t n = readLength();
+            if (type == MAP) {
+                n *= 2;
+            }
+            for (uint64_t i = 0; i < n; i++) {
+                skipHead();
+            }
+            return;
+        }
+        case STRUCT_BEGIN:
+            skipToStructEnd();
+            return;
+        }
+        throw Exception("invalid type " + std::to_string(type), off_);
+    }
+
+    // 跳过结构体剩余的字段，直到读到 StructEnd
+    void skipToStructEnd() {
+        for (;;) {
+            uint8_t tag;
+            uint8_t type = readHead(tag);
+            if (type == STRUCT_END) {
+                return;
+            }
+            skip(type);
+        }
+    }
+
+    // 读取 4B 长度，长度超过剩余数据时报错
+    uint32_t readLength() {
+        size_t start = off_;
+        const uint8_t* b = next(4);
+        uint32_t n = static_cast<uint32_t>(b[0]) << 24 | static_cast<uint32_t>(b[1]) << 16 |
+                     static_cast<uint32_t>(b[2]) << 8 | b[3];
+        if (n > len_ - off_) {
+            throw Exception("length " + std::to_string(n) + " exceeds remaining " + std::to_string(len_ - off_) + " bytes",
+                            start);
+        }
+        return n;
+    }
+
+    int64_t readInt(uint8_t type) {
+        switch (type) {
+        case ZERO_TAG:
+            return 0;
+        case INT1:
+            return static_cast<int8_t>(get(1));
+        case INT2:
+            return static_cast<int16_t>(get(2));
+        case INT4:
+            return static_cast<int32_t>(get(4));
+        case INT8:
+            return static_cast<int64_t>(get(8));
+        }
+        throw mismatch(type, "an integer");
+    }
+
+private:
+    const uint8_t* next(size_t n) {
+        if (len_ - off_ < n) {
+            throw Exception("unexpected end of data, need " + std::to_string(n) + " bytes, have " +
+                                std::to_string(len_ - off_),
+                            off_);
+        }
+        const uint8_t* p = buf_ + off_;
+        off_ += n;
+        return p;
+    }
+
+    uint64_t get(size_t n) {
+        const uint8_t* b = next(n);
+        uint64_t v = 0;
+        for (size_t i = 0; i < n; i++) {
+            v = v << 8 | b[i];
+        }
+        return v;
+    }
+
+    void skipHead() {
+        uint8_t tag;
+        skip(readHead(tag));
+    }
+
+    Exception mismatch(uint8_t type, const char* expect) const {
+        return Exception("type " + std::to_string(type) + " is not " + expect, off_);
+    }
+
+    template <typename T>
+    void getInt(T& v, uint8_t type) {
+        size_t start = off_;
+        int64_t n = readInt(type);
+        if (n < static_cast<int64_t>(std::numeric_limits<T>::min()) ||
+            n > static_cast<int64_t>(std::numeric_limits<T>::max())) {
+            throw Exception(std::to_string(n) + " overflows", start);
+        }
+        v = static_cast<T>(n);
+    }
+
+    void get(bool& v, uint8_t type) { v = readInt(type) != 0; }
+    void get(int8_t& v, uint8_t type) { getInt(v, type); }
+    void get(uint8_t& v, uint8_t type) { getInt(v, type); }
+    void get(int16_t& v, uint8_t type) { getInt(v, type); }
+    void get(uint16_t& v, uint8_t type) { getInt(v, type); }
+    void get(int32_t& v, uint8_t type) { getInt(v, type); }
+    void get(uint32_t& v, uint8_t type) { getInt(v, type); }
+    void get(int64_t& v, uint8_t type) { v = readInt(type); }
+
+    void get(float& v, uint8_t type) {
+        double d;
+        get(d, type);
+        v = static_cast<float>(d);
+    }
+
+    void get(double& v, uint8_t type) {
+        if (type == ZERO_TAG) {
+            v = 0;
+        } else if (type == FLOAT) {
+            uint32_t bits = static_cast<uint32_t>(get(4));
+            float f;
+            std::memcpy(&f, &bits, 4);
+            v = f;
+        } else if (type == DOUBLE) {
+            uint64_t bits = get(8);
+            std::memcpy(&v, &bits, 8);
+        } else {
+            throw mismatch(type, "a float");
+        }
+    }
+
+    void get(std::string& v, uint8_t type) {
+        size_t n;
+        if (type == STRING1) {
+            n = next(1)[0];
+        } else if (type == STRING4) {
+            n = readLength();
+        } else {
+            throw mismatch(type, "a string");
+        }
+        v.assign(reinterpret_cast<const char*>(next(n)), n);
+    }
+
+    // vector<byte> 也兼容按 List 编码的数据
+    template <typename T>
+    void getBytes(std::vector<T>& v, uint8_t type) {
+        v.clear();
+        if (type == LIST) {
+            uint32_t n = readLength();
+            for (uint32_t i = 0; i < n; i++) {
+                T e = 0;
+                read(e, 0, true);
+                v.push_back(e);
+            }
+            return;
+        }
+        if (type != SIMPLE_LIST) {
+            throw mismatch(type, "a SimpleList");
+        }
+        uint32_t n = readLength();
+        const uint8_t* b = next(n);
+        v.assign(b, b + n);
+    }
+
+    void get(std::vector<int8_t>& v, uint8_t type) { getBytes(v, type); }
+    void get(std::vector<uint8_t>& v, uint8_t type) { getBytes(v, type); }
+
+    template <typename T>
+    void get(std::vector<T>& v, uint8_t type) {
+        if (type != LIST) {
+            throw mismatch(type, "a List");
+        }
+        v.clear();
+        uint32_t n = readLength();
+        for (uint32_t i = 0; i < n; i++) {
+            T e = T();
+            read(e, 0, true);
+            v.push_back(std::move(e));
+        }
+    }
+
+    template <typename K, typename V>
+    void get(std::map<K, V>& v, uint8_t type) {
+        if (type != MAP) {
+            throw mismatch(type, "a Map");
+        }
+        v.clear();
+        uint32_t n = readLength();
+        for (uint32_t i = 0; i < n; i++) {
+            K k = K();
+            V e = V();
+            read(k, 0, true);
+            read(e, 1, true);
+            v[std::move(k)] = std::move(e);
+        }
+    }
+
+    template <typename T>
+    typename std::enable_if<std::is_enum<T>::value>::type get(T& v, uint8_t type) {
+        int32_t n;
+        getInt(n, type);
+        v = static_cast<T>(n);
+    }
+
+    template <typename T>
+    typename std::enable_if<std::is_class<T>::value>::type get(T& v, uint8_t type) {
+        if (type != STRUCT_BEGIN) {
+            throw mismatch(type, "a StructBegin");
+        }
+        v.readFrom(*this);
+        skipToStructEnd();
+    }
+
+    const uint8_t* buf_;
+    size_t len_;
+    size_t off_;
+};
+
+// 编码结构体，和 go 生成的代码相同，最外层是 tag 为 0 的结构体
+template <typename T>
+std::string encode(const T& v) {
+    Writer w;
+    w.write(v, 0);
+    return w.bytes();
+}
+
+// 解码 encode 的结果
+template <typename T>
+void decode(const std::string& data, T& v) {
+    Reader r(data);
+    r.read(v, 0, true);
+}
+
+}  // namespace jce
+
+#endif  // JCE2GO_JCE_H_
@@ -0,0 +1,583 @@
+// Package java 生成 Java 代码，接口和 Tars Java 的约定兼容：
+// struct 继承 JceStruct，实现 writeTo(JceOutputStream)、readFrom(JceInputStream)，
+// enum 生成带 value()、convert(int) 的 java enum，常量生成每个 module 的 Consts 类
+//
+// com.qq.taf.jce、Tars 的 List、Map、SimpleList 的长度按整数字段编码，和这里 4B 的长度不兼容，
+// 所以编解码使用同时生成的运行时（JceStruct、JceInputStream、JceOutputStream 等，只依赖标准库），
+// --lang_opt 支持以下参数：
+//
+//	runtime=PKG        运行时的包名，默认 jce
+//	prefix=PKG         所有 module 的包名前缀，如 prefix=com.foo 时 module test 对应 com.foo.test
+//	package.MODULE=PKG 单独指定某个 module 的包名，优先于 prefix
+//
+// 类型的对应关系和 Tars Java 相同：unsigned 类型使用更宽的类型，enum 成员使用 int，
+// vector<byte> 对应 byte[]，vector 对应 ArrayList，map 对应 HashMap
+package java
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+//go:embed runtime/*.java
+var runtime embed.FS
+
+// java 的关键字，不能作为成员名
+var keywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "class": true, "const": true, "continue": true, "default": true, "do": true, "double": true,
+	"else": true, "enum": true, "extends": true, "final": true, "finally": true, "float": true, "for": true,
+	"goto": true, "if": true, "implements": true, "import": true, "instanceof": true, "int": true,
+	"interface": true, "long": true, "native": true, "new": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "short": true, "static": true, "strictfp": true,
+	"super": true, "switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "try": true, "void": true, "volatile": true, "while": true, "true": true,
+	"false": true, "null": true,
+}
+
+// Generate 生成 set 中所有文件的代码，每个 struct、enum 一个 .java 文件
+func Generate(set *descriptor.Set, param string) ([]plugin.File, error) {
+	opts, err := lang.ParseParam(param)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{x: lang.NewIndex(set), runtime: "jce", packages: map[string]string{}}
+	for k, v := range opts {
+		switch {
+		case k == "runtime":
+			g.runtime = v
+		case k == "prefix":
+			g.prefix = v
+		case strings.HasPrefix(k, "package."):
+			g.packages[strings.TrimPrefix(k, "package.")] = v
+		default:
+			return nil, fmt.Errorf("unknown java option %q", k)
+		}
+	}
+
+	files, err := g.genRuntime()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]string{}
+	for _, f := range files {
+		seen[f.Name] = "runtime"
+	}
+	for _, f := range set.Files {
+		out, err := g.genFile(f)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range out {
+			if prev, ok := seen[o.Name]; ok {
+				return nil, fmt.Errorf("%s and %s both generate %s", prev, f.Name, o.Name)
+			}
+			seen[o.Name] = f.Name
+		}
+		files = append(files, out...)
+	}
+	return files, nil
+}
+
+// 运行时的文件，包名替换为 runtime 参数
+func (g *generator) genRuntime() ([]plugin.File, error) {
+	entries, err := runtime.ReadDir("runtime")
+	if err != nil {
+		return nil, err
+	}
+	dir := strings.ReplaceAll(g.runtime, ".", "/")
+	var files []plugin.File
+	for _, e := range entries {
+		data, err := runtime.ReadFile("runtime/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		content := strings.Replace(string(data), "\npackage jce;\n", "\npackage "+g.runtime+";\n", 1)
+		files = append(files, plugin.File{Name: dir + "/" + e.Name(), Content: content})
+	}
+	return files, nil
+}
+
+type generator struct {
+	x        *lang.Index
+	runtime  string
+	prefix   string
+	packages map[string]string
+
+	f  *descriptor.File
+	p  *lang.Printer
+	vc int // 生成局部变量名
+}
+
+type genError string
+
+func fail(format string, args ...interface{}) {
+	panic(genError(fmt.Sprintf(format, args...)))
+}
+
+// module 对应的 java 包名
+func (g *generator) pkg(module string) string {
+	if p, ok := g.packages[module]; ok {
+		return p
+	}
+	if g.prefix != "" {
+		return g.prefix + "." + module
+	}
+	return module
+}
+
+func (g *generator) genFile(f *descriptor.File) (files []plugin.File, err error) {
+	// 类型错误在深层的递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if msg, ok := e.(genError); ok {
+				err = fmt.Errorf("%s: %s", f.Name, string(msg))
+				return
+			}
+			panic(e)
+		}
+	}()
+
+	g.f = f
+	dir := strings.ReplaceAll(g.pkg(f.Module), ".", "/")
+	emit := func(class string, body func()) {
+		g.p = lang.NewPrinter("    ")
+		g.p.P("// DO NOT EDIT IT.")
+		g.p.P("// code generated by jce2go %s.", version.VERSION)
+		g.p.P("// source: %s", path.Base(f.Name))
+		g.p.P("")
+		g.p.P("package %s;", g.pkg(f.Module))
+		g.p.P("")
+		body()
+		files = append(files, plugin.File{Name: dir + "/" + class + ".java", Content: g.p.String()})
+	}
+
+	for _, en := range f.Enums {
+		en := en
+		emit(lang.Upper(en.Name), func() { g.genEnum(en) })
+	}
+	if len(f.Consts) > 0 {
+		emit("Consts", g.genConsts)
+	}
+	for _, st := range f.Structs {
+		st := st
+		emit(lang.Upper(st.Name), func() { g.genStruct(st) })
+	}
+	return files, nil
+}
+
+// javadoc 注释
+func (g *generator) doc(comment string) {
+	if comment == "" {
+		return
+	}
+	comment = strings.ReplaceAll(comment, "*/", "* /")
+	if !strings.Contains(comment, "\n") {
+		g.p.P("/** %s */", comment)
+		return
+	}
+	g.p.P("/**")
+	for _, line := range strings.Split(comment, "\n") {
+		g.p.P(" * %s", line)
+	}
+	g.p.P(" */")
+}
+
+func (g *generator) genEnum(en *descriptor.Enum) {
+	p := g.p
+	name := lang.Upper(en.Name)
+	g.doc(en.Comment)
+	p.P("public enum %s {", name)
+	p.In()
+	for i, v := range en.Values {
+		g.doc(v.Comment)
+		end := ","
+		if i == len(en.Values)-1 {
+			end = ";"
+		}
+		p.P("%s(%d)%s", v.Name, v.Value, end)
+	}
+	if len(en.Values) == 0 {
+		p.P(";")
+	}
+	p.P("")
+	p.P("private final int value;")
+	p.P("")
+	p.P("%s(int value) {", name)
+	p.P("    this.value = value;")
+	p.P("}")
+	p.P("")
+	p.P("public int value() {")
+	p.P("    return value;")
+	p.P("}")
+	p.P("")
+	p.P("/** 按取值查找枚举，未知的取值返回 null */")
+	p.P("public static %s convert(int value) {", name)
+	p.In()
+	p.P("for (%s v : values()) {", name)
+	p.P("    if (v.value == value) {")
+	p.P("        return v;")
+	p.P("    }")
+	p.P("}")
+	p.P("return null;")
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+}
+
+func (g *generator) genConsts() {
+	p := g.p
+	p.P("public final class Consts {")
+	p.In()
+	p.P("private Consts() {")
+	p.P("}")
+	for _, c := range g.f.Consts {
+		p.P("")
+		g.doc(c.Comment)
+		p.P("public static final %s %s = %s;", g.typ(c.Type), c.Name, g.literal(c.Type, c.Value))
+	}
+	p.Out()
+	p.P("}")
+}
+
+// 成员名，和关键字冲突时加上 _ 后缀
+func field(name string) string {
+	if keywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *generator) genStruct(st *descriptor.Struct) {
+	p := g.p
+	name := lang.Upper(st.Name)
+	g.vc = 0
+
+	g.doc(st.Comment)
+	p.P("public final class %s extends %s.JceStruct {", name, g.runtime)
+	p.In()
+	p.P("private static final long serialVersionUID = 1L;")
+
+	// 解码容器、struct 时使用的原型
+	for _, mb := range st.Members {
+		if g.needCache(mb.Type) {
+			p.P("")
+			p.P("static %s cache_%s;", g.typ(mb.Type), mb.Name)
+		}
+	}
+
+	for _, mb := range st.Members {
+		p.P("")
+		g.doc(mb.Comment)
+		p.P("private %s %s = %s;", g.typ(mb.Type), field(mb.Name), g.defaultValue(mb))
+	}
+
+	p.P("")
+	p.P("public %s() {", name)
+	p.P("}")
+	if len(st.Members) > 0 {
+		var params, assigns []string
+		for _, mb := range st.Members {
+			params = append(params, g.typ(mb.Type)+" "+field(mb.Name))
+			assigns = append(assigns, "this."+field(mb.Name)+" = "+field(mb.Name)+";")
+		}
+		p.P("")
+		p.P("public %s(%s) {", name, strings.Join(params, ", "))
+		p.In()
+		for _, a := range assigns {
+			p.P("%s", a)
+		}
+		p.Out()
+		p.P("}")
+	}
+
+	for _, mb := range st.Members {
+		t, f := g.typ(mb.Type), field(mb.Name)
+		p.P("")
+		p.P("public %s get%s() {", t, lang.Upper(mb.Name))
+		p.P("    return %s;", f)
+		p.P("}")
+		p.P("")
+		p.P("public void set%s(%s %s) {", lang.Upper(mb.Name), t, f)
+		p.P("    this.%s = %s;", f, f)
+		p.P("}")
+	}
+
+	p.P("")
+	p.P("@Override")
+	p.P("public void writeTo(%s.JceOutputStream _os) {", g.runtime)
+	p.In()
+	for _, mb := range st.Members {
+		f := field(mb.Name)
+		if !mb.Required && !g.isPrimitive(mb.Type) {
+			p.P("if (null != %s) {", f)
+			p.P("    _os.write(%s, %d);", f, mb.Tag)
+			p.P("}")
+			continue
+		}
+		p.P("_os.write(%s, %d);", f, mb.Tag)
+	}
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("@Override")
+	p.P("@SuppressWarnings(\"unchecked\")")
+	p.P("public void readFrom(%s.JceInputStream _is) {", g.runtime)
+	p.In()
+	// 运行时按 tag 从小到大查找字段
+	members := append([]*descriptor.Member(nil), st.Members...)
+	sort.SliceStable(members, func(i, j int) bool { return members[i].Tag < members[j].Tag })
+	for _, mb := range members {
+		g.genRead(mb)
+	}
+	p.Out()
+	p.P("}")
+
+	var names []string
+	for _, mb := range st.Members {
+		names = append(names, field(mb.Name))
+	}
+	p.P("")
+	p.P("@Override")
+	p.P("public boolean equals(Object o) {")
+	p.In()
+	p.P("if (this == o) {")
+	p.P("    return true;")
+	p.P("}")
+	p.P("if (o == null || getClass() != o.getClass()) {")
+	p.P("    return false;")
+	p.P("}")
+	if len(names) == 0 {
+		p.P("return true;")
+	} else {
+		p.P("%s that = (%s) o;", name, name)
+		var eqs []string
+		for _, n := range names {
+			eqs = append(eqs, "java.util.Objects.deepEquals("+n+", that."+n+")")
+		}
+		p.P("return %s;", strings.Join(eqs, "\n            && "))
+	}
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("@Override")
+	p.P("public int hashCode() {")
+	p.P("    return java.util.Arrays.deepHashCode(new Object[] {%s});", strings.Join(names, ", "))
+	p.P("}")
+
+	p.Out()
+	p.P("}")
+}
+
+func (g *generator) genRead(mb *descriptor.Member) {
+	p := g.p
+	f := field(mb.Name)
+	req := strconv.FormatBool(mb.Required)
+	switch {
+	case g.isPrimitive(mb.Type) || mb.Type.Kind == "string":
+		// 字段不存在时保留默认值
+		p.P("this.%s = _is.read(%s, %d, %s);", f, f, mb.Tag, req)
+	default:
+		cache := "cache_" + mb.Name
+		p.P("if (null == %s) {", cache)
+		p.In()
+		g.fill(mb.Type, cache, true)
+		p.Out()
+		p.P("}")
+		p.P("this.%s = (%s) _is.read(%s, %d, %s);", f, g.typ(mb.Type), cache, mb.Tag, req)
+	}
+}
+
+// 给变量 v 赋值为解码使用的原型，容器中放一个元素的原型，assigned 为 false 时声明局部变量
+func (g *generator) fill(t *descriptor.Type, v string, assigned bool) {
+	p := g.p
+	if assigned {
+		p.P("%s = %s;", v, g.newValue(t))
+	} else {
+		p.P("%s %s = %s;", g.typ(t), v, g.newValue(t))
+	}
+	switch {
+	case lang.IsBytes(t):
+		p.P("%s[0] = 0;", v)
+	case t.Kind == "vector" || t.Kind == "array":
+		p.P("%s.add(%s);", v, g.elem(t.Params[0]))
+	case t.Kind == "map":
+		k := g.elem(t.Params[0])
+		p.P("%s.put(%s, %s);", v, k, g.elem(t.Params[1]))
+	}
+}
+
+// 容器元素的原型，容器类型先声明局部变量
+func (g *generator) elem(t *descriptor.Type) string {
+	if t.Kind == "vector" || t.Kind == "array" || t.Kind == "map" {
+		g.vc++
+		v := "__var_" + strconv.Itoa(g.vc)
+		g.fill(t, v, false)
+		return v
+	}
+	return g.zero(t)
+}
+
+// 容器、struct 的新实例，byte[] 为长度 1 的数组
+func (g *generator) newValue(t *descriptor.Type) string {
+	if lang.IsBytes(t) {
+		return "new byte[1]"
+	}
+	return "new " + g.typ(t) + "()"
+}
+
+// 解码时需要原型的类型
+func (g *generator) needCache(t *descriptor.Type) bool {
+	return !g.isPrimitive(t) && t.Kind != "string"
+}
+
+func (g *generator) isPrimitive(t *descriptor.Type) bool {
+	switch t.Kind {
+	case "bool", "byte", "short", "int", "long", "float", "double", "enum":
+		return true
+	}
+	return false
+}
+
+// struct 的类名，其他 module 的类型使用全名
+func (g *generator) ref(name string) string {
+	m, short := lang.SplitName(name)
+	if m == g.f.Module {
+		return lang.Upper(short)
+	}
+	return g.pkg(m) + "." + lang.Upper(short)
+}
+
+func (g *generator) typ(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "boolean"
+	case "byte":
+		if t.IsUnsigned {
+			return "short"
+		}
+		return "byte"
+	case "short":
+		if t.IsUnsigned {
+			return "int"
+		}
+		return "short"
+	case "int":
+		if t.IsUnsigned {
+			return "long"
+		}
+		return "int"
+	case "long", "float", "double":
+		return t.Kind
+	case "enum":
+		return "int"
+	case "string":
+		return "String"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "byte[]"
+		}
+		return "java.util.ArrayList<" + g.boxed(t.Params[0]) + ">"
+	case "map":
+		return "java.util.HashMap<" + g.boxed(t.Params[0]) + ", " + g.boxed(t.Params[1]) + ">"
+	case "struct":
+		return g.ref(t.Name)
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 泛型参数使用的类型
+func (g *generator) boxed(t *descriptor.Type) string {
+	switch ty := g.typ(t); ty {
+	case "boolean":
+		return "Boolean"
+	case "byte":
+		return "Byte"
+	case "short":
+		return "Short"
+	case "int":
+		return "Integer"
+	case "long":
+		return "Long"
+	case "float":
+		return "Float"
+	case "double":
+		return "Double"
+	default:
+		return ty
+	}
+}
+
+// 成员的默认值，optional 的容器、struct 为 null，编码时跳过
+func (g *generator) defaultValue(mb *descriptor.Member) string {
+	if mb.Default != "" {
+		return g.literal(mb.Type, mb.Default)
+	}
+	if !mb.Required && g.needCache(mb.Type) {
+		return "null"
+	}
+	if lang.IsBytes(mb.Type) {
+		return "new byte[0]"
+	}
+	return g.zero(mb.Type)
+}
+
+// 零值，用于基础类型的默认值以及容器元素的原型
+func (g *generator) zero(t *descriptor.Type) string {
+	switch g.typ(t) {
+	case "boolean":
+		return "false"
+	case "byte":
+		return "(byte) 0"
+	case "short":
+		return "(short) 0"
+	case "int":
+		return "0"
+	case "long":
+		return "0L"
+	case "float":
+		return "0.0f"
+	case "double":
+		return "0.0"
+	case "String":
+		return `""`
+	}
+	return g.newValue(t)
+}
+
+// 源码中的常量、默认值转换为 java 的字面量
+func (g *generator) literal(t *descriptor.Type, v string) string {
+	switch g.typ(t) {
+	case "byte":
+		return "(byte) " + v
+	case "short":
+		return "(short) " + v
+	case "long":
+		if t.Kind == "long" || t.Kind == "int" {
+			return v + "L"
+		}
+	case "float":
+		return strings.TrimSuffix(v, "f") + "f"
+	}
+	if t.Kind == "enum" {
+		ev, err := g.x.EnumDefault(t, v)
+		if err != nil {
+			fail("%v", err)
+		}
+		return g.ref(t.Name) + "." + ev.Name + ".value()"
+	}
+	return v
+}
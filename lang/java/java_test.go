@@ -0,0 +1,202 @@
+package java
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang/langtest"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+const baseSchema = `module base
+{
+    struct Item
+    {
+        0 require int id;
+    };
+};
+`
+
+const schema = `#include "base.jce"
+
+module test
+{
+    const short VERSION = 3;
+    const long BIG = 1099511627776;
+
+    enum Color
+    {
+        Red = 1,
+        Green,
+    };
+
+    // 测试的结构体
+    struct Packet
+    {
+        0  require  byte                          b;
+        1  require  string                        s = "hi";
+        2  optional Color                         c = Green;
+        3  optional vector<byte>                  raw;
+        4  optional vector<base::Item>            items;
+        5  optional map<string, vector<int>>      m;
+        6  optional unsigned int                  ui;
+        7  require  base::Item                    item;
+        8  optional float                         f = 1.5;
+        9  optional string                        default;
+    };
+};
+`
+
+func generate(t *testing.T, param string) map[string]string {
+	dir := t.TempDir()
+	for name, src := range map[string]string{"base.jce": baseSchema, "test.jce": schema} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := parser.ParseSource(filepath.Join(dir, "test.jce"), []byte(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Generate(descriptor.Build(p), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return byName(files)
+}
+
+func byName(files []plugin.File) map[string]string {
+	ret := map[string]string{}
+	for _, f := range files {
+		ret[f.Name] = f.Content
+	}
+	return ret
+}
+
+func TestGenerate(t *testing.T) {
+	files := generate(t, "prefix=com.foo,package.base=org.common")
+	for _, name := range []string{"com/foo/test/Color.java", "com/foo/test/Consts.java", "com/foo/test/Packet.java", "org/common/Item.java"} {
+		if _, ok := files[name]; !ok {
+			t.Fatalf("%s not generated, got %v", name, files)
+		}
+	}
+
+	packet := files["com/foo/test/Packet.java"]
+	for _, want := range []string{
+		"package com.foo.test;",
+		"/** 测试的结构体 */",
+		"public final class Packet extends jce.JceStruct {",
+		"static java.util.ArrayList<org.common.Item> cache_items;",
+		`private String s = "hi";`,
+		"private int c = Color.Green.value();",
+		"private byte[] raw = null;",
+		"private long ui = 0L;",
+		"private org.common.Item item = new org.common.Item();",
+		"private float f = 1.5f;",
+		`private String default_ = "";`,
+		"public void writeTo(jce.JceOutputStream _os) {",
+		"_os.write(b, 0);",
+		"if (null != raw) {",
+		"public void readFrom(jce.JceInputStream _is) {",
+		"this.b = _is.read(b, 0, true);",
+		"this.s = _is.read(s, 1, true);",
+		"cache_raw[0] = 0;",
+		"java.util.ArrayList<Integer> __var_1 = new java.util.ArrayList<Integer>();",
+		`cache_m.put("", __var_1);`,
+		"this.m = (java.util.HashMap<String, java.util.ArrayList<Integer>>) _is.read(cache_m, 5, false);",
+		"this.item = (org.common.Item) _is.read(cache_item, 7, true);",
+		"public int getC() {",
+		"public void setDefault(String default_) {",
+	} {
+		if !strings.Contains(packet, want) {
+			t.Errorf("Packet.java missing %q:\n%s", want, packet)
+		}
+	}
+
+	consts := files["com/foo/test/Consts.java"]
+	for _, want := range []string{"public static final short VERSION = (short) 3;", "public static final long BIG = 1099511627776L;"} {
+		if !strings.Contains(consts, want) {
+			t.Errorf("Consts.java missing %q:\n%s", want, consts)
+		}
+	}
+	if color := files["com/foo/test/Color.java"]; !strings.Contains(color, "Green(2);") || !strings.Contains(color, "public static Color convert(int value) {") {
+		t.Errorf("unexpected Color.java:\n%s", color)
+	}
+
+	// 默认包名为 module 名
+	files = generate(t, "runtime=com.qq.tars.protocol.tars")
+	if item, ok := files["base/Item.java"]; !ok || !strings.Contains(item, "extends com.qq.tars.protocol.tars.JceStruct") {
+		t.Errorf("unexpected files %v", files)
+	}
+	// 运行时生成在 runtime 指定的包中
+	if rt := files["com/qq/tars/protocol/tars/JceInputStream.java"]; !strings.Contains(rt, "\npackage com.qq.tars.protocol.tars;\n") {
+		t.Errorf("unexpected JceInputStream.java:\n%s", rt)
+	}
+	if !strings.Contains(files["test/Packet.java"], "java.util.ArrayList<base.Item>") {
+		t.Errorf("unexpected Packet.java:\n%s", files["test/Packet.java"])
+	}
+
+	if _, err := Generate(&descriptor.Set{}, "bad=1"); err == nil {
+		t.Errorf("expect unknown option error")
+	}
+}
+
+// java 解码 fixtures.hex 中的数据后重新编码，结果和原来的数据相同
+func TestRoundTrip(t *testing.T) {
+	javac, err := exec.LookPath("javac")
+	if err != nil {
+		t.Skip("javac not found")
+	}
+	java, err := exec.LookPath("java")
+	if err != nil {
+		t.Skip("java not found")
+	}
+	files := langtest.Generate(t, Generate, "")
+
+	dir := t.TempDir()
+	langtest.WriteFiles(t, filepath.Join(dir, "src"), files, nil)
+	main := `import test.Packet;
+
+public class Main {
+    public static void main(String[] args) {
+        for (String arg : args) {
+            byte[] data = new byte[arg.length() / 2];
+            for (int i = 0; i < data.length; i++) {
+                data[i] = (byte) Integer.parseInt(arg.substring(2 * i, 2 * i + 2), 16);
+            }
+            Packet v = new Packet();
+            v.fromByteArray(data);
+            StringBuilder sb = new StringBuilder();
+            for (byte b : v.toByteArray()) {
+                sb.append(String.format("%02x", b));
+            }
+            System.out.println(sb);
+        }
+    }
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "Main.java"), []byte(main), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	srcs := []string{filepath.Join(dir, "src", "Main.java")}
+	for _, f := range files {
+		srcs = append(srcs, filepath.Join(dir, "src", filepath.FromSlash(f.Name)))
+	}
+	classes := filepath.Join(dir, "classes")
+	args := append([]string{"-encoding", "UTF-8", "-Xlint:unchecked", "-Werror", "-d", classes}, srcs...)
+	if out, err := exec.Command(javac, args...).CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	fixtures := langtest.Fixtures(t)
+	out, err := exec.Command(java, append([]string{"-cp", classes, "Main"}, fixtures...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	langtest.Check(t, out, fixtures)
+}
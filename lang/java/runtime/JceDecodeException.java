@@ -0,0 +1,20 @@
+// DO NOT EDIT IT.
+// jce 编码的 Java 运行时，由 jce2go --lang=java 生成，只依赖标准库。
+
+package jce;
+
+/** 数据格式错误，offset 为出错位置的字节偏移 */
+public class JceDecodeException extends RuntimeException {
+    private static final long serialVersionUID = 1L;
+
+    private final int offset;
+
+    public JceDecodeException(String message, int offset) {
+        super("offset " + offset + ": " + message);
+        this.offset = offset;
+    }
+
+    public int getOffset() {
+        return offset;
+    }
+}
@@ -0,0 +1,390 @@
+// DO NOT EDIT IT.
+// jce 编码的 Java 运行时，由 jce2go --lang=java 生成，只依赖标准库。
+
+package jce;
+
+import java.nio.charset.StandardCharsets;
+import java.util.ArrayList;
+import java.util.HashMap;
+import java.util.List;
+import java.util.Map;
+
+/**
+ * 从字节数组中读取 jce 数据。和 Tars 相同，struct 的成员按 tag 从小到大读取：
+ * read 跳过 tag 更小的未知字段，遇到 tag 更大的字段或者 StructEnd 时认为字段不存在。
+ * 基础类型、string 不存在时返回传入的值，容器、struct 不存在时返回 null，
+ * 容器、struct 传入的是解码使用的原型，容器中放一个元素的原型
+ */
+public class JceInputStream {
+    private final byte[] buf;
+    private int off;
+
+    public JceInputStream(byte[] buf) {
+        this.buf = buf;
+    }
+
+    /** 当前读取位置 */
+    public int getOffset() {
+        return off;
+    }
+
+    private int next(int n) {
+        if (buf.length - off < n) {
+            throw new JceDecodeException("unexpected end of data, need " + n + " bytes, have " + (buf.length - off), off);
+        }
+        int start = off;
+        off += n;
+        return start;
+    }
+
+    private JceDecodeException mismatch(int type, String expect) {
+        return new JceDecodeException("type " + JceType.name(type) + " is not " + expect, off);
+    }
+
+    /** 读取 head，返回 {type, tag} */
+    public int[] readHead() {
+        int start = off;
+        int b = buf[next(1)] & 0xff;
+        int type = b & 0x0f;
+        int tag = b >> 4;
+        if (tag == 15) {
+            tag = buf[next(1)] & 0xff;
+        }
+        if (type > JceType.SIMPLE_LIST) {
+            throw new JceDecodeException("invalid type " + type, start);
+        }
+        return new int[] {type, tag};
+    }
+
+    // 查找 tag 对应的字段，找到时读取 head 并返回 type，否则返回 -1 且不移动读取位置，require 的字段不存在时报错
+    private int field(int tag, boolean require) {
+        return field(tag, require, false);
+    }
+
+    // 生成的 go 代码写入结构体成员时 StructBegin 的 tag 总是 0，isStruct 为 true 时按位置把 tag 更小的 StructBegin 对应到这个字段
+    private int field(int tag, boolean require, boolean isStruct) {
+        while (off < buf.length) {
+            int start = off;
+            int[] head = readHead();
+            if (head[0] == JceType.STRUCT_END || head[1] > tag) {
+                off = start;
+                break;
+            }
+            if (head[1] == tag || (isStruct && head[0] == JceType.STRUCT_BEGIN)) {
+                return head[0];
+            }
+            skip(head[0]);
+        }
+        if (require) {
+            throw new JceDecodeException("require field tag " + tag + " not found", off);
+        }
+        return -1;
+    }
+
+    private long raw(int n) {
+        int start = next(n);
+        long v = 0;
+        for (int i = 0; i < n; i++) {
+            v = (v << 8) | (buf[start + i] & 0xff);
+        }
+        return v;
+    }
+
+    // 读取整数，超出 [min, max] 时报错
+    private long readInteger(int type, long min, long max) {
+        int start = off;
+        long v;
+        switch (type) {
+            case JceType.ZERO_TAG:
+                v = 0;
+                break;
+            case JceType.INT1:
+                v = (byte) raw(1);
+                break;
+            case JceType.INT2:
+                v = (short) raw(2);
+                break;
+            case JceType.INT4:
+                v = (int) raw(4);
+                break;
+            case JceType.INT8:
+                v = raw(8);
+                break;
+            default:
+                throw mismatch(type, "an integer");
+        }
+        if (v < min || v > max) {
+            throw new JceDecodeException(v + " overflows [" + min + ", " + max + "]", start);
+        }
+        return v;
+    }
+
+    private double readFloating(int type) {
+        switch (type) {
+            case JceType.ZERO_TAG:
+                return 0;
+            case JceType.FLOAT:
+                return Float.intBitsToFloat((int) raw(4));
+            case JceType.DOUBLE:
+                return Double.longBitsToDouble(raw(8));
+            default:
+                throw mismatch(type, "a float");
+        }
+    }
+
+    /** 读取 4B 长度，长度超过剩余数据时报错 */
+    public int readLength() {
+        int start = off;
+        long n = raw(4);
+        if (n > buf.length - off) {
+            throw new JceDecodeException("length " + n + " exceeds remaining " + (buf.length - off) + " bytes", start);
+        }
+        return (int) n;
+    }
+
+    private String readStringData(int type) {
+        int n;
+        if (type == JceType.STRING1) {
+            n = buf[next(1)] & 0xff;
+        } else if (type == JceType.STRING4) {
+            n = readLength();
+        } else {
+            throw mismatch(type, "a string");
+        }
+        return new String(buf, next(n), n, StandardCharsets.UTF_8);
+    }
+
+    // 读取 vector<byte>，也兼容按 List 编码的数据
+    private byte[] readBytesData(int type) {
+        if (type == JceType.LIST) {
+            int n = readLength();
+            byte[] ret = new byte[n];
+            for (int i = 0; i < n; i++) {
+                ret[i] = (byte) readInteger(readHead()[0], Byte.MIN_VALUE, 0xff);
+            }
+            return ret;
+        }
+        if (type != JceType.SIMPLE_LIST) {
+            throw mismatch(type, "a SimpleList");
+        }
+        int n = readLength();
+        int start = next(n);
+        byte[] ret = new byte[n];
+        System.arraycopy(buf, start, ret, 0, n);
+        return ret;
+    }
+
+    private ArrayList<Object> readListData(List<?> proto, int type) {
+        if (type != JceType.LIST) {
+            throw mismatch(type, "a List");
+        }
+        if (proto.isEmpty()) {
+            throw new IllegalArgumentException("list prototype must contain an element");
+        }
+        int n = readLength();
+        ArrayList<Object> ret = new ArrayList<Object>(n);
+        for (int i = 0; i < n; i++) {
+            ret.add(readValue(proto.get(0), readHead()[0]));
+        }
+        return ret;
+    }
+
+    private HashMap<Object, Object> readMapData(Map<?, ?> proto, int type) {
+        if (type != JceType.MAP) {
+            throw mismatch(type, "a Map");
+        }
+        if (proto.isEmpty()) {
+            throw new IllegalArgumentException("map prototype must contain an entry");
+        }
+        Map.Entry<?, ?> e = proto.entrySet().iterator().next();
+        int n = readLength();
+        HashMap<Object, Object> ret = new HashMap<Object, Object>();
+        for (int i = 0; i < n; i++) {
+            Object k = readValue(e.getKey(), readHead()[0]);
+            ret.put(k, readValue(e.getValue(), readHead()[0]));
+        }
+        return ret;
+    }
+
+    // 读取 StructBegin 之后的成员，跳过未知的字段直到 StructEnd
+    private void readStructData(JceStruct v, int type) {
+        if (type != JceType.STRUCT_BEGIN) {
+            throw mismatch(type, "a StructBegin");
+        }
+        v.readFrom(this);
+        for (;;) {
+            int[] head = readHead();
+            if (head[0] == JceType.STRUCT_END) {
+                return;
+            }
+            skip(head[0]);
+        }
+    }
+
+    // 按原型的类型读取一个值
+    private Object readValue(Object proto, int type) {
+        if (proto instanceof Boolean) {
+            return readInteger(type, Long.MIN_VALUE, Long.MAX_VALUE) != 0;
+        } else if (proto instanceof Byte) {
+            return (byte) readInteger(type, Byte.MIN_VALUE, Byte.MAX_VALUE);
+        } else if (proto instanceof Short) {
+            return (short) readInteger(type, Short.MIN_VALUE, Short.MAX_VALUE);
+        } else if (proto instanceof Integer) {
+            return (int) readInteger(type, Integer.MIN_VALUE, Integer.MAX_VALUE);
+        } else if (proto instanceof Long) {
+            return readInteger(type, Long.MIN_VALUE, Long.MAX_VALUE);
+        } else if (proto instanceof Float) {
+            return (float) readFloating(type);
+        } else if (proto instanceof Double) {
+            return readFloating(type);
+        } else if (proto instanceof String) {
+            return readStringData(type);
+        } else if (proto instanceof byte[]) {
+            return readBytesData(type);
+        } else if (proto instanceof JceStruct) {
+            JceStruct v = ((JceStruct) proto).newInit();
+            readStructData(v, type);
+            return v;
+        } else if (proto instanceof List) {
+            return readListData((List<?>) proto, type);
+        } else if (proto instanceof Map) {
+            return readMapData((Map<?, ?>) proto, type);
+        }
+        throw new IllegalArgumentException("can not read " + (proto == null ? "null" : proto.getClass().getName()));
+    }
+
+    public boolean read(boolean v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : readInteger(type, Long.MIN_VALUE, Long.MAX_VALUE) != 0;
+    }
+
+    public byte read(byte v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : (byte) readInteger(type, Byte.MIN_VALUE, Byte.MAX_VALUE);
+    }
+
+    public short read(short v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : (short) readInteger(type, Short.MIN_VALUE, Short.MAX_VALUE);
+    }
+
+    public int read(int v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : (int) readInteger(type, Integer.MIN_VALUE, Integer.MAX_VALUE);
+    }
+
+    public long read(long v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : readInteger(type, Long.MIN_VALUE, Long.MAX_VALUE);
+    }
+
+    public float read(float v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : (float) readFloating(type);
+    }
+
+    public double read(double v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : readFloating(type);
+    }
+
+    public String read(String v, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? v : readStringData(type);
+    }
+
+    /** 和 Tars 相同的接口，字段不存在时返回 null */
+    public String readString(int tag, boolean require) {
+        return read((String) null, tag, require);
+    }
+
+    public byte[] read(byte[] proto, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? null : readBytesData(type);
+    }
+
+    /** 读取 struct，返回 proto.newInit() 创建的新实例 */
+    public JceStruct read(JceStruct proto, int tag, boolean require) {
+        int type = field(tag, require, true);
+        if (type < 0) {
+            return null;
+        }
+        JceStruct v = proto.newInit();
+        readStructData(v, type);
+        return v;
+    }
+
+    /** 读取 struct 到 v 中，字段不存在时返回 false */
+    public boolean readStruct(JceStruct v, int tag, boolean require) {
+        int type = field(tag, require, true);
+        if (type < 0) {
+            return false;
+        }
+        readStructData(v, type);
+        return true;
+    }
+
+    /** 读取 vector、map，返回 ArrayList、HashMap */
+    public Object read(Object proto, int tag, boolean require) {
+        int type = field(tag, require);
+        return type < 0 ? null : readValue(proto, type);
+    }
+
+    /** 数据已经读完，否则报错 */
+    public void expectEnd() {
+        if (off < buf.length) {
+            throw new JceDecodeException((buf.length - off) + " bytes left after struct end", off);
+        }
+    }
+
+    /** 跳过一个指定类型的 data */
+    public void skip(int type) {
+        switch (type) {
+            case JceType.ZERO_TAG:
+            case JceType.STRUCT_END:
+                return;
+            case JceType.INT1:
+                next(1);
+                return;
+            case JceType.INT2:
+                next(2);
+                return;
+            case JceType.INT4:
+            case JceType.FLOAT:
+                next(4);
+                return;
+            case JceType.INT8:
+            case JceType.DOUBLE:
+                next(8);
+                return;
+            case JceType.STRING1:
+                next(buf[next(1)] & 0xff);
+                return;
+            case JceType.STRING4:
+            case JceType.SIMPLE_LIST:
+                next(readLength());
+                return;
+            case JceType.LIST:
+            case JceType.MAP: {
+                long n = readLength();
+                if (type == JceType.MAP) {
+                    n *= 2;
+                }
+                for (long i = 0; i < n; i++) {
+                    skip(readHead()[0]);
+                }
+                return;
+            }
+            case JceType.STRUCT_BEGIN:
+                for (;;) {
+                    int t = readHead()[0];
+                    if (t == JceType.STRUCT_END) {
+                        return;
+                    }
+                    skip(t);
+                }
+            default:
+                throw new JceDecodeException("invalid type " + type, off);
+        }
+    }
+}
@@ -0,0 +1,170 @@
+// DO NOT EDIT IT.
+// jce 编码的 Java 运行时，由 jce2go --lang=java 生成，只依赖标准库。
+
+package jce;
+
+import java.io.ByteArrayOutputStream;
+import java.nio.charset.StandardCharsets;
+import java.util.Collection;
+import java.util.Map;
+
+/**
+ * 把 jce 数据写入内存。
+ * 每个字段由 head + data 组成，head 第一个字节高 4 位是 tag、低 4 位是 type，
+ * tag >= 15 时高 4 位为 15，第二个字节存 tag。整数、长度均为大端，整数按取值选择最小的类型
+ */
+public class JceOutputStream {
+    private final ByteArrayOutputStream buf = new ByteArrayOutputStream(64);
+
+    /** 已写入的数据 */
+    public byte[] toByteArray() {
+        return buf.toByteArray();
+    }
+
+    /** 写 head */
+    public void writeHead(int type, int tag) {
+        if (tag < 0 || tag > 255) {
+            throw new IllegalArgumentException("tag " + tag + " out of range [0, 255]");
+        }
+        if (tag < 15) {
+            buf.write((tag << 4) | type);
+            return;
+        }
+        buf.write(0xf0 | type);
+        buf.write(tag);
+    }
+
+    private void writeRaw(long v, int n) {
+        for (int i = n - 1; i >= 0; i--) {
+            buf.write((int) (v >>> (i * 8)));
+        }
+    }
+
+    /** 写 4B 长度 */
+    public void writeLength(int n) {
+        writeRaw(n, 4);
+    }
+
+    public void write(boolean v, int tag) {
+        write(v ? 1 : 0, tag);
+    }
+
+    public void write(byte v, int tag) {
+        write((long) v, tag);
+    }
+
+    public void write(short v, int tag) {
+        write((long) v, tag);
+    }
+
+    public void write(int v, int tag) {
+        write((long) v, tag);
+    }
+
+    public void write(long v, int tag) {
+        if (v == 0) {
+            writeHead(JceType.ZERO_TAG, tag);
+        } else if (v >= Byte.MIN_VALUE && v <= Byte.MAX_VALUE) {
+            writeHead(JceType.INT1, tag);
+            writeRaw(v, 1);
+        } else if (v >= Short.MIN_VALUE && v <= Short.MAX_VALUE) {
+            writeHead(JceType.INT2, tag);
+            writeRaw(v, 2);
+        } else if (v >= Integer.MIN_VALUE && v <= Integer.MAX_VALUE) {
+            writeHead(JceType.INT4, tag);
+            writeRaw(v, 4);
+        } else {
+            writeHead(JceType.INT8, tag);
+            writeRaw(v, 8);
+        }
+    }
+
+    public void write(float v, int tag) {
+        if (v == 0) {
+            writeHead(JceType.ZERO_TAG, tag);
+            return;
+        }
+        writeHead(JceType.FLOAT, tag);
+        writeRaw(Float.floatToIntBits(v), 4);
+    }
+
+    public void write(double v, int tag) {
+        if (v == 0) {
+            writeHead(JceType.ZERO_TAG, tag);
+            return;
+        }
+        writeHead(JceType.DOUBLE, tag);
+        writeRaw(Double.doubleToLongBits(v), 8);
+    }
+
+    /** 写字符串，长度不超过 255 时使用 String1 */
+    public void write(String v, int tag) {
+        byte[] b = v.getBytes(StandardCharsets.UTF_8);
+        if (b.length <= 0xff) {
+            writeHead(JceType.STRING1, tag);
+            buf.write(b.length);
+        } else {
+            writeHead(JceType.STRING4, tag);
+            writeLength(b.length);
+        }
+        buf.write(b, 0, b.length);
+    }
+
+    /** 写 vector<byte>，使用 SimpleList */
+    public void write(byte[] v, int tag) {
+        writeHead(JceType.SIMPLE_LIST, tag);
+        writeLength(v.length);
+        buf.write(v, 0, v.length);
+    }
+
+    /** 写结构体，和生成的 go 代码相同，StructBegin 的 tag 总是 0，不写入成员的 tag */
+    public void write(JceStruct v, int tag) {
+        writeHead(JceType.STRUCT_BEGIN, 0);
+        v.writeTo(this);
+        writeHead(JceType.STRUCT_END, 0);
+    }
+
+    /** 写 vector，每个元素都是 tag 为 0 的字段 */
+    public void write(Collection<?> v, int tag) {
+        writeHead(JceType.LIST, tag);
+        writeLength(v.size());
+        for (Object e : v) {
+            write(e, 0);
+        }
+    }
+
+    /** 写 map，key 的 tag 为 0，value 的 tag 为 1 */
+    public void write(Map<?, ?> v, int tag) {
+        writeHead(JceType.MAP, tag);
+        writeLength(v.size());
+        for (Map.Entry<?, ?> e : v.entrySet()) {
+            write(e.getKey(), 0);
+            write(e.getValue(), 1);
+        }
+    }
+
+    /** 按运行时的类型写入，用于容器的元素 */
+    public void write(Object v, int tag) {
+        if (v instanceof Boolean) {
+            write(((Boolean) v).booleanValue(), tag);
+        } else if (v instanceof Byte || v instanceof Short || v instanceof Integer || v instanceof Long) {
+            write(((Number) v).longValue(), tag);
+        } else if (v instanceof Float) {
+            write(((Float) v).floatValue(), tag);
+        } else if (v instanceof Double) {
+            write(((Double) v).doubleValue(), tag);
+        } else if (v instanceof String) {
+            write((String) v, tag);
+        } else if (v instanceof byte[]) {
+            write((byte[]) v, tag);
+        } else if (v instanceof JceStruct) {
+            write((JceStruct) v, tag);
+        } else if (v instanceof Collection) {
+            write((Collection<?>) v, tag);
+        } else if (v instanceof Map) {
+            write((Map<?, ?>) v, tag);
+        } else {
+            throw new IllegalArgumentException("can not write " + (v == null ? "null" : v.getClass().getName()) + " with tag " + tag);
+        }
+    }
+}
@@ -0,0 +1,43 @@
+// DO NOT EDIT IT.
+// jce 编码的 Java 运行时，由 jce2go --lang=java 生成，只依赖标准库。
+
+package jce;
+
+/**
+ * 生成的 struct 的基类，接口和 Tars Java 的 JceStruct 相同。
+ * 编码格式和 jce2go 生成的 go 代码相同：List、Map、SimpleList 的长度为 4B 大端整数，
+ * 整个 struct 编码为 tag 为 0 的 StructBegin ... StructEnd，作为成员、容器的元素时 StructBegin 的 tag 也是 0，
+ * 解码时按位置对应到成员
+ */
+public abstract class JceStruct implements java.io.Serializable {
+    private static final long serialVersionUID = 1L;
+
+    /** 按 tag 写入所有成员，不包括 StructBegin、StructEnd */
+    public abstract void writeTo(JceOutputStream _os);
+
+    /** 按 tag 读取所有成员，不包括 StructBegin、StructEnd */
+    public abstract void readFrom(JceInputStream _is);
+
+    /** 创建同类型的新实例，解码时使用 */
+    public JceStruct newInit() {
+        try {
+            return getClass().getDeclaredConstructor().newInstance();
+        } catch (ReflectiveOperationException e) {
+            throw new IllegalStateException("can not create " + getClass().getName(), e);
+        }
+    }
+
+    /** 编码为 jce 数据 */
+    public byte[] toByteArray() {
+        JceOutputStream os = new JceOutputStream();
+        os.write(this, 0);
+        return os.toByteArray();
+    }
+
+    /** 从 jce 数据解码，数据格式错误时抛出 JceDecodeException */
+    public void fromByteArray(byte[] data) {
+        JceInputStream is = new JceInputStream(data);
+        is.readStruct(this, 0, true);
+        is.expectEnd();
+    }
+}
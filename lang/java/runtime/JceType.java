@@ -0,0 +1,35 @@
+// DO NOT EDIT IT.
+// jce 编码的 Java 运行时，由 jce2go --lang=java 生成，只依赖标准库。
+
+package jce;
+
+/** head 中的 type */
+public final class JceType {
+    private JceType() {
+    }
+
+    public static final int INT1 = 0;
+    public static final int INT2 = 1;
+    public static final int INT4 = 2;
+    public static final int INT8 = 3;
+    public static final int FLOAT = 4;
+    public static final int DOUBLE = 5;
+    public static final int STRING1 = 6;
+    public static final int STRING4 = 7;
+    public static final int MAP = 8;
+    public static final int LIST = 9;
+    public static final int STRUCT_BEGIN = 10;
+    public static final int STRUCT_END = 11;
+    public static final int ZERO_TAG = 12;
+    public static final int SIMPLE_LIST = 13;
+
+    private static final String[] NAMES = {
+        "Int1", "Int2", "Int4", "Int8", "Float", "Double", "String1", "String4",
+        "Map", "List", "StructBegin", "StructEnd", "ZeroTag", "SimpleList",
+    };
+
+    /** type 的名字，用于错误信息 */
+    public static String name(int type) {
+        return type >= 0 && type < NAMES.length ? NAMES[type] : "Type(" + type + ")";
+    }
+}
@@ -0,0 +1,254 @@
+// Package lang 生成 go 以外其他语言的代码
+//
+// 输入为完整解析后的 schema 描述（见 descriptor 包），和插件看到的内容相同，
+// 每个语言一个子包，输出的文件由调用方写到 -o 指定的目录
+package lang
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// Generator 一个语言的代码生成，param 为 --lang_opt 指定的参数
+type Generator func(set *descriptor.Set, param string) ([]plugin.File, error)
+
+// Index 按全名（module.name）查找 struct、enum
+type Index struct {
+	structs map[string]*descriptor.Struct
+	enums   map[string]*descriptor.Enum
+	modules map[string]*descriptor.File
+}
+
+// NewIndex 建立 set 的索引，同一个 module 分布在多个文件时 Module 返回第一个
+func NewIndex(set *descriptor.Set) *Index {
+	x := &Index{
+		structs: map[string]*descriptor.Struct{},
+		enums:   map[string]*descriptor.Enum{},
+		modules: map[string]*descriptor.File{},
+	}
+	for _, f := range set.Files {
+		if _, ok := x.modules[f.Module]; !ok {
+			x.modules[f.Module] = f
+		}
+		for _, st := range f.Structs {
+			x.structs[f.Module+"."+st.Name] = st
+		}
+		for _, en := range f.Enums {
+			x.enums[f.Module+"."+en.Name] = en
+		}
+	}
+	return x
+}
+
+// Struct 按全名查找 struct
+func (x *Index) Struct(name string) *descriptor.Struct {
+	return x.structs[name]
+}
+
+// Enum 按全名查找 enum
+func (x *Index) Enum(name string) *descriptor.Enum {
+	return x.enums[name]
+}
+
+// Module 定义 module 的文件
+func (x *Index) Module(module string) *descriptor.File {
+	return x.modules[module]
+}
+
+// EnumDefault 枚举成员的默认值，def 为源码中的默认值，可以是成员名（可能带 :: 前缀）或者数字
+func (x *Index) EnumDefault(t *descriptor.Type, def string) (*descriptor.EnumValue, error) {
+	en := x.Enum(t.Name)
+	if en == nil {
+		return nil, fmt.Errorf("enum %s not found", t.Name)
+	}
+	name := def
+	if i := strings.LastIndex(name, "::"); i >= 0 {
+		name = name[i+2:]
+	}
+	for _, v := range en.Values {
+		if v.Name == name || fmt.Sprint(v.Value) == name {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("enum %s has no value %s", t.Name, def)
+}
+
+// SplitName 把全名拆分为 module 和名字
+func SplitName(name string) (module, short string) {
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// Deps 文件中的类型引用的其他 module，排序后返回
+func Deps(f *descriptor.File) []string {
+	set := map[string]bool{}
+	var visit func(t *descriptor.Type)
+	visit = func(t *descriptor.Type) {
+		if t.Name != "" {
+			if m, _ := SplitName(t.Name); m != f.Module {
+				set[m] = true
+			}
+		}
+		for _, p := range t.Params {
+			visit(p)
+		}
+	}
+	for _, st := range f.Structs {
+		for _, mb := range st.Members {
+			visit(mb.Type)
+		}
+	}
+	for _, c := range f.Consts {
+		visit(c.Type)
+	}
+
+	ret := make([]string, 0, len(set))
+	for m := range set {
+		ret = append(ret, m)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// IsBytes 是否是 vector<byte>、array<byte>，按 SimpleList 编码
+func IsBytes(t *descriptor.Type) bool {
+	return (t.Kind == "vector" || t.Kind == "array") && t.Params[0].Kind == "byte"
+}
+
+// StructTags 结构体类型的成员的 tag，从小到大。生成的 go 代码写入结构体成员时 StructBegin 的 tag 总是 0，
+// 各语言的运行时解码时按位置把它对应到这些成员
+func StructTags(st *descriptor.Struct) []string {
+	var tags []int
+	for _, mb := range st.Members {
+		if mb.Type.Kind == "struct" {
+			tags = append(tags, int(mb.Tag))
+		}
+	}
+	sort.Ints(tags)
+	ret := make([]string, len(tags))
+	for i, tag := range tags {
+		ret[i] = strconv.Itoa(tag)
+	}
+	return ret
+}
+
+// ParseParam 解析 k1=v1,k2=v2 格式的参数
+func ParseParam(param string) (map[string]string, error) {
+	ret := map[string]string{}
+	for _, kv := range strings.Split(param, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		i := strings.Index(kv, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid option %q, expect key=value", kv)
+		}
+		ret[strings.TrimSpace(kv[:i])] = strings.TrimSpace(kv[i+1:])
+	}
+	return ret, nil
+}
+
+// Upper 首字母大写
+func Upper(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// Lower 首字母小写
+func Lower(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// Snake 驼峰转换为下划线风格，如 RequestPacket 转换为 request_packet
+func Snake(s string) string {
+	var b strings.Builder
+	r := []rune(s)
+	for i, c := range r {
+		if unicode.IsUpper(c) {
+			if i > 0 && (unicode.IsLower(r[i-1]) || unicode.IsDigit(r[i-1]) || (i+1 < len(r) && unicode.IsLower(r[i+1]) && r[i-1] != '_')) {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Comment 按行加上注释前缀，如 "// "、"# "
+func Comment(prefix, comment string) string {
+	if comment == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(comment, "\n") {
+		b.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// Printer 按行输出代码，自动缩进
+type Printer struct {
+	b      strings.Builder
+	indent string
+	depth  int
+}
+
+// NewPrinter 创建 Printer，indent 为一级缩进
+func NewPrinter(indent string) *Printer {
+	return &Printer{indent: indent}
+}
+
+// P 输出一行，没有参数时不做格式化
+func (p *Printer) P(format string, args ...interface{}) {
+	if len(args) > 0 {
+		format = fmt.Sprintf(format, args...)
+	}
+	if format != "" {
+		p.b.WriteString(strings.Repeat(p.indent, p.depth))
+	}
+	p.b.WriteString(format)
+	p.b.WriteByte('\n')
+}
+
+// Raw 原样输出多行内容，每行加上当前缩进，s 为空时不输出
+func (p *Printer) Raw(s string) {
+	if s == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(s, "\n"), "\n") {
+		p.P("%s", line)
+	}
+}
+
+// In 增加一级缩进
+func (p *Printer) In() {
+	p.depth++
+}
+
+// Out 减少一级缩进
+func (p *Printer) Out() {
+	p.depth--
+}
+
+// String 输出的内容
+func (p *Printer) String() string {
+	return p.b.String()
+}
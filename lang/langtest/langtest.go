@@ -0,0 +1,112 @@
+// Package langtest 是 lang 下各语言生成器的测试共用的 schema 和数据，文件都在 lang/testdata 中：
+//
+//	base.jce、test.jce  测试的 schema，test::Packet 覆盖了所有的类型
+//	fixtures.hex        test::Packet 的 jce 编码，每行一个，# 开头的行为注释
+//
+// fixtures.hex 需要和 demo2go/fixture 中生成的 go 代码编码的结果相同，由 demo2go/fixture_test.go 检查。
+// 现在的数据由 dynamic.Encode 编码，还没有和生成的 go 代码比较，见 fixtures.hex 开头的注释。
+// 修改 schema 或者 go 代码的生成后需要重新生成：
+//
+//	jce2go -mod github.com/erpc-go/jce2go -o demo2go/fixture lang/testdata/base.jce lang/testdata/test.jce
+//	go test ./demo2go -run TestLangFixtures -update
+package langtest
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// Dir 返回 lang/testdata 的路径
+func Dir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "testdata")
+}
+
+// Set 解析 test.jce 以及它 include 的 base.jce
+func Set(t *testing.T) *descriptor.Set {
+	t.Helper()
+	filename := filepath.Join(Dir(), "test.jce")
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parser.ParseSource(filename, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return descriptor.Build(p)
+}
+
+// Generate 使用语言生成器 gen 为 test.jce 生成代码
+func Generate(t *testing.T, gen func(set *descriptor.Set, param string) ([]plugin.File, error), param string) []plugin.File {
+	t.Helper()
+	files, err := gen(Set(t), param)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// WriteFiles 把生成的文件写入 dir，需要时创建子目录，rewrite 不为 nil 时写入 rewrite 返回的内容
+func WriteFiles(t *testing.T, dir string, files []plugin.File, rewrite func(content string) string) {
+	t.Helper()
+	for _, f := range files {
+		content := f.Content
+		if rewrite != nil {
+			content = rewrite(content)
+		}
+		name := filepath.Join(dir, filepath.FromSlash(f.Name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Fixtures 返回 fixtures.hex 中的数据，每个为一个 hex 字符串
+func Fixtures(t *testing.T) []string {
+	t.Helper()
+	data, err := ioutil.ReadFile(filepath.Join(Dir(), "fixtures.hex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ret []string
+	s := bufio.NewScanner(bytes.NewReader(data))
+	s.Buffer(nil, len(data)+1)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			ret = append(ret, line)
+		}
+	}
+	if len(ret) == 0 {
+		t.Fatal("no fixtures in fixtures.hex")
+	}
+	return ret
+}
+
+// Check 检查 out 中每行的 hex 和对应的 fixture 相同，out 为解码 fixture 后重新编码的结果
+func Check(t *testing.T, out []byte, fixtures []string) {
+	t.Helper()
+	got := strings.Fields(string(out))
+	if len(got) != len(fixtures) {
+		t.Fatalf("unexpected output %s", out)
+	}
+	for i := range fixtures {
+		if got[i] != fixtures[i] {
+			t.Errorf("fixture %d:\nwant %s\ngot  %s", i, fixtures[i], got[i])
+		}
+	}
+}
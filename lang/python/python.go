@@ -0,0 +1,450 @@
+// Package python 生成 Python 代码：struct 生成 dataclass，enum 生成 IntEnum，
+// 每个 struct 有 to_bytes、from_bytes 方法，依赖同目录下的纯 Python 运行时 jce.py
+//
+// 每个 jce 文件生成一个同名的 .py 文件，include 的文件按 module 导入。
+// --lang_opt=package=a.b 时所有文件属于 python 包 a.b，使用 from a.b import xxx 导入，
+// 否则使用 import xxx，需要把输出目录加入 sys.path。
+// 类型的对应关系：
+//
+//	bool                      bool
+//	byte、short、int、long      int
+//	float、double              float
+//	string                    str
+//	vector<byte>              bytes
+//	vector<T>、array<T>        List[T]
+//	map<K, V>                 Dict[K, V]
+//
+// 成员名和 python 关键字冲突时加上 _ 后缀
+package python
+
+import (
+	_ "embed"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+//go:embed runtime/jce.py
+var runtime string
+
+// RuntimeName 运行时的文件名
+const RuntimeName = "jce.py"
+
+// python 的关键字，不能作为属性名
+var keywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// Generate 生成 set 中所有文件的代码以及运行时
+func Generate(set *descriptor.Set, param string) ([]plugin.File, error) {
+	opts, err := lang.ParseParam(param)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{x: lang.NewIndex(set)}
+	for k, v := range opts {
+		switch k {
+		case "package":
+			g.pkg = v
+		default:
+			return nil, fmt.Errorf("unknown python option %q", k)
+		}
+	}
+
+	files := []plugin.File{{Name: RuntimeName, Content: runtime}}
+	seen := map[string]string{}
+	for _, f := range set.Files {
+		name := moduleName(f.Name) + ".py"
+		if prev, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s and %s both generate %s", prev, f.Name, name)
+		}
+		seen[name] = f.Name
+
+		code, err := g.genFile(f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, plugin.File{Name: name, Content: code})
+	}
+	return files, nil
+}
+
+// test.jce 对应的 python 模块名 test
+func moduleName(jce string) string {
+	base := path.Base(strings.ReplaceAll(jce, "\\", "/"))
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+type generator struct {
+	x   *lang.Index
+	pkg string
+	f   *descriptor.File
+	p   *lang.Printer
+}
+
+type genError string
+
+func fail(format string, args ...interface{}) {
+	panic(genError(fmt.Sprintf(format, args...)))
+}
+
+func (g *generator) genFile(f *descriptor.File) (code string, err error) {
+	// 类型错误在深层的递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if msg, ok := e.(genError); ok {
+				err = fmt.Errorf("%s: %s", f.Name, string(msg))
+				return
+			}
+			panic(e)
+		}
+	}()
+
+	g.f, g.p = f, lang.NewPrinter("    ")
+	p := g.p
+	p.P("# DO NOT EDIT IT.")
+	p.P("# code generated by jce2go %s.", version.VERSION)
+	p.P("# source: %s", path.Base(f.Name))
+	p.P("")
+	p.P("from __future__ import annotations")
+	p.P("")
+	p.P("from dataclasses import dataclass, field")
+	p.P("from enum import IntEnum")
+	p.P("from typing import Dict, List")
+	p.P("")
+	g.importModule("jce")
+	for _, m := range lang.Deps(f) {
+		dep := g.x.Module(m)
+		if dep == nil {
+			return "", fmt.Errorf("%s: module %s not found", f.Name, m)
+		}
+		g.importModule(moduleName(dep.Name))
+	}
+
+	for _, en := range f.Enums {
+		g.genEnum(en)
+	}
+	if len(f.Consts) > 0 {
+		p.P("")
+		p.P("")
+		for _, c := range f.Consts {
+			p.Raw(lang.Comment("# ", c.Comment))
+			p.P("%s: %s = %s", c.Name, g.typ(c.Type), g.literal(c.Type, c.Value))
+		}
+	}
+	for _, st := range f.Structs {
+		g.genStruct(st)
+	}
+	return p.String(), nil
+}
+
+func (g *generator) importModule(name string) {
+	if g.pkg != "" {
+		g.p.P("from %s import %s", g.pkg, name)
+		return
+	}
+	g.p.P("import %s", name)
+}
+
+// python 的 docstring
+func (g *generator) doc(comment string) {
+	if comment == "" {
+		return
+	}
+	comment = strings.ReplaceAll(comment, `"""`, `\"\"\"`)
+	if !strings.Contains(comment, "\n") {
+		g.p.P(`"""%s"""`, comment)
+		return
+	}
+	g.p.P(`"""`)
+	g.p.Raw(comment)
+	g.p.P(`"""`)
+}
+
+func (g *generator) genEnum(en *descriptor.Enum) {
+	p := g.p
+	p.P("")
+	p.P("")
+	p.P("class %s(IntEnum):", lang.Upper(en.Name))
+	p.In()
+	g.doc(en.Comment)
+	if len(en.Values) == 0 && en.Comment == "" {
+		p.P("pass")
+	}
+	for _, v := range en.Values {
+		p.Raw(lang.Comment("# ", v.Comment))
+		p.P("%s = %d", attr(v.Name), v.Value)
+	}
+	p.Out()
+}
+
+// python 的 tuple 字面量
+func tuple(items []string) string {
+	if len(items) == 1 {
+		return "(" + items[0] + ",)"
+	}
+	return "(" + strings.Join(items, ", ") + ")"
+}
+
+// 属性名，和关键字冲突时加上 _ 后缀
+func attr(name string) string {
+	if keywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+func (g *generator) genStruct(st *descriptor.Struct) {
+	p := g.p
+	name := lang.Upper(st.Name)
+
+	p.P("")
+	p.P("")
+	p.P("@dataclass")
+	p.P("class %s:", name)
+	p.In()
+	g.doc(st.Comment)
+	if st.Comment != "" && len(st.Members) > 0 {
+		p.P("")
+	}
+	for _, mb := range st.Members {
+		p.Raw(lang.Comment("# ", mb.Comment))
+		p.P("%s: %s = %s", attr(mb.Name), g.typ(mb.Type), g.fieldDefault(mb))
+	}
+	if len(st.Members) > 0 || st.Comment != "" {
+		p.P("")
+	}
+
+	p.P("def to_bytes(self) -> bytes:")
+	p.In()
+	p.P(`"""编码为 jce 数据"""`)
+	p.P("w = jce.Writer()")
+	p.P("self.write_to(w, 0)")
+	p.P("return w.getvalue()")
+	p.Out()
+	p.P("")
+	p.P("@classmethod")
+	p.P("def from_bytes(cls, data: bytes) -> %s:", name)
+	p.In()
+	p.P(`"""从 jce 数据解码"""`)
+	p.P("r = jce.Reader(data)")
+	p.P("return cls.read_from(r, r.read_head()[0])")
+	p.Out()
+
+	p.P("")
+	p.P("def write_to(self, w: jce.Writer, tag: int) -> None:")
+	p.In()
+	p.P("w.write_head(jce.STRUCT_BEGIN, 0)  # 和生成的 go 代码相同，不写入成员的 tag")
+	for _, mb := range st.Members {
+		p.P("%s", g.writeExpr(mb.Type, "self."+attr(mb.Name), strconv.Itoa(int(mb.Tag))))
+	}
+	p.P("w.write_head(jce.STRUCT_END, 0)")
+	p.Out()
+
+	var required []string
+	for _, mb := range st.Members {
+		if mb.Required {
+			required = append(required, strconv.Itoa(int(mb.Tag)))
+		}
+	}
+	p.P("")
+	p.P("@classmethod")
+	p.P("def read_from(cls, r: jce.Reader, ty: int) -> %s:", name)
+	p.In()
+	p.P("v = cls()")
+	p.P("for tag, ty in r.read_struct(ty, %s, %s):", tuple(required), tuple(lang.StructTags(st)))
+	p.In()
+	for i, mb := range st.Members {
+		kw := "elif"
+		if i == 0 {
+			kw = "if"
+		}
+		p.P("%s tag == %d:", kw, mb.Tag)
+		p.In()
+		p.P("v.%s = %s", attr(mb.Name), g.readExpr(mb.Type))
+		p.Out()
+	}
+	if len(st.Members) > 0 {
+		p.P("else:")
+		p.In()
+	}
+	p.P("r.skip(ty)")
+	if len(st.Members) > 0 {
+		p.Out()
+	}
+	p.Out()
+	p.P("return v")
+	p.Out()
+	p.Out()
+}
+
+// struct、enum 的类名，其他 module 的类型加上 module 前缀
+func (g *generator) ref(name string) string {
+	m, short := lang.SplitName(name)
+	if m == g.f.Module {
+		return lang.Upper(short)
+	}
+	dep := g.x.Module(m)
+	if dep == nil {
+		fail("module %s not found", m)
+	}
+	return moduleName(dep.Name) + "." + lang.Upper(short)
+}
+
+func (g *generator) typ(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "bool"
+	case "byte", "short", "int", "long":
+		return "int"
+	case "float", "double":
+		return "float"
+	case "string":
+		return "str"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "bytes"
+		}
+		return "List[" + g.typ(t.Params[0]) + "]"
+	case "map":
+		return "Dict[" + g.typ(t.Params[0]) + ", " + g.typ(t.Params[1]) + "]"
+	case "struct", "enum":
+		return g.ref(t.Name)
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 整数类型的取值范围
+func intRange(t *descriptor.Type) (string, string) {
+	bits := map[string]int{"byte": 8, "short": 16, "int": 32, "long": 64}[t.Kind]
+	if t.IsUnsigned {
+		return "0", fmt.Sprintf("(1 << %d) - 1", bits)
+	}
+	return fmt.Sprintf("-(1 << %d)", bits-1), fmt.Sprintf("(1 << %d) - 1", bits-1)
+}
+
+// 读取一个值的表达式，head 已经读到变量 ty 中
+func (g *generator) readExpr(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "r.read_bool(ty)"
+	case "byte", "short", "int", "long":
+		lo, hi := intRange(t)
+		return "r.read_int(ty, " + lo + ", " + hi + ")"
+	case "float", "double":
+		return "r.read_float(ty)"
+	case "string":
+		return "r.read_string(ty)"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "r.read_bytes(ty)"
+		}
+		return "r.read_list(ty, lambda r, ty: " + g.readExpr(t.Params[0]) + ")"
+	case "map":
+		return "r.read_map(ty, lambda r, ty: " + g.readExpr(t.Params[0]) + ", lambda r, ty: " + g.readExpr(t.Params[1]) + ")"
+	case "enum":
+		return "jce.enum_value(" + g.ref(t.Name) + ", r.read_int(ty, -(1 << 31), (1 << 31) - 1))"
+	case "struct":
+		return g.ref(t.Name) + ".read_from(r, ty)"
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 写入一个值的语句
+func (g *generator) writeExpr(t *descriptor.Type, v, tag string) string {
+	switch t.Kind {
+	case "bool":
+		return "w.write_bool(" + v + ", " + tag + ")"
+	case "byte", "short", "int", "long", "enum":
+		return "w.write_int(" + v + ", " + tag + ")"
+	case "float":
+		return "w.write_float(" + v + ", " + tag + ")"
+	case "double":
+		return "w.write_double(" + v + ", " + tag + ")"
+	case "string":
+		return "w.write_string(" + v + ", " + tag + ")"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "w.write_bytes(" + v + ", " + tag + ")"
+		}
+		return "w.write_list(" + v + ", " + tag + ", lambda w, v, tag: " + g.writeExpr(t.Params[0], "v", "tag") + ")"
+	case "map":
+		return "w.write_map(" + v + ", " + tag + ", lambda w, v, tag: " + g.writeExpr(t.Params[0], "v", "tag") +
+			", lambda w, v, tag: " + g.writeExpr(t.Params[1], "v", "tag") + ")"
+	case "struct":
+		return v + ".write_to(w, " + tag + ")"
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// dataclass 成员的默认值，可变的类型使用 default_factory
+func (g *generator) fieldDefault(mb *descriptor.Member) string {
+	if mb.Default != "" {
+		return g.literal(mb.Type, mb.Default)
+	}
+	switch mb.Type.Kind {
+	case "bool":
+		return "False"
+	case "byte", "short", "int", "long":
+		return "0"
+	case "float", "double":
+		return "0.0"
+	case "string":
+		return `""`
+	case "vector", "array":
+		if lang.IsBytes(mb.Type) {
+			return `b""`
+		}
+		return "field(default_factory=list)"
+	case "map":
+		return "field(default_factory=dict)"
+	case "enum":
+		// 和 go 一样，枚举的零值为 0
+		en := g.x.Enum(mb.Type.Name)
+		if en == nil {
+			fail("enum %s not found", mb.Type.Name)
+		}
+		for _, v := range en.Values {
+			if v.Value == 0 {
+				return g.ref(mb.Type.Name) + "." + attr(v.Name)
+			}
+		}
+		return "0"
+	case "struct":
+		// 同一个文件中的 struct 可能定义在后面，使用 lambda 延迟求值
+		return "field(default_factory=lambda: " + g.ref(mb.Type.Name) + "())"
+	}
+	fail("unsupported type %s", mb.Type.Kind)
+	return ""
+}
+
+// 源码中的常量、默认值转换为 python 的字面量
+func (g *generator) literal(t *descriptor.Type, v string) string {
+	switch t.Kind {
+	case "bool":
+		if v == "true" {
+			return "True"
+		}
+		return "False"
+	case "enum":
+		ev, err := g.x.EnumDefault(t, v)
+		if err != nil {
+			fail("%v", err)
+		}
+		return g.ref(t.Name) + "." + attr(ev.Name)
+	}
+	return v
+}
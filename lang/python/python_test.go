@@ -0,0 +1,69 @@
+package python
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang/langtest"
+)
+
+func TestGenerate(t *testing.T) {
+	files := langtest.Generate(t, Generate, "package=gen.jce")
+	if len(files) != 3 || files[0].Name != RuntimeName || files[1].Name != "base.py" || files[2].Name != "test.py" {
+		t.Fatalf("unexpected files %v", files)
+	}
+	code := files[2].Content
+	for _, s := range []string{
+		"from gen.jce import jce",
+		"from gen.jce import base",
+		"class Color(IntEnum):",
+		"    Green = 2",
+		"    c: Color = Color.Green",
+		"    raw: bytes = b\"\"",
+		"    nested: List[List[str]] = field(default_factory=list)",
+		"    byId: Dict[int, base.Item] = field(default_factory=dict)",
+		"    from_: str = \"\"",
+		"        for tag, ty in r.read_struct(ty, (0, 1), (13,)):",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+
+	if _, err := Generate(&descriptor.Set{}, "pkg=x"); err == nil {
+		t.Fatal("expect error for unknown option")
+	}
+}
+
+// python 解码 fixtures.hex 中的数据后重新编码，结果和原来的数据相同
+func TestRoundTrip(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+	files := langtest.Generate(t, Generate, "")
+
+	dir := t.TempDir()
+	langtest.WriteFiles(t, dir, files, nil)
+	main := `import sys
+import test
+
+for arg in sys.argv[1:]:
+    v = test.Packet.from_bytes(bytes.fromhex(arg))
+    print(v.to_bytes().hex())
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.py"), []byte(main), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := langtest.Fixtures(t)
+	out, err := exec.Command(python, append([]string{filepath.Join(dir, "main.py")}, fixtures...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	langtest.Check(t, out, fixtures)
+}
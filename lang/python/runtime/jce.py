@@ -0,0 +1,300 @@
+# DO NOT EDIT IT.
+# jce 编码的 Python 运行时，由 jce2go --lang=python 生成，只依赖标准库。
+#
+# 每个字段由 head + data 组成，head 第一个字节高 4 位是 tag、低 4 位是 type，
+# tag >= 15 时高 4 位为 15，第二个字节存 tag。整数、长度均为大端。
+
+import struct
+from typing import Any, Callable, Dict, Iterator, List, Sequence, Tuple, Type, TypeVar
+
+INT1 = 0
+INT2 = 1
+INT4 = 2
+INT8 = 3
+FLOAT = 4
+DOUBLE = 5
+STRING1 = 6
+STRING4 = 7
+MAP = 8
+LIST = 9
+STRUCT_BEGIN = 10
+STRUCT_END = 11
+ZERO_TAG = 12
+SIMPLE_LIST = 13
+
+_TYPE_NAMES = [
+    "Int1", "Int2", "Int4", "Int8", "Float", "Double", "String1", "String4",
+    "Map", "List", "StructBegin", "StructEnd", "ZeroTag", "SimpleList",
+]
+
+_INTS = {INT1: ">b", INT2: ">h", INT4: ">i", INT8: ">q"}
+_SIZES = {INT1: 1, INT2: 2, INT4: 4, INT8: 8}
+
+T = TypeVar("T")
+K = TypeVar("K")
+V = TypeVar("V")
+E = TypeVar("E")
+
+
+def _type_name(ty: int) -> str:
+    return _TYPE_NAMES[ty] if 0 <= ty < len(_TYPE_NAMES) else "Type(%d)" % ty
+
+
+class JceError(ValueError):
+    """数据格式错误，offset 为出错位置的字节偏移"""
+
+    def __init__(self, message: str, offset: int) -> None:
+        super().__init__("offset %d: %s" % (offset, message))
+        self.offset = offset
+
+
+def enum_value(cls: Type[E], v: int) -> E:
+    """把整数转换为枚举，未知的取值保留为整数"""
+    try:
+        return cls(v)  # type: ignore[call-arg]
+    except ValueError:
+        return v  # type: ignore[return-value]
+
+
+class Reader:
+    """从字节数组中读取 jce 数据"""
+
+    def __init__(self, data: bytes) -> None:
+        self._buf = bytes(data)
+        self._off = 0
+
+    @property
+    def offset(self) -> int:
+        """当前读取位置"""
+        return self._off
+
+    def _next(self, n: int) -> int:
+        if len(self._buf) - self._off < n:
+            raise JceError("unexpected end of data, need %d bytes, have %d" % (n, len(self._buf) - self._off), self._off)
+        start = self._off
+        self._off += n
+        return start
+
+    def _mismatch(self, ty: int, expect: str) -> JceError:
+        return JceError("type %s is not %s" % (_type_name(ty), expect), self._off)
+
+    def read_head(self) -> Tuple[int, int]:
+        """读取 head，返回 (type, tag)"""
+        start = self._off
+        b = self._buf[self._next(1)]
+        ty, tag = b & 0x0F, b >> 4
+        if tag == 15:
+            tag = self._buf[self._next(1)]
+        if ty > SIMPLE_LIST:
+            raise JceError("invalid type %d" % ty, start)
+        return ty, tag
+
+    def _read_raw_int(self, ty: int) -> int:
+        if ty == ZERO_TAG:
+            return 0
+        if ty not in _INTS:
+            raise self._mismatch(ty, "an integer")
+        start = self._next(_SIZES[ty])
+        return struct.unpack_from(_INTS[ty], self._buf, start)[0]
+
+    def read_int(self, ty: int, lo: int, hi: int) -> int:
+        """读取整数，超出 [lo, hi] 时报错，unsigned long 按补码转换"""
+        start = self._off
+        v = self._read_raw_int(ty)
+        if v < 0 and lo == 0 and hi >= 1 << 63:
+            v += 1 << 64
+        if v < lo or v > hi:
+            raise JceError("%d overflows [%d, %d]" % (v, lo, hi), start)
+        return v
+
+    def read_bool(self, ty: int) -> bool:
+        """读取 bool，按整数编码，非 0 为 true"""
+        return self._read_raw_int(ty) != 0
+
+    def read_float(self, ty: int) -> float:
+        """读取 float、double"""
+        if ty == ZERO_TAG:
+            return 0.0
+        if ty == FLOAT:
+            return struct.unpack_from(">f", self._buf, self._next(4))[0]
+        if ty == DOUBLE:
+            return struct.unpack_from(">d", self._buf, self._next(8))[0]
+        raise self._mismatch(ty, "a float")
+
+    def read_length(self) -> int:
+        """读取 4B 长度，长度超过剩余数据时报错"""
+        start = self._next(4)
+        n = struct.unpack_from(">I", self._buf, start)[0]
+        if n > len(self._buf) - self._off:
+            raise JceError("length %d exceeds remaining %d bytes" % (n, len(self._buf) - self._off), start)
+        return n
+
+    def read_string(self, ty: int) -> str:
+        """读取字符串"""
+        if ty == STRING1:
+            n = self._buf[self._next(1)]
+        elif ty == STRING4:
+            n = self.read_length()
+        else:
+            raise self._mismatch(ty, "a string")
+        start = self._next(n)
+        return self._buf[start:start + n].decode("utf-8")
+
+    def read_bytes(self, ty: int) -> bytes:
+        """读取 vector<byte>，也兼容按 List 编码的数据"""
+        if ty == LIST:
+            return bytes(x & 0xFF for x in self.read_list(ty, lambda r, ty: r.read_int(ty, -128, 255)))
+        if ty != SIMPLE_LIST:
+            raise self._mismatch(ty, "a SimpleList")
+        n = self.read_length()
+        start = self._next(n)
+        return self._buf[start:start + n]
+
+    def read_list(self, ty: int, elem: Callable[["Reader", int], T]) -> List[T]:
+        """读取 vector，每个元素都是 tag 为 0 的字段"""
+        if ty != LIST:
+            raise self._mismatch(ty, "a List")
+        return [elem(self, self.read_head()[0]) for _ in range(self.read_length())]
+
+    def read_map(self, ty: int, key: Callable[["Reader", int], K], value: Callable[["Reader", int], V]) -> Dict[K, V]:
+        """读取 map，key 的 tag 为 0，value 的 tag 为 1"""
+        if ty != MAP:
+            raise self._mismatch(ty, "a Map")
+        ret: Dict[K, V] = {}
+        for _ in range(self.read_length()):
+            k = key(self, self.read_head()[0])
+            ret[k] = value(self, self.read_head()[0])
+        return ret
+
+    def read_struct(self, ty: int, required: Sequence[int], structs: Sequence[int] = ()) -> Iterator[Tuple[int, int]]:
+        """读取结构体，依次返回每个字段的 (tag, type)，调用方读取或者 skip 字段的 data。
+        required 为 require 字段的 tag，读到 StructEnd 时检查。structs 为结构体类型的成员的 tag，从小到大：
+        生成的 go 代码写入结构体成员时 StructBegin 的 tag 总是 0，tag 对应不到结构体成员时，
+        按位置对应到上一个字段之后的第一个结构体成员"""
+        if ty != STRUCT_BEGIN:
+            raise self._mismatch(ty, "a StructBegin")
+        seen = set()
+        last = -1
+        while True:
+            ty, tag = self.read_head()
+            if ty == STRUCT_END:
+                break
+            if ty == STRUCT_BEGIN and (tag not in structs or tag <= last):
+                tag = next((t for t in structs if t > last), tag)
+            seen.add(tag)
+            last = tag
+            yield tag, ty
+        for tag in required:
+            if tag not in seen:
+                raise JceError("require field tag %d not found" % tag, self._off)
+
+    def skip(self, ty: int) -> None:
+        """跳过一个指定类型的 data"""
+        if ty in (ZERO_TAG, STRUCT_END):
+            return
+        if ty in _INTS:
+            self._read_raw_int(ty)
+        elif ty in (FLOAT, DOUBLE):
+            self.read_float(ty)
+        elif ty in (STRING1, STRING4):
+            self.read_string(ty)
+        elif ty == SIMPLE_LIST:
+            self._next(self.read_length())
+        elif ty in (LIST, MAP):
+            n = self.read_length()
+            for _ in range(n * 2 if ty == MAP else n):
+                self.skip(self.read_head()[0])
+        elif ty == STRUCT_BEGIN:
+            while True:
+                ty = self.read_head()[0]
+                if ty == STRUCT_END:
+                    return
+                self.skip(ty)
+        else:
+            raise JceError("invalid type %d" % ty, self._off)
+
+
+class Writer:
+    """把 jce 数据写入内存"""
+
+    def __init__(self) -> None:
+        self._buf = bytearray()
+
+    def getvalue(self) -> bytes:
+        """已写入的数据"""
+        return bytes(self._buf)
+
+    def write_head(self, ty: int, tag: int) -> None:
+        if tag < 15:
+            self._buf.append(tag << 4 | ty)
+        else:
+            self._buf.append(0xF0 | ty)
+            self._buf.append(tag)
+
+    def write_length(self, n: int) -> None:
+        self._buf += struct.pack(">I", n)
+
+    def write_int(self, v: int, tag: int) -> None:
+        """写整数，按取值选择最小的编码类型，unsigned long 超过 int64 的部分按补码写入"""
+        v = int(v)
+        if v >= 1 << 63:
+            v -= 1 << 64
+        if v == 0:
+            self.write_head(ZERO_TAG, tag)
+            return
+        for ty in (INT1, INT2, INT4, INT8):
+            bits = _SIZES[ty] * 8 - 1
+            if -(1 << bits) <= v < 1 << bits:
+                self.write_head(ty, tag)
+                self._buf += struct.pack(_INTS[ty], v)
+                return
+        raise OverflowError("%d overflows int64" % v)
+
+    def write_bool(self, v: bool, tag: int) -> None:
+        """写 bool，按整数 0、1 编码"""
+        self.write_int(1 if v else 0, tag)
+
+    def write_float(self, v: float, tag: int) -> None:
+        if v == 0:
+            self.write_head(ZERO_TAG, tag)
+            return
+        self.write_head(FLOAT, tag)
+        self._buf += struct.pack(">f", v)
+
+    def write_double(self, v: float, tag: int) -> None:
+        if v == 0:
+            self.write_head(ZERO_TAG, tag)
+            return
+        self.write_head(DOUBLE, tag)
+        self._buf += struct.pack(">d", v)
+
+    def write_string(self, v: str, tag: int) -> None:
+        """写字符串，长度不超过 255 时使用 String1"""
+        b = v.encode("utf-8")
+        if len(b) <= 0xFF:
+            self.write_head(STRING1, tag)
+            self._buf.append(len(b))
+        else:
+            self.write_head(STRING4, tag)
+            self.write_length(len(b))
+        self._buf += b
+
+    def write_bytes(self, v: bytes, tag: int) -> None:
+        """写 vector<byte>"""
+        self.write_head(SIMPLE_LIST, tag)
+        self.write_length(len(v))
+        self._buf += v
+
+    def write_list(self, v: Sequence[T], tag: int, elem: Callable[["Writer", T, int], Any]) -> None:
+        self.write_head(LIST, tag)
+        self.write_length(len(v))
+        for e in v:
+            elem(self, e, 0)
+
+    def write_map(self, v: Dict[K, V], tag: int, key: Callable[["Writer", K, int], Any],
+                  value: Callable[["Writer", V, int], Any]) -> None:
+        self.write_head(MAP, tag)
+        self.write_length(len(v))
+        for k, e in v.items():
+            key(self, k, 0)
+            value(self, e, 1)
@@ -0,0 +1,575 @@
+//! jce 编码的 Rust 运行时，jce2go --lang=rust 生成的代码依赖这个 crate，只依赖标准库。
+//!
+//! 每个字段由 head + data 组成，head 第一个字节高 4 位是 tag、低 4 位是 type，
+//! tag >= 15 时高 4 位为 15，第二个字节存 tag。整数、长度均为大端。
+//!
+//! 基础类型、生成的 struct 和 enum 实现了 [`Encode`]、[`Decode`]，
+//! vector、map 由生成的代码调用 [`Writer::write_list`]、[`Reader::read_list`] 等方法编解码，
+//! 这样 `Vec<u8>` 可以按 SimpleList 编码。
+
+use std::collections::{BTreeMap, HashSet};
+use std::convert::TryFrom;
+use std::fmt;
+
+pub const INT1: u8 = 0;
+pub const INT2: u8 = 1;
+pub const INT4: u8 = 2;
+pub const INT8: u8 = 3;
+pub const FLOAT: u8 = 4;
+pub const DOUBLE: u8 = 5;
+pub const STRING1: u8 = 6;
+pub const STRING4: u8 = 7;
+pub const MAP: u8 = 8;
+pub const LIST: u8 = 9;
+pub const STRUCT_BEGIN: u8 = 10;
+pub const STRUCT_END: u8 = 11;
+pub const ZERO_TAG: u8 = 12;
+pub const SIMPLE_LIST: u8 = 13;
+
+const TYPE_NAMES: [&str; 14] = [
+    "Int1", "Int2", "Int4", "Int8", "Float", "Double", "String1", "String4", "Map", "List", "StructBegin",
+    "StructEnd", "ZeroTag", "SimpleList",
+];
+
+fn type_name(ty: u8) -> String {
+    match TYPE_NAMES.get(ty as usize) {
+        Some(name) => name.to_string(),
+        None => format!("Type({})", ty),
+    }
+}
+
+/// 数据格式错误，offset 为出错位置的字节偏移
+#[derive(Clone, Debug, PartialEq)]
+pub struct Error {
+    pub offset: usize,
+    pub message: String,
+}
+
+impl Error {
+    pub fn new(offset: usize, message: impl Into<String>) -> Error {
+        Error { offset, message: message.into() }
+    }
+}
+
+impl fmt::Display for Error {
+    fn fmt(&self, f: &mut fmt::Formatter<'_>) -> fmt::Result {
+        write!(f, "offset {}: {}", self.offset, self.message)
+    }
+}
+
+impl std::error::Error for Error {}
+
+pub type Result<T> = std::result::Result<T, Error>;
+
+/// 编码为 tag 对应的字段
+pub trait Encode {
+    fn encode(&self, w: &mut Writer, tag: u8);
+}
+
+/// 从 type 为 ty 的字段解码，head 已经读取
+pub trait Decode: Sized {
+    fn decode(r: &mut Reader<'_>, ty: u8) -> Result<Self>;
+}
+
+/// 编码结构体，和 go 生成的代码相同，最外层是 tag 为 0 的结构体
+pub fn to_bytes<T: Encode>(v: &T) -> Vec<u8> {
+    let mut w = Writer::new();
+    v.encode(&mut w, 0);
+    w.into_bytes()
+}
+
+/// 解码 to_bytes 的结果
+pub fn from_bytes<T: Decode>(data: &[u8]) -> Result<T> {
+    let mut r = Reader::new(data);
+    let (ty, _) = r.read_head()?;
+    T::decode(&mut r, ty)
+}
+
+/// 从字节数组中读取 jce 数据
+pub struct Reader<'a> {
+    buf: &'a [u8],
+    off: usize,
+}
+
+impl<'a> Reader<'a> {
+    pub fn new(buf: &'a [u8]) -> Reader<'a> {
+        Reader { buf, off: 0 }
+    }
+
+    /// 当前读取位置
+    pub fn offset(&self) -> usize {
+        self.off
+    }
+
+    fn next(&mut self, n: usize) -> Result<&'a [u8]> {
+        let have = self.buf.len() - self.off;
+        if have < n {
+            return Err(Error::new(self.off, format!("unexpected end of data, need {} bytes, have {}", n, have)));
+        }
+        let b = &self.buf[self.off..self.off + n];
+        self.off += n;
+        Ok(b)
+    }
+
+    fn mismatch(&self, ty: u8, expect: &str) -> Error {
+        Error::new(self.off, format!("type {} is not {}", type_name(ty), expect))
+    }
+
+    /// 读取 head，返回 (type, tag)
+    pub fn read_head(&mut self) -> Result<(u8, u8)> {
+        let start = self.off;
+        let b = self.next(1)?[0];
+        let (ty, mut tag) = (b & 0x0F, b >> 4);
+        if tag == 15 {
+            tag = self.next(1)?[0];
+        }
+        if ty > SIMPLE_LIST {
+            return Err(Error::new(start, format!("invalid type {}", ty)));
+        }
+        Ok((ty, tag))
+    }
+
+    /// 读取整数
+    pub fn read_int(&mut self, ty: u8) -> Result<i64> {
+        let v = match ty {
+            ZERO_TAG => 0,
+            INT1 => self.next(1)?[0] as i8 as i64,
+            INT2 => i16::from_be_bytes(<[u8; 2]>::try_from(self.next(2)?).unwrap()) as i64,
+            INT4 => i32::from_be_bytes(<[u8; 4]>::try_from(self.next(4)?).unwrap()) as i64,
+            INT8 => i64::from_be_bytes(<[u8; 8]>::try_from(self.next(8)?).unwrap()),
+            _ => return Err(self.mismatch(ty, "an integer")),
+        };
+        Ok(v)
+    }
+
+    /// 读取 float、double
+    pub fn read_float(&mut self, ty: u8) -> Result<f64> {
+        let v = match ty {
+            ZERO_TAG => 0.0,
+            FLOAT => f32::from_be_bytes(<[u8; 4]>::try_from(self.next(4)?).unwrap()) as f64,
+            DOUBLE => f64::from_be_bytes(<[u8; 8]>::try_from(self.next(8)?).unwrap()),
+            _ => return Err(self.mismatch(ty, "a float")),
+        };
+        Ok(v)
+    }
+
+    /// 读取 4B 长度，长度超过剩余数据时报错
+    pub fn read_length(&mut self) -> Result<usize> {
+        let start = self.off;
+        let n = u32::from_be_bytes(<[u8; 4]>::try_from(self.next(4)?).unwrap()) as usize;
+        let have = self.buf.len() - self.off;
+        if n > have {
+            return Err(Error::new(start, format!("length {} exceeds remaining {} bytes", n, have)));
+        }
+        Ok(n)
+    }
+
+    /// 读取字符串
+    pub fn read_string(&mut self, ty: u8) -> Result<String> {
+        let n = match ty {
+            STRING1 => self.next(1)?[0] as usize,
+            STRING4 => self.read_length()?,
+            _ => return Err(self.mismatch(ty, "a string")),
+        };
+        let start = self.off;
+        let b = self.next(n)?;
+        String::from_utf8(b.to_vec()).map_err(|e| Error::new(start, e.to_string()))
+    }
+
+    /// 读取 vector<byte>，也兼容按 List 编码的数据
+    pub fn read_bytes(&mut self, ty: u8) -> Result<Vec<u8>> {
+        if ty == LIST {
+            return self.read_list(ty, |r, ty| {
+                let start = r.offset();
+                match r.read_int(ty)? {
+                    v @ -128..=255 => Ok(v as u8),
+                    v => Err(Error::new(start, format!("{} overflows a byte", v))),
+                }
+            });
+        }
+        if ty != SIMPLE_LIST {
+            return Err(self.mismatch(ty, "a SimpleList"));
+        }
+        let n = self.read_length()?;
+        Ok(self.next(n)?.to_vec())
+    }
+
+    /// 读取 vector，每个元素都是 tag 为 0 的字段
+    pub fn read_list<T, F>(&mut self, ty: u8, mut elem: F) -> Result<Vec<T>>
+    where
+        F: FnMut(&mut Reader<'a>, u8) -> Result<T>,
+    {
+        if ty != LIST {
+            return Err(self.mismatch(ty, "a List"));
+        }
+        let n = self.read_length()?;
+        let mut ret = Vec::with_capacity(n);
+        for _ in 0..n {
+            let (ty, _) = self.read_head()?;
+            ret.push(elem(self, ty)?);
+        }
+        Ok(ret)
+    }
+
+    /// 读取 map，key 的 tag 为 0，value 的 tag 为 1
+    pub fn read_map<K, V, FK, FV>(&mut self, ty: u8, mut key: FK, mut value: FV) -> Result<BTreeMap<K, V>>
+    where
+        K: Ord,
+        FK: FnMut(&mut Reader<'a>, u8) -> Result<K>,
+        FV: FnMut(&mut Reader<'a>, u8) -> Result<V>,
+    {
+        if ty != MAP {
+            return Err(self.mismatch(ty, "a Map"));
+        }
+        let n = self.read_length()?;
+        let mut ret = BTreeMap::new();
+        for _ in 0..n {
+            let (ty, _) = self.read_head()?;
+            let k = key(self, ty)?;
+            let (ty, _) = self.read_head()?;
+            let v = value(self, ty)?;
+            ret.insert(k, v);
+        }
+        Ok(ret)
+    }
+
+    /// 读取结构体，field 读取 tag 对应的字段并返回 true，不认识的 tag 返回 false 由 read_struct 跳过。
+    /// required 为 require 字段的 tag，读到 StructEnd 时检查。structs 为结构体类型的成员的 tag，从小到大：
+    /// 生成的 go 代码写入结构体成员时 StructBegin 的 tag 总是 0，tag 对应不到结构体成员时，
+    /// 按位置对应到上一个字段之后的第一个结构体成员
+    pub fn read_struct<F>(&mut self, ty: u8, required: &[u8], structs: &[u8], mut field: F) -> Result<()>
+    where
+        F: FnMut(&mut Reader<'a>, u8, u8) -> Result<bool>,
+    {
+        if ty != STRUCT_BEGIN {
+            return Err(self.mismatch(ty, "a StructBegin"));
+        }
+        let mut seen = HashSet::new();
+        let mut last: Option<u8> = None;
+        loop {
+            let (ty, mut tag) = self.read_head()?;
+            if ty == STRUCT_END {
+                break;
+            }
+            if ty == STRUCT_BEGIN && (!structs.contains(&tag) || last.map_or(false, |l| tag <= l)) {
+                if let Some(&t) = structs.iter().find(|&&t| last.map_or(true, |l| t > l)) {
+                    tag = t;
+                }
+            }
+            if field(self, tag, ty)? {
+                seen.insert(tag);
+            } else {
+                self.skip(ty)?;
+            }
+            last = Some(tag);
+        }
+        for tag in required {
+            if !seen.contains(tag) {
+                return Err(Error::new(self.off, format!("require field tag {} not found", tag)));
+            }
+        }
+        Ok(())
+    }
+
+    /// 跳过一个指定类型的 data
+    pub fn skip(&mut self, ty: u8) -> Result<()> {
+        match ty {
+            ZERO_TAG | STRUCT_END => {}
+            INT1 | INT2 | INT4 | INT8 => {
+                self.read_int(ty)?;
+            }
+            FLOAT | DOUBLE => {
+                self.read_float(ty)?;
+            }
+            STRING1 | STRING4 => {
+                self.read_string(ty)?;
+            }
+            SIMPLE_LIST => {
+                let n = self.read_length()?;
+                self.next(n)?;
+            }
+            LIST | MAP => {
+                let n = self.read_length()? * if ty == MAP { 2 } else { 1 };
+                for _ in 0..n {
+                    let (ty, _) = self.read_head()?;
+                    self.skip(ty)?;
+                }
+            }
+            STRUCT_BEGIN => loop {
+                let (ty, _) = self.read_head()?;
+                if ty == STRUCT_END {
+                    break;
+                }
+                self.skip(ty)?;
+            },
+            _ => return Err(Error::new(self.off, format!("invalid type {}", ty))),
+        }
+        Ok(())
+    }
+}
+
+/// 把 jce 数据写入内存
+#[derive(Default)]
+pub struct Writer {
+    buf: Vec<u8>,
+}
+
+impl Writer {
+    pub fn new() -> Writer {
+        Writer::default()
+    }
+
+    /// 已写入的数据
+    pub fn as_bytes(&self) -> &[u8] {
+        &self.buf
+    }
+
+    pub fn into_bytes(self) -> Vec<u8> {
+        self.buf
+    }
+
+    pub fn write_head(&mut self, ty: u8, tag: u8) {
+        if tag < 15 {
+            self.buf.push(tag << 4 | ty);
+        } else {
+            self.buf.push(0xF0 | ty);
+            self.buf.push(tag);
+        }
+    }
+
+    pub fn write_length(&mut self, n: usize) {
+        self.buf.extend_from_slice(&(n as u32).to_be_bytes());
+    }
+
+    /// 写整数，按取值选择最小的编码类型
+    pub fn write_int(&mut self, v: i64, tag: u8) {
+        if v == 0 {
+            self.write_head(ZERO_TAG, tag);
+        } else if let Ok(v) = i8::try_from(v) {
+            self.write_head(INT1, tag);
+            self.buf.push(v as u8);
+        } else if let Ok(v) = i16::try_from(v) {
+            self.write_head(INT2, tag);
+            self.buf.extend_from_slice(&v.to_be_bytes());
+        } else if let Ok(v) = i32::try_from(v) {
+            self.write_head(INT4, tag);
+            self.buf.extend_from_slice(&v.to_be_bytes());
+        } else {
+            self.write_head(INT8, tag);
+            self.buf.extend_from_slice(&v.to_be_bytes());
+        }
+    }
+
+    pub fn write_float(&mut self, v: f32, tag: u8) {
+        if v == 0.0 {
+            self.write_head(ZERO_TAG, tag);
+            return;
+        }
+        self.write_head(FLOAT, tag);
+        self.buf.extend_from_slice(&v.to_be_bytes());
+    }
+
+    pub fn write_double(&mut self, v: f64, tag: u8) {
+        if v == 0.0 {
+            self.write_head(ZERO_TAG, tag);
+            return;
+        }
+        self.write_head(DOUBLE, tag);
+        self.buf.extend_from_slice(&v.to_be_bytes());
+    }
+
+    /// 写字符串，长度不超过 255 时使用 String1
+    pub fn write_string(&mut self, v: &str, tag: u8) {
+        if v.len() <= 0xFF {
+            self.write_head(STRING1, tag);
+            self.buf.push(v.len() as u8);
+        } else {
+            self.write_head(STRING4, tag);
+            self.write_length(v.len());
+        }
+        self.buf.extend_from_slice(v.as_bytes());
+    }
+
+    /// 写 vector<byte>
+    pub fn write_bytes(&mut self, v: &[u8], tag: u8) {
+        self.write_head(SIMPLE_LIST, tag);
+        self.write_length(v.len());
+        self.buf.extend_from_slice(v);
+    }
+
+    pub fn write_list<T, F>(&mut self, v: &[T], tag: u8, mut elem: F)
+    where
+        F: FnMut(&mut Writer, &T, u8),
+    {
+        self.write_head(LIST, tag);
+        self.write_length(v.len());
+        for e in v {
+            elem(self, e, 0);
+        }
+    }
+
+    pub fn write_map<K, V, FK, FV>(&mut self, v: &BTreeMap<K, V>, tag: u8, mut key: FK, mut value: FV)
+    where
+        FK: FnMut(&mut Writer, &K, u8),
+        FV: FnMut(&mut Writer, &V, u8),
+    {
+        self.write_head(MAP, tag);
+        self.write_length(v.len());
+        for (k, e) in v {
+            key(self, k, 0);
+            value(self, e, 1);
+        }
+    }
+
+    /// 写结构体，body 写入每个字段。和生成的 go 代码相同，StructBegin 的 tag 总是 0，不写入成员的 tag
+    pub fn write_struct<F: FnOnce(&mut Writer)>(&mut self, _tag: u8, body: F) {
+        self.write_head(STRUCT_BEGIN, 0);
+        body(self);
+        self.write_head(STRUCT_END, 0);
+    }
+}
+
+impl Encode for bool {
+    fn encode(&self, w: &mut Writer, tag: u8) {
+        w.write_int(*self as i64, tag);
+    }
+}
+
+impl Decode for bool {
+    fn decode(r: &mut Reader<'_>, ty: u8) -> Result<Self> {
+        Ok(r.read_int(ty)? != 0)
+    }
+}
+
+macro_rules! impl_int {
+    ($($t:ty),*) => {$(
+        impl Encode for $t {
+            fn encode(&self, w: &mut Writer, tag: u8) {
+                w.write_int(*self as i64, tag);
+            }
+        }
+
+        impl Decode for $t {
+            fn decode(r: &mut Reader<'_>, ty: u8) -> Result<Self> {
+                let start = r.offset();
+                let v = r.read_int(ty)?;
+                <$t>::try_from(v).map_err(|_| Error::new(start, format!("{} overflows {}", v, stringify!($t))))
+            }
+        }
+    )*};
+}
+
+impl_int!(i8, u8, i16, u16, i32, u32, i64);
+
+impl Encode for f32 {
+    fn encode(&self, w: &mut Writer, tag: u8) {
+        w.write_float(*self, tag);
+    }
+}
+
+impl Decode for f32 {
+    fn decode(r: &mut Reader<'_>, ty: u8) -> Result<Self> {
+        Ok(r.read_float(ty)? as f32)
+    }
+}
+
+impl Encode for f64 {
+    fn encode(&self, w: &mut Writer, tag: u8) {
+        w.write_double(*self, tag);
+    }
+}
+
+impl Decode for f64 {
+    fn decode(r: &mut Reader<'_>, ty: u8) -> Result<Self> {
+        r.read_float(ty)
+    }
+}
+
+impl Encode for String {
+    fn encode(&self, w: &mut Writer, tag: u8) {
+        w.write_string(self, tag);
+    }
+}
+
+impl Decode for String {
+    fn decode(r: &mut Reader<'_>, ty: u8) -> Result<Self> {
+        r.read_string(ty)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn round_trip() {
+        let mut w = Writer::new();
+        w.write_struct(0, |w| {
+            (-3i8).encode(w, 0);
+            70000i32.encode(w, 1);
+            "hi".to_string().encode(w, 20);
+            w.write_bytes(&[1, 2, 3], 3);
+            w.write_list(&[1i64, 1 << 40], 4, |w, e, tag| e.encode(w, tag));
+        });
+        let data = w.into_bytes();
+
+        let mut r = Reader::new(&data);
+        let (ty, _) = r.read_head().unwrap();
+        let mut got = (0i8, 0i32, String::new(), Vec::new(), Vec::new());
+        r.read_struct(ty, &[0, 1], &[], |r, tag, ty| {
+            match tag {
+                0 => got.0 = i8::decode(r, ty)?,
+                1 => got.1 = i32::decode(r, ty)?,
+                20 => got.2 = String::decode(r, ty)?,
+                3 => got.3 = r.read_bytes(ty)?,
+                4 => got.4 = r.read_list(ty, |r, ty| i64::decode(r, ty))?,
+                _ => return Ok(false),
+            }
+            Ok(true)
+        })
+        .unwrap();
+        assert_eq!(got, (-3, 70000, "hi".to_string(), vec![1, 2, 3], vec![1, 1 << 40]));
+        assert_eq!(r.offset(), data.len());
+    }
+
+    #[test]
+    fn struct_member() {
+        // 结构体成员的 StructBegin 的 tag 为 0，按位置对应到 tag 为 2 和 5 的成员
+        let mut w = Writer::new();
+        w.write_struct(0, |w| {
+            1i32.encode(w, 0);
+            w.write_struct(2, |w| 7i32.encode(w, 0));
+            w.write_struct(5, |w| 8i32.encode(w, 0));
+        });
+        let data = w.into_bytes();
+        assert_eq!(data, [0x0A, 0x00, 0x01, 0x0A, 0x00, 0x07, 0x0B, 0x0A, 0x00, 0x08, 0x0B, 0x0B]);
+
+        let mut r = Reader::new(&data);
+        let (ty, _) = r.read_head().unwrap();
+        let mut got = Vec::new();
+        r.read_struct(ty, &[0], &[2, 5], |r, tag, ty| {
+            let mut v = 0i32;
+            if tag == 0 {
+                v = i32::decode(r, ty)?;
+            } else {
+                r.read_struct(ty, &[], &[], |r, _, ty| {
+                    v = i32::decode(r, ty)?;
+                    Ok(true)
+                })?;
+            }
+            got.push((tag, v));
+            Ok(true)
+        })
+        .unwrap();
+        assert_eq!(got, [(0, 1), (2, 7), (5, 8)]);
+    }
+
+    #[test]
+    fn errors() {
+        let mut r = Reader::new(&[0x01, 0x00]);
+        assert!(u8::decode(&mut r, INT2).is_err());
+        let mut r = Reader::new(&[0x0A, 0x0B]);
+        let (ty, _) = r.read_head().unwrap();
+        assert_eq!(r.read_struct(ty, &[0], &[], |_, _, _| Ok(true)).unwrap_err().message, "require field tag 0 not found");
+    }
+}
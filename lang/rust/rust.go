@@ -0,0 +1,509 @@
+// Package rust 生成 Rust 代码：struct、enum、常量，以及它们的 Encode、Decode 实现
+//
+// 每个 jce 文件生成一个同名的 .rs 文件，另外生成 mod.rs 声明所有文件，
+// 把输出目录作为一个 module 使用（mod gen;），文件之间通过 super:: 引用。
+// 编解码依赖仓库中的运行时 crate（lang/rust/runtime），crate 名默认为 jce，
+// --lang_opt=crate=NAME 指定其他名字。
+// 类型的对应关系：
+//
+//	bool                       bool
+//	byte、short、int、long     i8、i16、i32、i64（unsigned 对应 u8、u16、u32）
+//	float、double              f32、f64
+//	string                     String
+//	vector<byte>               Vec<u8>
+//	vector<T>、array<T>        Vec<T>
+//	map<K, V>                  BTreeMap<K, V>，按 key 排序编码，输出是确定的
+//
+// optional 成员对应 Option<T>，没有默认值时为 None，None 编码时跳过
+package rust
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// ModName 声明所有文件的 module 文件名
+const ModName = "mod.rs"
+
+// rust 的关键字，成员名使用 r#name，不能作为 raw 标识符的加上 _ 后缀
+var keywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true, "continue": true, "dyn": true,
+	"else": true, "enum": true, "extern": true, "false": true, "fn": true, "for": true, "if": true,
+	"impl": true, "in": true, "let": true, "loop": true, "match": true, "mod": true, "move": true,
+	"mut": true, "pub": true, "ref": true, "return": true, "static": true, "struct": true, "trait": true,
+	"true": true, "type": true, "unsafe": true, "use": true, "where": true, "while": true, "abstract": true,
+	"become": true, "box": true, "do": true, "final": true, "macro": true, "override": true, "priv": true,
+	"try": true, "typeof": true, "unsized": true, "virtual": true, "yield": true,
+}
+
+var reserved = map[string]bool{"self": true, "Self": true, "super": true, "crate": true}
+
+// Generate 生成 set 中所有文件的代码以及 mod.rs
+func Generate(set *descriptor.Set, param string) ([]plugin.File, error) {
+	opts, err := lang.ParseParam(param)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{x: lang.NewIndex(set), crate: "jce", files: map[string]string{}}
+	for k, v := range opts {
+		switch k {
+		case "crate":
+			g.crate = v
+		default:
+			return nil, fmt.Errorf("unknown rust option %q", k)
+		}
+	}
+
+	// 类型全名所在的文件
+	seen := map[string]string{}
+	for _, f := range set.Files {
+		mod := modName(f.Name)
+		if mod == "mod" || keywords[mod] || reserved[mod] {
+			return nil, fmt.Errorf("%s: %s is not a valid rust module name", f.Name, mod)
+		}
+		if prev, ok := seen[mod]; ok {
+			return nil, fmt.Errorf("%s and %s both generate %s.rs", prev, f.Name, mod)
+		}
+		seen[mod] = f.Name
+		for _, st := range f.Structs {
+			g.files[f.Module+"."+st.Name] = mod
+		}
+		for _, en := range f.Enums {
+			g.files[f.Module+"."+en.Name] = mod
+		}
+	}
+
+	var files []plugin.File
+	mods := lang.NewPrinter("")
+	mods.P("// DO NOT EDIT IT.")
+	mods.P("// code generated by jce2go %s.", version.VERSION)
+	mods.P("")
+	for _, f := range set.Files {
+		code, err := g.genFile(f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, plugin.File{Name: modName(f.Name) + ".rs", Content: code})
+		mods.P("pub mod %s;", modName(f.Name))
+	}
+	return append(files, plugin.File{Name: ModName, Content: mods.String()}), nil
+}
+
+// test.jce 对应 module test
+func modName(jce string) string {
+	base := path.Base(strings.ReplaceAll(jce, "\\", "/"))
+	return strings.TrimSuffix(base, path.Ext(base))
+}
+
+type generator struct {
+	x     *lang.Index
+	crate string
+	files map[string]string
+
+	f   *descriptor.File
+	mod string
+	p   *lang.Printer
+}
+
+type genError string
+
+func fail(format string, args ...interface{}) {
+	panic(genError(fmt.Sprintf(format, args...)))
+}
+
+func (g *generator) genFile(f *descriptor.File) (code string, err error) {
+	// 类型错误在深层的递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if msg, ok := e.(genError); ok {
+				err = fmt.Errorf("%s: %s", f.Name, string(msg))
+				return
+			}
+			panic(e)
+		}
+	}()
+
+	g.f, g.mod, g.p = f, modName(f.Name), lang.NewPrinter("    ")
+	p := g.p
+	p.P("// DO NOT EDIT IT.")
+	p.P("// code generated by jce2go %s.", version.VERSION)
+	p.P("// source: %s", path.Base(f.Name))
+	p.P("")
+	p.P("#![allow(non_camel_case_types, non_snake_case, non_upper_case_globals, dead_code, unused_imports)]")
+	p.P("")
+	p.P("use std::collections::BTreeMap;")
+	p.P("")
+	p.P("use %s::{Decode, Encode};", g.crate)
+
+	for _, en := range f.Enums {
+		g.genEnum(en)
+	}
+	if len(f.Consts) > 0 {
+		p.P("")
+		for _, c := range f.Consts {
+			g.doc(c.Comment)
+			ty := g.typ(c.Type)
+			if c.Type.Kind == "string" {
+				ty = "&str"
+			}
+			p.P("pub const %s: %s = %s;", c.Name, ty, g.literal(c.Type, c.Value))
+		}
+	}
+	for _, st := range f.Structs {
+		g.genStruct(st)
+	}
+	return p.String(), nil
+}
+
+func (g *generator) doc(comment string) {
+	g.p.Raw(lang.Comment("/// ", comment))
+}
+
+func (g *generator) genEnum(en *descriptor.Enum) {
+	p := g.p
+	if len(en.Values) == 0 {
+		fail("enum %s has no values", en.Name)
+	}
+
+	// 重复的取值生成关联常量
+	var aliases []*descriptor.EnumValue
+	first := map[int32]*descriptor.EnumValue{}
+	def := en.Values[0]
+	p.P("")
+	g.doc(en.Comment)
+	p.P("#[derive(Clone, Copy, Debug, PartialEq, Eq, PartialOrd, Ord, Hash)]")
+	p.P("#[repr(i32)]")
+	p.P("pub enum %s {", en.Name)
+	p.In()
+	for _, v := range en.Values {
+		if _, ok := first[v.Value]; ok {
+			aliases = append(aliases, v)
+			continue
+		}
+		first[v.Value] = v
+		if v.Value == 0 {
+			def = v
+		}
+		g.doc(v.Comment)
+		p.P("%s = %d,", v.Name, v.Value)
+	}
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("impl %s {", en.Name)
+	p.In()
+	for _, v := range aliases {
+		g.doc(v.Comment)
+		p.P("pub const %s: %s = %s::%s;", v.Name, en.Name, en.Name, first[v.Value].Name)
+		p.P("")
+	}
+	p.P("/// 按取值查找枚举，未知的取值返回 None")
+	p.P("pub fn from_i32(v: i32) -> Option<%s> {", en.Name)
+	p.In()
+	p.P("match v {")
+	p.In()
+	for _, v := range en.Values {
+		if first[v.Value] == v {
+			p.P("%d => Some(%s::%s),", v.Value, en.Name, v.Name)
+		}
+	}
+	p.P("_ => None,")
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("impl Default for %s {", en.Name)
+	p.P("    fn default() -> Self {")
+	p.P("        %s::%s", en.Name, def.Name)
+	p.P("    }")
+	p.P("}")
+
+	p.P("")
+	p.P("impl Encode for %s {", en.Name)
+	p.P("    fn encode(&self, w: &mut %s::Writer, tag: u8) {", g.crate)
+	p.P("        w.write_int(*self as i64, tag);")
+	p.P("    }")
+	p.P("}")
+
+	p.P("")
+	p.P("impl Decode for %s {", en.Name)
+	p.P("    fn decode(r: &mut %s::Reader<'_>, ty: u8) -> %s::Result<Self> {", g.crate, g.crate)
+	p.P("        let start = r.offset();")
+	p.P("        let v = i32::decode(r, ty)?;")
+	p.P("        %s::from_i32(v).ok_or_else(|| %s::Error::new(start, format!(\"unknown %s value {}\", v)))", en.Name, g.crate, en.Name)
+	p.P("    }")
+	p.P("}")
+}
+
+// 成员名，和关键字冲突时使用 raw 标识符
+func field(name string) string {
+	if reserved[name] {
+		return name + "_"
+	}
+	if keywords[name] {
+		return "r#" + name
+	}
+	return name
+}
+
+func (g *generator) genStruct(st *descriptor.Struct) {
+	p := g.p
+	p.P("")
+	g.doc(st.Comment)
+	p.P("#[derive(Clone, Debug, PartialEq)]")
+	p.P("pub struct %s {", st.Name)
+	p.In()
+	for _, mb := range st.Members {
+		g.doc(mb.Comment)
+		ty := g.typ(mb.Type)
+		if !mb.Required {
+			ty = "Option<" + ty + ">"
+		}
+		p.P("pub %s: %s,", field(mb.Name), ty)
+	}
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("impl Default for %s {", st.Name)
+	p.In()
+	p.P("fn default() -> Self {")
+	p.In()
+	p.P("%s {", st.Name)
+	p.In()
+	for _, mb := range st.Members {
+		p.P("%s: %s,", field(mb.Name), g.defaultValue(mb))
+	}
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+
+	// 写
+	p.P("")
+	p.P("impl Encode for %s {", st.Name)
+	p.In()
+	p.P("fn encode(&self, w: &mut %s::Writer, tag: u8) {", g.crate)
+	p.In()
+	if len(st.Members) == 0 {
+		p.P("w.write_struct(tag, |_| {});")
+	} else {
+		p.P("w.write_struct(tag, |w| {")
+		p.In()
+		for _, mb := range st.Members {
+			tag := strconv.Itoa(int(mb.Tag))
+			if mb.Required {
+				p.P("%s;", g.writeExpr(mb.Type, "&self."+field(mb.Name), tag))
+				continue
+			}
+			p.P("if let Some(v) = &self.%s {", field(mb.Name))
+			p.P("    %s;", g.writeExpr(mb.Type, "v", tag))
+			p.P("}")
+		}
+		p.Out()
+		p.P("});")
+	}
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+
+	// 读
+	var required []string
+	for _, mb := range st.Members {
+		if mb.Required {
+			required = append(required, strconv.Itoa(int(mb.Tag)))
+		}
+	}
+	p.P("")
+	p.P("impl Decode for %s {", st.Name)
+	p.In()
+	p.P("fn decode(r: &mut %s::Reader<'_>, ty: u8) -> %s::Result<Self> {", g.crate, g.crate)
+	p.In()
+	if len(st.Members) == 0 {
+		p.P("r.read_struct(ty, &[], &[], |_, _, _| Ok(false))?;")
+		p.P("Ok(%s::default())", st.Name)
+	} else {
+		p.P("let mut v = %s::default();", st.Name)
+		p.P("r.read_struct(ty, &[%s], &[%s], |r, tag, ty| {", strings.Join(required, ", "), strings.Join(lang.StructTags(st), ", "))
+		p.In()
+		p.P("match tag {")
+		p.In()
+		for _, mb := range st.Members {
+			if mb.Required {
+				p.P("%d => v.%s = %s?,", mb.Tag, field(mb.Name), g.readExpr(mb.Type))
+			} else {
+				p.P("%d => v.%s = Some(%s?),", mb.Tag, field(mb.Name), g.readExpr(mb.Type))
+			}
+		}
+		p.P("_ => return Ok(false),")
+		p.Out()
+		p.P("}")
+		p.P("Ok(true)")
+		p.Out()
+		p.P("})?;")
+		p.P("Ok(v)")
+	}
+	p.Out()
+	p.P("}")
+	p.Out()
+	p.P("}")
+}
+
+// 写字段的表达式，v 为值的引用
+func (g *generator) writeExpr(t *descriptor.Type, v, tag string) string {
+	switch {
+	case lang.IsBytes(t):
+		return fmt.Sprintf("w.write_bytes(%s, %s)", v, tag)
+	case t.Kind == "vector" || t.Kind == "array":
+		return fmt.Sprintf("w.write_list(%s, %s, |w, e, tag| %s)", v, tag, g.writeExpr(t.Params[0], "e", "tag"))
+	case t.Kind == "map":
+		return fmt.Sprintf("w.write_map(%s, %s, |w, k, tag| %s, |w, e, tag| %s)", v, tag,
+			g.writeExpr(t.Params[0], "k", "tag"), g.writeExpr(t.Params[1], "e", "tag"))
+	}
+	return fmt.Sprintf("(%s).encode(w, %s)", v, tag)
+}
+
+// 读字段的表达式，类型为 Result<T>，r、ty 为 reader 和字段的类型
+func (g *generator) readExpr(t *descriptor.Type) string {
+	switch {
+	case lang.IsBytes(t):
+		return "r.read_bytes(ty)"
+	case t.Kind == "vector" || t.Kind == "array":
+		return fmt.Sprintf("r.read_list(ty, |r, ty| %s)", g.readExpr(t.Params[0]))
+	case t.Kind == "map":
+		return fmt.Sprintf("r.read_map(ty, |r, ty| %s, |r, ty| %s)", g.readExpr(t.Params[0]), g.readExpr(t.Params[1]))
+	}
+	return fmt.Sprintf("<%s>::decode(r, ty)", g.typ(t))
+}
+
+// 类型名，其他文件的类型通过 super:: 引用
+func (g *generator) ref(name string) string {
+	mod, ok := g.files[name]
+	if !ok {
+		fail("type %s not found", name)
+	}
+	_, short := lang.SplitName(name)
+	if mod == g.mod {
+		return short
+	}
+	return "super::" + mod + "::" + short
+}
+
+func (g *generator) typ(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "bool"
+	case "byte", "short", "int", "long":
+		bits := map[string]string{"byte": "8", "short": "16", "int": "32", "long": "64"}[t.Kind]
+		if t.IsUnsigned {
+			return "u" + bits
+		}
+		return "i" + bits
+	case "float":
+		return "f32"
+	case "double":
+		return "f64"
+	case "string":
+		return "String"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "Vec<u8>"
+		}
+		return "Vec<" + g.typ(t.Params[0]) + ">"
+	case "map":
+		if !ordered(t.Params[0]) {
+			fail("map key %s is not supported, BTreeMap needs a totally ordered key", t.Params[0].Kind)
+		}
+		return "BTreeMap<" + g.typ(t.Params[0]) + ", " + g.typ(t.Params[1]) + ">"
+	case "struct", "enum":
+		return g.ref(t.Name)
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 类型是否实现了 Ord，可以作为 BTreeMap 的 key
+func ordered(t *descriptor.Type) bool {
+	switch t.Kind {
+	case "bool", "byte", "short", "int", "long", "string", "enum":
+		return true
+	case "vector", "array":
+		return ordered(t.Params[0])
+	case "map":
+		return ordered(t.Params[0]) && ordered(t.Params[1])
+	}
+	return false
+}
+
+// 成员的默认值，optional 成员有默认值时为 Some，否则为 None
+func (g *generator) defaultValue(mb *descriptor.Member) string {
+	if !mb.Required {
+		if mb.Default == "" {
+			return "None"
+		}
+		return "Some(" + g.value(mb.Type, mb.Default) + ")"
+	}
+	if mb.Default != "" {
+		return g.value(mb.Type, mb.Default)
+	}
+	switch mb.Type.Kind {
+	case "bool":
+		return "false"
+	case "byte", "short", "int", "long":
+		return "0"
+	case "float", "double":
+		return "0.0"
+	case "string":
+		return "String::new()"
+	case "vector", "array":
+		return "Vec::new()"
+	case "map":
+		return "BTreeMap::new()"
+	}
+	return g.typ(mb.Type) + "::default()"
+}
+
+// 默认值的表达式，字符串需要转换为 String
+func (g *generator) value(t *descriptor.Type, v string) string {
+	if t.Kind == "string" {
+		return g.literal(t, v) + ".to_string()"
+	}
+	return g.literal(t, v)
+}
+
+// 源码中的常量、默认值转换为 rust 的字面量
+func (g *generator) literal(t *descriptor.Type, v string) string {
+	switch t.Kind {
+	case "float", "double":
+		v = strings.TrimSuffix(v, "f")
+		if strings.ContainsAny(v, "xX") {
+			return fmt.Sprintf("%s as %s", v, g.typ(t))
+		}
+		if !strings.ContainsAny(v, ".eE") {
+			v += ".0"
+		}
+		return v
+	case "enum":
+		ev, err := g.x.EnumDefault(t, v)
+		if err != nil {
+			fail("%v", err)
+		}
+		return g.ref(t.Name) + "::" + ev.Name
+	}
+	return v
+}
@@ -0,0 +1,114 @@
+package rust
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang/langtest"
+)
+
+func TestGenerate(t *testing.T) {
+	files := langtest.Generate(t, Generate, "")
+	var code string
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+		if f.Name == "test.rs" {
+			code = f.Content
+		}
+	}
+	if strings.Join(names, ",") != "base.rs,test.rs,mod.rs" {
+		t.Fatalf("unexpected files %v", names)
+	}
+	if !strings.Contains(files[2].Content, "pub mod base;\npub mod test;\n") {
+		t.Errorf("unexpected mod.rs:\n%s", files[2].Content)
+	}
+	for _, s := range []string{
+		"use jce::{Decode, Encode};",
+		"pub const VERSION: i16 = 3;",
+		`pub const NAME: &str = "test";`,
+		"pub enum Color {",
+		"    Red = 1,",
+		"pub b: i8,",
+		"pub c: Option<Color>,",
+		"pub raw: Option<Vec<u8>>,",
+		"pub items: Option<Vec<super::base::Item>>,",
+		"pub byId: Option<BTreeMap<i32, super::base::Item>>,",
+		"pub default: Option<String>,",
+		"c: Some(Color::Green),",
+		"f: Some(1.0),",
+		"w.write_map(v, 5, |w, k, tag| (k).encode(w, tag), |w, e, tag| (e).encode(w, tag));",
+		"11 => v.nested = Some(r.read_list(ty, |r, ty| r.read_list(ty, |r, ty| <String>::decode(r, ty)))?),",
+		"r.read_struct(ty, &[0, 1], &[13], |r, tag, ty| {",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+
+	if _, err := Generate(&descriptor.Set{}, "edition=2021"); err == nil {
+		t.Fatal("expect error for unknown option")
+	}
+}
+
+// rust 解码 fixtures.hex 中的数据后重新编码，结果和原来的数据相同
+func TestRoundTrip(t *testing.T) {
+	cargo, err := exec.LookPath("cargo")
+	if err != nil {
+		t.Skip("cargo not found")
+	}
+	runtime, err := filepath.Abs("runtime")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := langtest.Generate(t, Generate, "")
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "src", "gen"), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	langtest.WriteFiles(t, filepath.Join(dir, "src", "gen"), files, nil)
+	manifest := `[package]
+name = "roundtrip"
+version = "0.1.0"
+edition = "2018"
+
+[dependencies]
+jce = { path = ` + strconv.Quote(runtime) + ` }
+`
+	main := `mod gen;
+
+fn main() {
+    for arg in std::env::args().skip(1) {
+        let data: Vec<u8> = (0..arg.len()).step_by(2).map(|i| u8::from_str_radix(&arg[i..i + 2], 16).unwrap()).collect();
+        let v: gen::test::Packet = jce::from_bytes(&data).unwrap();
+        let out: String = jce::to_bytes(&v).iter().map(|b| format!("{:02x}", b)).collect();
+        println!("{}", out);
+    }
+}
+`
+	for name, content := range map[string]string{"Cargo.toml": manifest, "src/main.rs": main} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	build := exec.Command(cargo, "build", "--offline", "--quiet")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "CARGO_TARGET_DIR="+filepath.Join(dir, "target"), "RUSTFLAGS=-D warnings")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+
+	fixtures := langtest.Fixtures(t)
+	out, err := exec.Command(filepath.Join(dir, "target", "debug", "roundtrip"), fixtures...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	langtest.Check(t, out, fixtures)
+}
@@ -0,0 +1,445 @@
+// DO NOT EDIT IT.
+// jce 编码的 TypeScript 运行时，由 jce2go --lang=ts 生成。
+//
+// 每个字段由 head + data 组成，head 第一个字节高 4 位是 tag、低 4 位是 type，
+// tag >= 15 时高 4 位为 15，第二个字节存 tag。整数、长度均为大端。
+
+export const INT1 = 0;
+export const INT2 = 1;
+export const INT4 = 2;
+export const INT8 = 3;
+export const FLOAT = 4;
+export const DOUBLE = 5;
+export const STRING1 = 6;
+export const STRING4 = 7;
+export const MAP = 8;
+export const LIST = 9;
+export const STRUCT_BEGIN = 10;
+export const STRUCT_END = 11;
+export const ZERO_TAG = 12;
+export const SIMPLE_LIST = 13;
+
+const TYPE_NAMES = [
+  "Int1", "Int2", "Int4", "Int8", "Float", "Double", "String1", "String4",
+  "Map", "List", "StructBegin", "StructEnd", "ZeroTag", "SimpleList",
+];
+
+function typeName(ty: number): string {
+  return TYPE_NAMES[ty] ?? `Type(${ty})`;
+}
+
+const encoder = new TextEncoder();
+const decoder = new TextDecoder();
+
+/** 数据格式错误，offset 为出错位置的字节偏移 */
+export class JceError extends Error {
+  readonly offset: number;
+
+  constructor(message: string, offset: number) {
+    super(`offset ${offset}: ${message}`);
+    this.name = "JceError";
+    this.offset = offset;
+  }
+}
+
+/** 从字节数组中读取 jce 数据 */
+export class Reader {
+  private readonly buf: Uint8Array;
+  private readonly view: DataView;
+  private off = 0;
+
+  constructor(buf: Uint8Array) {
+    this.buf = buf;
+    this.view = new DataView(buf.buffer, buf.byteOffset, buf.byteLength);
+  }
+
+  /** 当前读取位置 */
+  get offset(): number {
+    return this.off;
+  }
+
+  private next(n: number): number {
+    if (this.buf.length - this.off < n) {
+      throw new JceError(`unexpected end of data, need ${n} bytes, have ${this.buf.length - this.off}`, this.off);
+    }
+    const start = this.off;
+    this.off += n;
+    return start;
+  }
+
+  private mismatch(ty: number, expect: string): JceError {
+    return new JceError(`type ${typeName(ty)} is not ${expect}`, this.off);
+  }
+
+  /** 读取 head，返回 [type, tag] */
+  readHead(): [number, number] {
+    const start = this.off;
+    const b = this.buf[this.next(1)];
+    const ty = b & 0x0f;
+    let tag = b >> 4;
+    if (tag === 15) {
+      tag = this.buf[this.next(1)];
+    }
+    if (ty > SIMPLE_LIST) {
+      throw new JceError(`invalid type ${ty}`, start);
+    }
+    return [ty, tag];
+  }
+
+  private readRawInt(ty: number): bigint | number {
+    switch (ty) {
+      case ZERO_TAG:
+        return 0;
+      case INT1:
+        return this.view.getInt8(this.next(1));
+      case INT2:
+        return this.view.getInt16(this.next(2));
+      case INT4:
+        return this.view.getInt32(this.next(4));
+      case INT8:
+        return this.view.getBigInt64(this.next(8));
+    }
+    throw this.mismatch(ty, "an integer");
+  }
+
+  /** 读取不超过 32 位的整数，超出 [min, max] 时报错 */
+  readInt(ty: number, min: number, max: number): number {
+    const start = this.off;
+    const raw = this.readRawInt(ty);
+    const v = Number(raw);
+    if (v < min || v > max) {
+      throw new JceError(`${raw} overflows [${min}, ${max}]`, start);
+    }
+    return v;
+  }
+
+  /** 读取 long */
+  readLong(ty: number, unsigned = false): bigint {
+    const v = BigInt(this.readRawInt(ty));
+    return unsigned ? BigInt.asUintN(64, v) : v;
+  }
+
+  /** 读取 bool，按整数编码，非 0 为 true */
+  readBool(ty: number): boolean {
+    return this.readRawInt(ty) != 0;
+  }
+
+  /** 读取 float、double */
+  readFloat(ty: number): number {
+    switch (ty) {
+      case ZERO_TAG:
+        return 0;
+      case FLOAT:
+        return this.view.getFloat32(this.next(4));
+      case DOUBLE:
+        return this.view.getFloat64(this.next(8));
+    }
+    throw this.mismatch(ty, "a float");
+  }
+
+  /** 读取 4B 长度，长度超过剩余数据时报错 */
+  readLength(): number {
+    const start = this.next(4);
+    const n = this.view.getUint32(start);
+    if (n > this.buf.length - this.off) {
+      throw new JceError(`length ${n} exceeds remaining ${this.buf.length - this.off} bytes`, start);
+    }
+    return n;
+  }
+
+  /** 读取字符串 */
+  readString(ty: number): string {
+    let n: number;
+    if (ty === STRING1) {
+      n = this.buf[this.next(1)];
+    } else if (ty === STRING4) {
+      n = this.readLength();
+    } else {
+      throw this.mismatch(ty, "a string");
+    }
+    const start = this.next(n);
+    return decoder.decode(this.buf.subarray(start, start + n));
+  }
+
+  /** 读取 vector<byte>，也兼容按 List 编码的数据 */
+  readBytes(ty: number): Uint8Array {
+    if (ty === LIST) {
+      return Uint8Array.from(this.readList(ty, (r, ty) => r.readInt(ty, -128, 255) & 0xff));
+    }
+    if (ty !== SIMPLE_LIST) {
+      throw this.mismatch(ty, "a SimpleList");
+    }
+    const n = this.readLength();
+    const start = this.next(n);
+    return this.buf.slice(start, start + n);
+  }
+
+  /** 读取 vector，每个元素都是 tag 为 0 的字段 */
+  readList<T>(ty: number, elem: (r: Reader, ty: number) => T): T[] {
+    if (ty !== LIST) {
+      throw this.mismatch(ty, "a List");
+    }
+    const n = this.readLength();
+    const ret: T[] = [];
+    for (let i = 0; i < n; i++) {
+      ret.push(elem(this, this.readHead()[0]));
+    }
+    return ret;
+  }
+
+  /** 读取 map，key 的 tag 为 0，value 的 tag 为 1 */
+  readMap<K, V>(ty: number, key: (r: Reader, ty: number) => K, value: (r: Reader, ty: number) => V): Map<K, V> {
+    if (ty !== MAP) {
+      throw this.mismatch(ty, "a Map");
+    }
+    const n = this.readLength();
+    const ret = new Map<K, V>();
+    for (let i = 0; i < n; i++) {
+      const k = key(this, this.readHead()[0]);
+      ret.set(k, value(this, this.readHead()[0]));
+    }
+    return ret;
+  }
+
+  /**
+   * 读取结构体，每个字段调用一次 field，field 返回 false 时跳过该字段。
+   * required 为 require 字段的 tag，没有读到时报错。structs 为结构体类型的成员的 tag，从小到大：
+   * 生成的 go 代码写入结构体成员时 StructBegin 的 tag 总是 0，tag 对应不到结构体成员时，
+   * 按位置对应到上一个字段之后的第一个结构体成员
+   */
+  readStruct(ty: number, required: number[], structs: number[], field: (tag: number, ty: number) => boolean): void {
+    if (ty !== STRUCT_BEGIN) {
+      throw this.mismatch(ty, "a StructBegin");
+    }
+    const seen = new Set<number>();
+    let last = -1;
+    for (;;) {
+      const head = this.readHead();
+      const ty = head[0];
+      let tag = head[1];
+      if (ty === STRUCT_END) {
+        break;
+      }
+      if (ty === STRUCT_BEGIN && (!structs.includes(tag) || tag <= last)) {
+        tag = structs.find((t) => t > last) ?? tag;
+      }
+      if (field(tag, ty)) {
+        seen.add(tag);
+      } else {
+        this.skip(ty);
+      }
+      last = tag;
+    }
+    for (const tag of required) {
+      if (!seen.has(tag)) {
+        throw new JceError(`require field tag ${tag} not found`, this.off);
+      }
+    }
+  }
+
+  /** 跳过一个指定类型的 data */
+  skip(ty: number): void {
+    switch (ty) {
+      case ZERO_TAG:
+      case STRUCT_END:
+        return;
+      case INT1:
+      case INT2:
+      case INT4:
+      case INT8:
+        this.readRawInt(ty);
+        return;
+      case FLOAT:
+      case DOUBLE:
+        this.readFloat(ty);
+        return;
+      case STRING1:
+      case STRING4:
+        this.readString(ty);
+        return;
+      case SIMPLE_LIST:
+        this.next(this.readLength());
+        return;
+      case LIST:
+      case MAP: {
+        let n = this.readLength();
+        if (ty === MAP) {
+          n *= 2;
+        }
+        for (let i = 0; i < n; i++) {
+          this.skip(this.readHead()[0]);
+        }
+        return;
+      }
+      case STRUCT_BEGIN:
+        for (;;) {
+          const [ty] = this.readHead();
+          if (ty === STRUCT_END) {
+            return;
+          }
+          this.skip(ty);
+        }
+    }
+    throw new JceError(`invalid type ${ty}`, this.off);
+  }
+}
+
+/** 把 jce 数据写入内存 */
+export class Writer {
+  private buf = new Uint8Array(64);
+  private view = new DataView(this.buf.buffer);
+  private len = 0;
+
+  /** 已写入的数据 */
+  bytes(): Uint8Array {
+    return this.buf.slice(0, this.len);
+  }
+
+  // 预留 n 个字节，返回写入位置。grow 可能替换 buf、view，需要先调用 grow 再使用它们
+  private grow(n: number): number {
+    if (this.len + n > this.buf.length) {
+      const buf = new Uint8Array(Math.max(this.buf.length * 2, this.len + n));
+      buf.set(this.buf.subarray(0, this.len));
+      this.buf = buf;
+      this.view = new DataView(buf.buffer);
+    }
+    const start = this.len;
+    this.len += n;
+    return start;
+  }
+
+  private writeRaw(b: Uint8Array): void {
+    const at = this.grow(b.length);
+    this.buf.set(b, at);
+  }
+
+  /** 写 head */
+  writeHead(ty: number, tag: number): void {
+    if (tag < 15) {
+      const at = this.grow(1);
+      this.buf[at] = (tag << 4) | ty;
+      return;
+    }
+    const start = this.grow(2);
+    this.buf[start] = 0xf0 | ty;
+    this.buf[start + 1] = tag;
+  }
+
+  /** 写 4B 长度 */
+  writeLength(n: number): void {
+    const at = this.grow(4);
+    this.view.setUint32(at, n);
+  }
+
+  /** 写整数，按取值选择最小的编码类型 */
+  writeInt(v: number, tag: number): void {
+    if (!Number.isSafeInteger(v)) {
+      throw new RangeError(`${v} is not an integer`);
+    }
+    if (v === 0) {
+      this.writeHead(ZERO_TAG, tag);
+    } else if (v >= -0x80 && v <= 0x7f) {
+      this.writeHead(INT1, tag);
+      const at = this.grow(1);
+      this.view.setInt8(at, v);
+    } else if (v >= -0x8000 && v <= 0x7fff) {
+      this.writeHead(INT2, tag);
+      const at = this.grow(2);
+      this.view.setInt16(at, v);
+    } else if (v >= -0x80000000 && v <= 0x7fffffff) {
+      this.writeHead(INT4, tag);
+      const at = this.grow(4);
+      this.view.setInt32(at, v);
+    } else {
+      this.writeHead(INT8, tag);
+      const at = this.grow(8);
+      this.view.setBigInt64(at, BigInt(v));
+    }
+  }
+
+  /** 写 long，unsigned long 超过 int64 的部分按补码写入 */
+  writeLong(v: bigint, tag: number): void {
+    v = BigInt.asIntN(64, v);
+    if (v >= -0x80000000n && v <= 0x7fffffffn) {
+      this.writeInt(Number(v), tag);
+      return;
+    }
+    this.writeHead(INT8, tag);
+    const at = this.grow(8);
+    this.view.setBigInt64(at, v);
+  }
+
+  /** 写 bool，按整数 0、1 编码 */
+  writeBool(v: boolean, tag: number): void {
+    this.writeInt(v ? 1 : 0, tag);
+  }
+
+  /** 写 float */
+  writeFloat(v: number, tag: number): void {
+    if (v === 0) {
+      this.writeHead(ZERO_TAG, tag);
+      return;
+    }
+    this.writeHead(FLOAT, tag);
+    const at = this.grow(4);
+    this.view.setFloat32(at, v);
+  }
+
+  /** 写 double */
+  writeDouble(v: number, tag: number): void {
+    if (v === 0) {
+      this.writeHead(ZERO_TAG, tag);
+      return;
+    }
+    this.writeHead(DOUBLE, tag);
+    const at = this.grow(8);
+    this.view.setFloat64(at, v);
+  }
+
+  /** 写字符串，长度不超过 255 时使用 String1 */
+  writeString(v: string, tag: number): void {
+    const b = encoder.encode(v);
+    if (b.length <= 0xff) {
+      this.writeHead(STRING1, tag);
+      const at = this.grow(1);
+      this.buf[at] = b.length;
+    } else {
+      this.writeHead(STRING4, tag);
+      this.writeLength(b.length);
+    }
+    this.writeRaw(b);
+  }
+
+  /** 写 vector<byte> */
+  writeBytes(v: Uint8Array, tag: number): void {
+    this.writeHead(SIMPLE_LIST, tag);
+    this.writeLength(v.length);
+    this.writeRaw(v);
+  }
+
+  /** 写 vector */
+  writeList<T>(v: T[], tag: number, elem: (w: Writer, v: T, tag: number) => void): void {
+    this.writeHead(LIST, tag);
+    this.writeLength(v.length);
+    for (const e of v) {
+      elem(this, e, 0);
+    }
+  }
+
+  /** 写 map */
+  writeMap<K, V>(v: Map<K, V>, tag: number, key: (w: Writer, v: K, tag: number) => void, value: (w: Writer, v: V, tag: number) => void): void {
+    this.writeHead(MAP, tag);
+    this.writeLength(v.size);
+    for (const [k, e] of v) {
+      key(this, k, 0);
+      value(this, e, 1);
+    }
+  }
+
+  /** 写结构体，body 写入各个字段。和生成的 go 代码相同，StructBegin 的 tag 总是 0，不写入成员的 tag */
+  writeStruct(tag: number, body: () => void): void {
+    this.writeHead(STRUCT_BEGIN, 0);
+    body();
+    this.writeHead(STRUCT_END, 0);
+  }
+}
@@ -0,0 +1,438 @@
+// Package ts 生成 TypeScript 代码：interface、enum、常量，以及每个 struct 的 encode、decode 函数
+//
+// 每个 jce 文件生成一个同名的 .ts 文件，编解码依赖同目录下的运行时 jce.ts。
+// 类型的对应关系：
+//
+//	bool                      boolean
+//	byte、short、int、float、double  number
+//	long                      bigint
+//	string                    string
+//	vector<byte>              Uint8Array
+//	vector<T>、array<T>        T[]
+//	map<K, V>                 Map<K, V>
+//
+// optional 成员在 interface 中可以省略，编码时使用默认值，解码时总是会赋值
+package ts
+
+import (
+	_ "embed"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+//go:embed runtime/jce.ts
+var runtime string
+
+// RuntimeName 运行时的文件名
+const RuntimeName = "jce.ts"
+
+// Generate 生成 set 中所有文件的代码以及运行时
+func Generate(set *descriptor.Set, param string) ([]plugin.File, error) {
+	g := &generator{x: lang.NewIndex(set)}
+	files := []plugin.File{{Name: RuntimeName, Content: runtime}}
+	seen := map[string]string{}
+	for _, f := range set.Files {
+		name := fileName(f.Name)
+		if prev, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s and %s both generate %s", prev, f.Name, name)
+		}
+		seen[name] = f.Name
+
+		code, err := g.genFile(f)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, plugin.File{Name: name, Content: code})
+	}
+	return files, nil
+}
+
+// test.jce 生成 test.ts
+func fileName(jce string) string {
+	base := path.Base(strings.ReplaceAll(jce, "\\", "/"))
+	return strings.TrimSuffix(base, path.Ext(base)) + ".ts"
+}
+
+type generator struct {
+	x *lang.Index
+	f *descriptor.File
+	p *lang.Printer
+}
+
+func (g *generator) genFile(f *descriptor.File) (code string, err error) {
+	// 类型错误在深层的递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if msg, ok := e.(genError); ok {
+				err = fmt.Errorf("%s: %s", f.Name, string(msg))
+				return
+			}
+			panic(e)
+		}
+	}()
+
+	g.f, g.p = f, lang.NewPrinter("  ")
+	p := g.p
+	p.P("// DO NOT EDIT IT.")
+	p.P("// code generated by jce2go %s.", version.VERSION)
+	p.P("// source: %s", path.Base(f.Name))
+	p.P("")
+	p.P(`import * as jce from "./jce";`)
+	for _, m := range lang.Deps(f) {
+		dep := g.x.Module(m)
+		if dep == nil {
+			return "", fmt.Errorf("%s: module %s not found", f.Name, m)
+		}
+		p.P(`import * as %s from "./%s";`, m, strings.TrimSuffix(fileName(dep.Name), ".ts"))
+	}
+
+	for _, en := range f.Enums {
+		g.genEnum(en)
+	}
+	if len(f.Consts) > 0 {
+		p.P("")
+		for _, c := range f.Consts {
+			g.doc(c.Comment)
+			p.P("export const %s: %s = %s;", c.Name, g.typ(c.Type), g.literal(c.Type, c.Value))
+		}
+	}
+	for _, st := range f.Structs {
+		g.genStruct(st)
+	}
+	return p.String(), nil
+}
+
+type genError string
+
+func fail(format string, args ...interface{}) {
+	panic(genError(fmt.Sprintf(format, args...)))
+}
+
+// 输出 jsdoc 注释
+func (g *generator) doc(comment string) {
+	if comment == "" {
+		return
+	}
+	lines := strings.Split(comment, "\n")
+	if len(lines) == 1 {
+		g.p.P("/** %s */", strings.ReplaceAll(comment, "*/", "* /"))
+		return
+	}
+	g.p.P("/**")
+	for _, line := range lines {
+		g.p.P(" * %s", strings.ReplaceAll(line, "*/", "* /"))
+	}
+	g.p.P(" */")
+}
+
+func (g *generator) genEnum(en *descriptor.Enum) {
+	p := g.p
+	p.P("")
+	g.doc(en.Comment)
+	p.P("export enum %s {", lang.Upper(en.Name))
+	p.In()
+	for _, v := range en.Values {
+		g.doc(v.Comment)
+		p.P("%s = %d,", v.Name, v.Value)
+	}
+	p.Out()
+	p.P("}")
+}
+
+func (g *generator) genStruct(st *descriptor.Struct) {
+	p := g.p
+	name := lang.Upper(st.Name)
+
+	p.P("")
+	g.doc(st.Comment)
+	p.P("export interface %s {", name)
+	p.In()
+	for _, mb := range st.Members {
+		g.doc(mb.Comment)
+		opt := "?"
+		if mb.Required {
+			opt = ""
+		}
+		p.P("%s%s: %s;", mb.Name, opt, g.typ(mb.Type))
+	}
+	p.Out()
+	p.P("}")
+
+	// 默认值
+	p.P("")
+	p.P("/** 所有成员都为默认值的 %s */", name)
+	p.P("export function new%s(): %s {", name, name)
+	p.In()
+	p.P("return {")
+	p.In()
+	for _, mb := range st.Members {
+		p.P("%s: %s,", mb.Name, g.defaultValue(mb))
+	}
+	p.Out()
+	p.P("};")
+	p.Out()
+	p.P("}")
+
+	// 写
+	p.P("")
+	p.P("export function write%s(w: jce.Writer, v: %s, tag: number): void {", name, name)
+	p.In()
+	p.P("w.writeStruct(tag, () => {")
+	p.In()
+	for _, mb := range st.Members {
+		value := "v." + mb.Name
+		if !mb.Required {
+			value += " ?? " + g.defaultValue(mb)
+		}
+		p.P("%s;", g.writeExpr(mb.Type, value, strconv.Itoa(int(mb.Tag))))
+	}
+	p.Out()
+	p.P("});")
+	p.Out()
+	p.P("}")
+
+	// 读
+	var required []string
+	for _, mb := range st.Members {
+		if mb.Required {
+			required = append(required, strconv.Itoa(int(mb.Tag)))
+		}
+	}
+	p.P("")
+	p.P("export function read%s(r: jce.Reader, ty: number): %s {", name, name)
+	p.In()
+	p.P("const v = new%s();", name)
+	p.P("r.readStruct(ty, [%s], [%s], (tag, ty) => {", strings.Join(required, ", "), strings.Join(lang.StructTags(st), ", "))
+	p.In()
+	p.P("switch (tag) {")
+	p.In()
+	for _, mb := range st.Members {
+		p.P("case %d:", mb.Tag)
+		p.In()
+		p.P("v.%s = %s;", mb.Name, g.readExpr(mb.Type))
+		p.P("return true;")
+		p.Out()
+	}
+	p.Out()
+	p.P("}")
+	p.P("return false;")
+	p.Out()
+	p.P("});")
+	p.P("return v;")
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("/** 把 %s 编码为 jce 数据 */", name)
+	p.P("export function encode%s(v: %s): Uint8Array {", name, name)
+	p.In()
+	p.P("const w = new jce.Writer();")
+	p.P("write%s(w, v, 0);", name)
+	p.P("return w.bytes();")
+	p.Out()
+	p.P("}")
+
+	p.P("")
+	p.P("/** 从 jce 数据解码 %s */", name)
+	p.P("export function decode%s(data: Uint8Array): %s {", name, name)
+	p.In()
+	p.P("const r = new jce.Reader(data);")
+	p.P("return read%s(r, r.readHead()[0]);", name)
+	p.Out()
+	p.P("}")
+}
+
+// struct、enum 的类型名，其他 module 的类型加上 module 前缀
+func (g *generator) ref(name string) string {
+	m, short := lang.SplitName(name)
+	if m == g.f.Module {
+		return lang.Upper(short)
+	}
+	return m + "." + lang.Upper(short)
+}
+
+func (g *generator) typ(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "boolean"
+	case "byte", "short", "int", "float", "double":
+		return "number"
+	case "long":
+		return "bigint"
+	case "string":
+		return "string"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "Uint8Array"
+		}
+		elem := g.typ(t.Params[0])
+		if strings.Contains(elem, "<") || strings.HasSuffix(elem, "]") {
+			return "Array<" + elem + ">"
+		}
+		return elem + "[]"
+	case "map":
+		return "Map<" + g.typ(t.Params[0]) + ", " + g.typ(t.Params[1]) + ">"
+	case "struct", "enum":
+		return g.ref(t.Name)
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 整数类型的取值范围
+func intRange(t *descriptor.Type) (string, string) {
+	switch t.Kind {
+	case "byte":
+		if t.IsUnsigned {
+			return "0", "255"
+		}
+		return "-128", "127"
+	case "short":
+		if t.IsUnsigned {
+			return "0", "65535"
+		}
+		return "-32768", "32767"
+	}
+	if t.IsUnsigned {
+		return "0", "4294967295"
+	}
+	return "-2147483648", "2147483647"
+}
+
+// 读取一个值的表达式，head 已经读到变量 ty 中
+func (g *generator) readExpr(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "r.readBool(ty)"
+	case "byte", "short", "int":
+		min, max := intRange(t)
+		return "r.readInt(ty, " + min + ", " + max + ")"
+	case "long":
+		if t.IsUnsigned {
+			return "r.readLong(ty, true)"
+		}
+		return "r.readLong(ty)"
+	case "float", "double":
+		return "r.readFloat(ty)"
+	case "string":
+		return "r.readString(ty)"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "r.readBytes(ty)"
+		}
+		return "r.readList(ty, (r, ty) => " + g.readExpr(t.Params[0]) + ")"
+	case "map":
+		return "r.readMap(ty, (r, ty) => " + g.readExpr(t.Params[0]) + ", (r, ty) => " + g.readExpr(t.Params[1]) + ")"
+	case "enum":
+		return "r.readInt(ty, -2147483648, 2147483647) as " + g.ref(t.Name)
+	case "struct":
+		m, short := lang.SplitName(t.Name)
+		fn := "read" + lang.Upper(short)
+		if m != g.f.Module {
+			fn = m + "." + fn
+		}
+		return fn + "(r, ty)"
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 写入一个值的表达式
+func (g *generator) writeExpr(t *descriptor.Type, v, tag string) string {
+	switch t.Kind {
+	case "bool":
+		return "w.writeBool(" + v + ", " + tag + ")"
+	case "byte", "short", "int", "enum":
+		return "w.writeInt(" + v + ", " + tag + ")"
+	case "long":
+		return "w.writeLong(" + v + ", " + tag + ")"
+	case "float":
+		return "w.writeFloat(" + v + ", " + tag + ")"
+	case "double":
+		return "w.writeDouble(" + v + ", " + tag + ")"
+	case "string":
+		return "w.writeString(" + v + ", " + tag + ")"
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "w.writeBytes(" + v + ", " + tag + ")"
+		}
+		return "w.writeList(" + v + ", " + tag + ", (w, v, tag) => " + g.writeExpr(t.Params[0], "v", "tag") + ")"
+	case "map":
+		return "w.writeMap(" + v + ", " + tag + ", (w, v, tag) => " + g.writeExpr(t.Params[0], "v", "tag") +
+			", (w, v, tag) => " + g.writeExpr(t.Params[1], "v", "tag") + ")"
+	case "struct":
+		m, short := lang.SplitName(t.Name)
+		fn := "write" + lang.Upper(short)
+		if m != g.f.Module {
+			fn = m + "." + fn
+		}
+		return fn + "(w, " + v + ", " + tag + ")"
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 成员的默认值
+func (g *generator) defaultValue(mb *descriptor.Member) string {
+	if mb.Default != "" {
+		return g.literal(mb.Type, mb.Default)
+	}
+	return g.zero(mb.Type)
+}
+
+func (g *generator) zero(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool":
+		return "false"
+	case "byte", "short", "int", "float", "double":
+		return "0"
+	case "long":
+		return "0n"
+	case "string":
+		return `""`
+	case "vector", "array":
+		if lang.IsBytes(t) {
+			return "new Uint8Array(0)"
+		}
+		return "[]"
+	case "map":
+		return "new Map()"
+	case "enum":
+		// 和 go 一样，枚举的零值为 0
+		return "0 as " + g.ref(t.Name)
+	case "struct":
+		m, short := lang.SplitName(t.Name)
+		fn := "new" + lang.Upper(short)
+		if m != g.f.Module {
+			fn = m + "." + fn
+		}
+		return fn + "()"
+	}
+	fail("unsupported type %s", t.Kind)
+	return ""
+}
+
+// 源码中的常量、默认值转换为 ts 的字面量
+func (g *generator) literal(t *descriptor.Type, v string) string {
+	switch t.Kind {
+	case "long":
+		if strings.Contains(v, ".") {
+			fail("invalid long value %s", v)
+		}
+		return v + "n"
+	case "enum":
+		ev, err := g.x.EnumDefault(t, v)
+		if err != nil {
+			fail("%v", err)
+		}
+		return g.ref(t.Name) + "." + ev.Name
+	}
+	return v
+}
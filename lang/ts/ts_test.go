@@ -0,0 +1,108 @@
+package ts
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/lang/langtest"
+)
+
+func TestGenerate(t *testing.T) {
+	files := langtest.Generate(t, Generate, "")
+	if len(files) != 3 || files[0].Name != RuntimeName || files[1].Name != "base.ts" || files[2].Name != "test.ts" {
+		t.Fatalf("unexpected files %v", files)
+	}
+	code := files[2].Content
+	for _, s := range []string{
+		"export enum Color {",
+		"  Green = 2,",
+		"  c?: Color;",
+		"  raw?: Uint8Array;",
+		"  nested?: Array<string[]>;",
+		"  byId?: Map<number, base.Item>;",
+		"    w.writeInt(v.c ?? Color.Green, 2);",
+		"export function decodePacket(data: Uint8Array): Packet {",
+		"  r.readStruct(ty, [0, 1], [13], (tag, ty) => {",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+}
+
+// 找到支持直接运行 ts 的 node（22.7 以上）：JCE2GO_NODE 指定的路径，PATH 中的 node，nvm 安装的 node
+func findNode(t *testing.T) string {
+	candidates := []string{os.Getenv("JCE2GO_NODE"), "node"}
+	nvm := os.Getenv("NVM_DIR")
+	if nvm == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			nvm = filepath.Join(home, ".nvm")
+		}
+	}
+	if nvm != "" {
+		nodes, _ := filepath.Glob(filepath.Join(nvm, "versions", "node", "*", "bin", "node"))
+		// 版本从新到旧
+		sort.Slice(nodes, func(i, j int) bool { return nodeVersion(nodes[i]) > nodeVersion(nodes[j]) })
+		candidates = append(candidates, nodes...)
+	}
+
+	var tried []string
+	for _, node := range candidates {
+		if node == "" {
+			continue
+		}
+		if err := exec.Command(node, "--experimental-transform-types", "--no-warnings", "-e", "0").Run(); err == nil {
+			return node
+		}
+		tried = append(tried, node)
+	}
+	t.Skipf("node supporting --experimental-transform-types (>= 22.7) not found in %v, set JCE2GO_NODE to run this test", tried)
+	return ""
+}
+
+// nvm 路径中的版本号，如 .../v22.20.0/bin/node 为 22020000
+func nodeVersion(node string) int {
+	var major, minor, patch int
+	fmt.Sscanf(filepath.Base(filepath.Dir(filepath.Dir(node))), "v%d.%d.%d", &major, &minor, &patch)
+	return major*1000000 + minor*1000 + patch
+}
+
+// ts 解码 fixtures.hex 中的数据后重新编码，结果和原来的数据相同
+func TestRoundTrip(t *testing.T) {
+	node := findNode(t)
+	files := langtest.Generate(t, Generate, "")
+
+	dir := t.TempDir()
+	// node 需要带扩展名的导入路径
+	imports := regexp.MustCompile(`from "(\./[^"]+)";`)
+	langtest.WriteFiles(t, dir, files, func(content string) string {
+		return imports.ReplaceAllString(content, `from "$1.ts";`)
+	})
+	main := `import { decodePacket, encodePacket } from "./test.ts";
+for (const arg of process.argv.slice(2)) {
+  const data = Uint8Array.from(Buffer.from(arg, "hex"));
+  console.log(Buffer.from(encodePacket(decodePacket(data))).toString("hex"));
+}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.ts"), []byte(main), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte(`{"type": "module"}`), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	fixtures := langtest.Fixtures(t)
+	args := []string{"--experimental-transform-types", "--no-warnings", filepath.Join(dir, "main.ts")}
+	out, err := exec.Command(node, append(args, fixtures...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	langtest.Check(t, out, fixtures)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/lang/cpp"
+	"github.com/erpc-go/jce2go/lang/java"
+	"github.com/erpc-go/jce2go/lang/python"
+	"github.com/erpc-go/jce2go/lang/rust"
+	"github.com/erpc-go/jce2go/lang/ts"
+	"github.com/erpc-go/jce2go/log"
+	"github.com/erpc-go/jce2go/plugin"
+)
+
+// --lang 支持的其他语言，go 代码由 generate 包生成
+var langs = map[string]lang.Generator{
+	"cpp":    cpp.Generate,
+	"java":   java.Generate,
+	"python": python.Generate,
+	"rust":   rust.Generate,
+	"ts":     ts.Generate,
+}
+
+func langNames() string {
+	names := []string{"go"}
+	for name := range langs {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return strings.Join(names, ", ")
+}
+
+// runLang 生成其他语言的代码，输入文件以及它们 include 的文件都会生成
+func runLang(name, param, outdir string, files []string) error {
+	gen, ok := langs[name]
+	if !ok {
+		return fmt.Errorf("unsupported language %q, expect one of %s", name, langNames())
+	}
+
+	var jces []string
+	for _, f := range files {
+		if path.Ext(f) == ".jce" {
+			jces = append(jces, f)
+		}
+	}
+	ps, err := parseInputs(jces)
+	if err != nil {
+		return err
+	}
+
+	out, err := gen(descriptor.Build(ps...), param)
+	if err != nil {
+		return err
+	}
+	if outdir == "" {
+		outdir = "."
+	}
+	written, err := (&plugin.Response{Files: out}).Write(outdir)
+	if err != nil {
+		return err
+	}
+	for _, f := range written {
+		log.Raw("[ok]%s -> %s\n", name, f)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package lex
+
+import "strconv"
+
+// Error 词法分析过程中的错误，记录出错的文件和位置
+// Error record lexical error with position.
+type Error struct {
+	Filename string
+	Line     int
+	Column   int
+	Msg      string
+}
+
+func (e *Error) Error() string {
+	return e.Filename + ": " + strconv.Itoa(e.Line) + ".    " + e.Msg
+}
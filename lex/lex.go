@@ -14,6 +14,12 @@ type LexState struct {
 
 	tokenBuff bytes.Buffer // 存储标记的缓冲区
 
+	size        int // 源码总长度
+	pos         int // current 在源码中的字节偏移
+	lineStart   int // 当前行起始位置的字节偏移
+	tokenStart  int // 当前 token 起始位置的字节偏移
+	tokenColumn int // 当前 token 起始位置的列号
+
 	peekedToken *Token
 
 	filename string        // 处理的文件名
@@ -25,6 +31,8 @@ func NewLexState(filename string, source []byte) *LexState {
 	return &LexState{
 		current:    ' ',
 		lineNumber: 1,
+		size:       len(source),
+		pos:        -1,
 		filename:   filename,
 		source:     bytes.NewBuffer(source),
 	}
@@ -45,6 +53,9 @@ func (ls *LexState) NextToken() *Token {
 	tk := &Token{}
 	tk.Type, tk.Value = ls.llex()
 	tk.Line = ls.lineNumber
+	tk.Column = ls.tokenColumn
+	tk.Offset = ls.tokenStart
+	tk.End = ls.pos
 	return tk
 }
 
@@ -59,8 +70,12 @@ func (ls *LexState) PeekToken() *Token {
 // lexErr 方法接受一个错误字符串作为参数，然后将其与当前行号和源代码文件名组合，
 // 生成一个详细的错误消息并引发一个 panic。这个方法在词法分析过程中遇到错误时被调用。
 func (ls *LexState) lexErr(err string) {
-	line := strconv.Itoa(ls.lineNumber)
-	panic(ls.filename + ": " + line + ".    " + err)
+	panic(&Error{
+		Filename: ls.filename,
+		Line:     ls.lineNumber,
+		Column:   ls.pos - ls.lineStart + 1,
+		Msg:      err,
+	})
 }
 
 // incLineNumber 方法用于在遇到换行符时递增行号。
@@ -74,6 +89,7 @@ func (ls *LexState) incLineNumber() {
 		ls.next() /* skip '\n\r' or '\r\n' */
 	}
 	ls.lineNumber++
+	ls.lineStart = ls.pos
 }
 
 // readNumber 方法用于从输入缓冲区读取一个数字（整数或浮点数）。
@@ -253,7 +269,10 @@ func (ls *LexState) next() {
 	ls.current, err = ls.source.ReadByte()
 	if err != nil {
 		ls.current = EOS
+		ls.pos = ls.size
+		return
 	}
+	ls.pos++
 }
 
 // llexDefault 方法用于处理词法分析器中的默认情况。
@@ -290,6 +309,8 @@ func (ls *LexState) llexDefault() (TokenType, *TokenValue) {
 func (ls *LexState) llex() (TokenType, *TokenValue) {
 	for {
 		ls.tokenBuff.Reset()
+		ls.tokenStart = ls.pos
+		ls.tokenColumn = ls.pos - ls.lineStart + 1
 		switch ls.current {
 		case EOS:
 			return TkEos, nil
@@ -342,3 +363,26 @@ func (ls *LexState) llex() (TokenType, *TokenValue) {
 		}
 	}
 }
+
+// Tokenize 对整个源码做词法分析，返回包括注释在内的全部 token（不含结尾的 EOS）。
+// 词法错误不会 panic，而是以 *Error 的形式返回。
+func Tokenize(filename string, source []byte) (tokens []Token, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			le, ok := e.(*Error)
+			if !ok {
+				panic(e)
+			}
+			err = le
+		}
+	}()
+
+	ls := NewLexState(filename, source)
+	for {
+		tk := ls.NextToken()
+		if IsEOS(tk.Type) {
+			return
+		}
+		tokens = append(tokens, *tk)
+	}
+}
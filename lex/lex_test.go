@@ -42,3 +42,29 @@ module base
 		}
 	}
 }
+
+func TestTokenize(t *testing.T) {
+	data := "module base\n{\n    struct request { 1 require base::req r; };\n};"
+	tokens, err := Tokenize("base.jce", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tk := range tokens {
+		if tk.Type == TkName && tk.Value.String == "base::req" {
+			if tk.Line != 3 || tk.Column != 32 || data[tk.Offset:tk.End] != "base::req" {
+				t.Errorf("bad position: %+v", tk)
+			}
+			return
+		}
+	}
+	t.Error("base::req not found")
+}
+
+func TestTokenizeError(t *testing.T) {
+	_, err := Tokenize("base.jce", []byte("module base {\n  $"))
+	le, ok := err.(*Error)
+	if !ok || le.Line != 2 || le.Column != 3 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
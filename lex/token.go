@@ -3,9 +3,12 @@ package lex
 // Token 结构表示词法分析器中的一个标记。它包含一个 TK 类型的字段 T，一个指向 SemInfo 结构的指针 S 和一个整数类型的字段 Line，用于表示标记所在的行号。
 // Token record token information.
 type Token struct {
-	Type  TokenType
-	Value *TokenValue
-	Line  int
+	Type   TokenType
+	Value  *TokenValue
+	Line   int // token 结束所在的行号
+	Column int // token 起始所在的列号，从 1 开始
+	Offset int // token 起始位置在源码中的字节偏移
+	End    int // token 结束位置（不含）在源码中的字节偏移
 }
 
 // TokenType is a byte type.
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"time"
 )
 
@@ -22,6 +23,13 @@ func (l *Logger) SetLevel(level Level) {
 	l.level = level
 }
 
+// 设置日志输出，默认输出到标准输出
+func (l *Logger) SetOutput(w io.Writer) {
+	l.writer = bufio.ReadWriter{
+		Writer: bufio.NewWriter(w),
+	}
+}
+
 func (l *Logger) SetModle(mode Mode) {
 	l.mode = mode
 }
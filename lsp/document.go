@@ -0,0 +1,172 @@
+package lsp
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// 编辑器中打开的一个 jce 文件
+type document struct {
+	uri  string
+	path string
+	text []byte
+
+	tokens []lex.Token    // 词法分析结果，有词法错误时为出错前的部分
+	p      *parser.Parser // 最近一次解析成功的语法树
+	err    error          // 最近一次解析的错误
+	lines  []int          // 每行起始位置的字节偏移
+}
+
+func newDocument(uri string, text string) *document {
+	d := &document{uri: uri, path: uriToPath(uri)}
+	d.update(text)
+	return d
+}
+
+// update 更新文档内容并重新分析
+func (d *document) update(text string) {
+	d.text = []byte(text)
+	d.lines = lineStarts(d.text)
+	d.tokens, _ = lex.Tokenize(d.path, d.text)
+
+	p, err := parser.ParseSource(d.path, d.text)
+	d.err = err
+	if err == nil {
+		d.p = p
+	}
+}
+
+// diagnostics 把解析错误转换成诊断信息
+func (d *document) diagnostics() []Diagnostic {
+	diags := []Diagnostic{}
+	if d.err == nil {
+		return diags
+	}
+
+	diag := Diagnostic{Severity: severityError, Source: "jce2go", Message: d.err.Error()}
+	if le, ok := d.err.(*lex.Error); ok {
+		diag.Message = le.Msg
+		if le.Filename != d.path {
+			// include 的文件出错，报在文件开头
+			diag.Message = filepath.Base(le.Filename) + ":" + strconv.Itoa(le.Line) + ": " + le.Msg
+		} else if le.Line > 0 {
+			start := d.position(d.offset(le.Line, le.Column))
+			end := start
+			end.Character++
+			diag.Range = Range{Start: start, End: end}
+		}
+	}
+	return append(diags, diag)
+}
+
+// tokenAt 返回位置所在的 token
+func (d *document) tokenAt(pos Position) (int, *lex.Token) {
+	off := d.offsetOf(pos)
+	for i := range d.tokens {
+		tk := &d.tokens[i]
+		if off >= tk.Offset && off <= tk.End && tk.Type != lex.TkComment {
+			// 光标紧贴在 token 之后时，优先取名字
+			if off == tk.End && tk.Type != lex.TkName && i+1 < len(d.tokens) && d.tokens[i+1].Offset == off {
+				continue
+			}
+			return i, tk
+		}
+	}
+	return -1, nil
+}
+
+// tokenRange 返回 token 在文档中的区间
+func (d *document) tokenRange(tk *lex.Token) Range {
+	return Range{Start: d.position(tk.Offset), End: d.position(tk.End)}
+}
+
+// offset 把 1 开始的行列号转换为字节偏移
+func (d *document) offset(line, column int) int {
+	return lineColumnOffset(d.lines, len(d.text), line, column)
+}
+
+// offsetOf 把 LSP 的位置转换为字节偏移
+func (d *document) offsetOf(pos Position) int {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return len(d.text)
+	}
+	off := d.lines[pos.Line]
+	for n := 0; n < pos.Character && off < len(d.text) && d.text[off] != '\n'; {
+		r, size := utf8.DecodeRune(d.text[off:])
+		n += len(utf16.Encode([]rune{r}))
+		off += size
+	}
+	return off
+}
+
+// position 把字节偏移转换为 LSP 的位置
+func (d *document) position(off int) Position {
+	return position(d.text, d.lines, off)
+}
+
+func position(text []byte, lines []int, off int) Position {
+	if off > len(text) {
+		off = len(text)
+	}
+	line := 0
+	for line+1 < len(lines) && lines[line+1] <= off {
+		line++
+	}
+	character := len(utf16.Encode([]rune(string(text[lines[line]:off]))))
+	return Position{Line: line, Character: character}
+}
+
+func lineStarts(text []byte) []int {
+	lines := []int{0}
+	for i, c := range text {
+		if c == '\n' {
+			lines = append(lines, i+1)
+		}
+	}
+	return lines
+}
+
+func lineColumnOffset(lines []int, size int, line, column int) int {
+	if line < 1 || line > len(lines) {
+		return size
+	}
+	off := lines[line-1] + column - 1
+	if off > size {
+		off = size
+	}
+	return off
+}
+
+// 某个文件中 1 开始的行列号对应的 LSP 区间，用于跳转到 include 的文件
+func fileRange(path string, line, column int, length int) Range {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		start := Position{Line: line - 1, Character: column - 1}
+		return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + length}}
+	}
+	lines := lineStarts(text)
+	off := lineColumnOffset(lines, len(text), line, column)
+	return Range{Start: position(text, lines, off), End: position(text, lines, off+length)}
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+func pathToURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,286 @@
+package lsp
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// resolve 解析 token 对应的符号：先看是否是当前文件中的定义，再按 module::Name 在 include 链中查找
+func resolve(d *document, tk *lex.Token) *parser.Symbol {
+	if d.p == nil || tk == nil || tk.Type != lex.TkName {
+		return nil
+	}
+	if sym := d.p.DeclarationAt(tk.Line, tk.Column); sym != nil {
+		return sym
+	}
+	return d.p.Resolve(tk.Value.String)
+}
+
+// definition 跳转到定义
+func definition(d *document, pos Position) interface{} {
+	_, tk := d.tokenAt(pos)
+	sym := resolve(d, tk)
+	if sym == nil {
+		return nil
+	}
+
+	line, column, name := sym.Position()
+	if sym.Parser.Filepath == d.path {
+		start := d.position(d.offset(line, column))
+		end := d.position(d.offset(line, column) + len(name))
+		return Location{URI: d.uri, Range: Range{Start: start, End: end}}
+	}
+	return Location{URI: pathToURI(sym.Parser.Filepath), Range: fileRange(sym.Parser.Filepath, line, column, len(name))}
+}
+
+// hover 悬停提示
+func hover(d *document, pos Position) interface{} {
+	_, tk := d.tokenAt(pos)
+	sym := resolve(d, tk)
+	if sym == nil {
+		return nil
+	}
+
+	r := d.tokenRange(tk)
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: describe(sym)},
+		Range:    &r,
+	}
+}
+
+// describe 生成符号的 markdown 描述
+func describe(sym *parser.Symbol) string {
+	var b strings.Builder
+	code := func(s string) {
+		b.WriteString("```jce\n" + s + "\n```\n")
+	}
+	comment := func(s string) {
+		if s = strings.TrimSpace(s); s != "" {
+			b.WriteString("\n" + s + "\n")
+		}
+	}
+	line, _, _ := sym.Position()
+	where := "\n" + filepath.Base(sym.Parser.Filepath) + ":" + strconv.Itoa(line)
+
+	switch {
+	case sym.Member != nil:
+		mb := sym.Member
+		req := "optional"
+		if mb.Require {
+			req = "require"
+		}
+		decl := strconv.Itoa(int(mb.Tag)) + " " + req + " " + mb.Type.String() + " " + mb.Key
+		if mb.Default != "" {
+			decl += " = " + mb.Default
+		}
+		code(decl)
+		b.WriteString("\ntag: `" + strconv.Itoa(int(mb.Tag)) + "`, type: `" + resolvedType(sym.Parser, mb.Type) + "`\n")
+		comment(mb.Comment)
+	case sym.Struct != nil:
+		code("struct " + sym.Parser.Module + "::" + sym.Struct.Name)
+		comment(sym.Struct.Comment)
+		b.WriteString(where)
+	case sym.EnumMember != nil:
+		value := sym.Enum.Values()[sym.EnumMember.Key]
+		code(sym.Parser.Module + "::" + sym.Enum.Name + "::" + sym.EnumMember.Key + " = " + strconv.Itoa(int(value)))
+		comment(sym.EnumMember.Comment)
+		b.WriteString(where)
+	case sym.Enum != nil:
+		values := sym.Enum.Values()
+		var lines []string
+		for _, mb := range sym.Enum.Member {
+			if mb.Type != parser.EnumTypeComment {
+				lines = append(lines, "    "+mb.Key+" = "+strconv.Itoa(int(values[mb.Key]))+",")
+			}
+		}
+		code("enum " + sym.Parser.Module + "::" + sym.Enum.Name + " {\n" + strings.Join(lines, "\n") + "\n}")
+		comment(sym.Enum.TypeComment)
+		b.WriteString(where)
+	case sym.Const != nil:
+		code("const " + sym.Const.Type.String() + " " + sym.Const.Name + " = " + sym.Const.Value)
+		comment(sym.Const.PreComment)
+	}
+	return b.String()
+}
+
+// resolvedType 返回类型的完整描述，自定义类型会标明是 struct 还是 enum，并补全 module
+func resolvedType(p *parser.Parser, ty *parser.VarType) string {
+	switch ty.Type {
+	case lex.TkName:
+		name := ty.TypeSt
+		if !strings.Contains(name, "::") {
+			name = p.Module + "::" + name
+		}
+		switch ty.CType {
+		case lex.TkStruct:
+			return "struct " + name
+		case lex.TkEnum:
+			return "enum " + name
+		}
+		return name
+	case lex.TkTVector:
+		return "vector<" + resolvedType(p, ty.TypeK) + ">"
+	case lex.TkTMap:
+		return "map<" + resolvedType(p, ty.TypeK) + ", " + resolvedType(p, ty.TypeV) + ">"
+	case lex.TkTArray:
+		return resolvedType(p, ty.TypeK) + "[" + strconv.FormatInt(ty.TypeL, 10) + "]"
+	}
+	return ty.String()
+}
+
+// 可以补全的内置类型
+var builtinTypes = []string{"bool", "byte", "short", "int", "long", "float", "double", "string", "vector", "map", "unsigned"}
+
+// completion 补全：= 之后补全枚举值，其他位置补全类型
+func completion(d *document, pos Position) []CompletionItem {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return []CompletionItem{}
+	}
+	off := d.offsetOf(pos)
+	lineStart := d.lines[pos.Line]
+
+	var lineTokens []lex.Token
+	for _, tk := range d.tokens {
+		if tk.Offset >= lineStart && tk.End <= off && tk.Type != lex.TkComment {
+			lineTokens = append(lineTokens, tk)
+		}
+	}
+
+	for i, tk := range lineTokens {
+		if tk.Type == lex.TkEq {
+			typeName := ""
+			if i >= 2 && lineTokens[i-2].Type == lex.TkName {
+				typeName = lineTokens[i-2].Value.String
+			}
+			return enumCompletion(d.p, typeName)
+		}
+	}
+
+	items := []CompletionItem{}
+	for _, t := range builtinTypes {
+		items = append(items, CompletionItem{Label: t, Kind: completionKeyword})
+	}
+	if d.p == nil {
+		return items
+	}
+
+	d.p.Visit(func(fp *parser.Parser) {
+		prefix := ""
+		if fp.Module != d.p.Module {
+			prefix = fp.Module + "::"
+		}
+		for _, st := range fp.Structs {
+			items = append(items, CompletionItem{Label: prefix + st.Name, Kind: completionStruct, Detail: "struct " + fp.Module + "::" + st.Name})
+		}
+		for _, en := range fp.Enums {
+			items = append(items, CompletionItem{Label: prefix + en.Name, Kind: completionEnum, Detail: "enum " + fp.Module + "::" + en.Name})
+		}
+	})
+	return items
+}
+
+// enumCompletion 补全枚举默认值，能确定成员类型时只补全该枚举的成员
+func enumCompletion(p *parser.Parser, typeName string) []CompletionItem {
+	items := []CompletionItem{}
+	if p == nil {
+		return items
+	}
+
+	add := func(en *parser.EnumInfo) {
+		values := en.Values()
+		keys := make([]string, 0, len(values))
+		for _, mb := range en.Member {
+			if mb.Type != parser.EnumTypeComment {
+				keys = append(keys, mb.Key)
+			}
+		}
+		for _, k := range keys {
+			items = append(items, CompletionItem{
+				Label:  k,
+				Kind:   completionEnumMember,
+				Detail: en.Name + " = " + strconv.Itoa(int(values[k])),
+			})
+		}
+	}
+
+	if typeName != "" {
+		if _, en := p.FindEnum(typeName); en != nil {
+			add(en)
+			return items
+		}
+	}
+
+	p.Visit(func(fp *parser.Parser) {
+		for i := range fp.Enums {
+			add(&fp.Enums[i])
+		}
+	})
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+	return items
+}
+
+// documentSymbols 文档大纲
+func documentSymbols(d *document) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	p := d.p
+	if p == nil {
+		return symbols
+	}
+
+	rangeOf := func(line, column int, name string) Range {
+		off := d.offset(line, column)
+		return Range{Start: d.position(off), End: d.position(off + len(name))}
+	}
+
+	module := DocumentSymbol{Name: p.Module, Kind: symbolNamespace}
+	module.Range = Range{Start: Position{}, End: d.position(len(d.text))}
+	for i, tk := range d.tokens {
+		if tk.Type == lex.TkModule && i+1 < len(d.tokens) {
+			module.SelectionRange = d.tokenRange(&d.tokens[i+1])
+			break
+		}
+	}
+
+	for _, cst := range p.Consts {
+		r := rangeOf(cst.Line, cst.Column, cst.Name)
+		module.Children = append(module.Children, DocumentSymbol{
+			Name: cst.Name, Detail: cst.Type.String(), Kind: symbolConstant, Range: r, SelectionRange: r,
+		})
+	}
+	for _, en := range p.Enums {
+		r := rangeOf(en.Line, en.Column, en.Name)
+		sym := DocumentSymbol{Name: en.Name, Kind: symbolEnum, Range: r, SelectionRange: r}
+		values := en.Values()
+		for _, mb := range en.Member {
+			if mb.Type == parser.EnumTypeComment {
+				continue
+			}
+			mr := rangeOf(mb.Line, mb.Column, mb.Key)
+			sym.Children = append(sym.Children, DocumentSymbol{
+				Name: mb.Key, Detail: strconv.Itoa(int(values[mb.Key])), Kind: symbolEnumMember, Range: mr, SelectionRange: mr,
+			})
+		}
+		module.Children = append(module.Children, sym)
+	}
+	for _, st := range p.Structs {
+		r := rangeOf(st.Line, st.Column, st.Name)
+		sym := DocumentSymbol{Name: st.Name, Kind: symbolStruct, Range: r, SelectionRange: r}
+		for _, mb := range st.Member {
+			if mb.CommentType != "" {
+				continue
+			}
+			mr := rangeOf(mb.Line, mb.Column, mb.Key)
+			sym.Children = append(sym.Children, DocumentSymbol{
+				Name: mb.Key, Detail: strconv.Itoa(int(mb.Tag)) + " " + mb.Type.String(), Kind: symbolField, Range: mr, SelectionRange: mr,
+			})
+		}
+		module.Children = append(module.Children, sym)
+	}
+
+	return append(symbols, module)
+}
@@ -0,0 +1,248 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/format"
+	"github.com/erpc-go/jce2go/log"
+)
+
+// Server jce 语言服务，通过 jsonrpc 和编辑器通信，支持：
+// 1. 编辑时实时诊断
+// 2. module::Type 跳转到定义（会在 include 的文件中查找）
+// 3. 悬停显示类型、tag 等信息
+// 4. 类型、枚举默认值补全
+// 5. 文档大纲
+// 6. 格式化
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer 创建语言服务，in、out 一般是标准输入输出
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: map[string]*document{},
+	}
+}
+
+// rpcError 处理请求时的错误
+type rpcError struct {
+	code int
+	msg  string
+}
+
+// Serve 循环处理请求，直到收到 exit 通知或者输入结束
+func (s *Server) Serve() error {
+	for {
+		body, err := s.read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err = json.Unmarshal(body, &req); err != nil {
+			s.write(errorResponse{JSONRPC: "2.0", Error: responseError{Code: codeParseError, Message: err.Error()}})
+			continue
+		}
+
+		log.Debug("lsp request: %s", req.Method)
+
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, rerr := s.handle(&req)
+		if req.ID == nil {
+			// 通知不需要回复
+			continue
+		}
+		if rerr != nil {
+			s.write(errorResponse{JSONRPC: "2.0", ID: req.ID, Error: responseError{Code: rerr.code, Message: rerr.msg}})
+			continue
+		}
+		s.write(response{JSONRPC: "2.0", ID: req.ID, Result: result})
+	}
+}
+
+// 读取一个消息，消息格式为 header + \r\n + json body
+func (s *Server) read() ([]byte, error) {
+	header, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, io.EOF
+		}
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+
+	body := make([]byte, length)
+	if _, err = io.ReadFull(s.in, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func (s *Server) write(v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Error("lsp marshal failed, err:%s", err)
+		return
+	}
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	s.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) handle(req *request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":           1, // 全量同步
+				"definitionProvider":         true,
+				"hoverProvider":              true,
+				"documentSymbolProvider":     true,
+				"documentFormattingProvider": true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{":", "=", "<"},
+				},
+			},
+			"serverInfo": map[string]string{"name": "jce2go"},
+		}, nil
+	case "initialized", "$/cancelRequest", "workspace/didChangeConfiguration":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		d := newDocument(params.TextDocument.URI, params.TextDocument.Text)
+		s.docs[d.uri] = d
+		s.publishDiagnostics(d)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		d, ok := s.docs[params.TextDocument.URI]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		d.update(params.ContentChanges[len(params.ContentChanges)-1].Text)
+		s.publishDiagnostics(d)
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.docs, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+		return nil, nil
+
+	case "textDocument/definition":
+		d, pos, rerr := s.positionParams(req)
+		if d == nil {
+			return nil, rerr
+		}
+		return definition(d, pos), nil
+	case "textDocument/hover":
+		d, pos, rerr := s.positionParams(req)
+		if d == nil {
+			return nil, rerr
+		}
+		return hover(d, pos), nil
+	case "textDocument/completion":
+		d, pos, rerr := s.positionParams(req)
+		if d == nil {
+			return nil, rerr
+		}
+		return completion(d, pos), nil
+	case "textDocument/documentSymbol":
+		d, rerr := s.documentParams(req)
+		if d == nil {
+			return nil, rerr
+		}
+		return documentSymbols(d), nil
+	case "textDocument/formatting":
+		d, rerr := s.documentParams(req)
+		if d == nil {
+			return nil, rerr
+		}
+		return formatting(d), nil
+	}
+
+	if strings.HasPrefix(req.Method, "$/") {
+		return nil, nil
+	}
+	return nil, &rpcError{code: codeMethodNotFound, msg: "method not found: " + req.Method}
+}
+
+func invalidParams(err error) *rpcError {
+	return &rpcError{code: codeInvalidParams, msg: err.Error()}
+}
+
+func (s *Server) positionParams(req *request) (*document, Position, *rpcError) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, Position{}, invalidParams(err)
+	}
+	d, ok := s.docs[params.TextDocument.URI]
+	if !ok {
+		return nil, Position{}, nil
+	}
+	return d, params.Position, nil
+}
+
+func (s *Server) documentParams(req *request) (*document, *rpcError) {
+	var params documentParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, invalidParams(err)
+	}
+	return s.docs[params.TextDocument.URI], nil
+}
+
+func (s *Server) publishDiagnostics(d *document) {
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{URI: d.uri, Diagnostics: d.diagnostics()})
+}
+
+// formatting 格式化整个文档，内容不变时不返回修改
+func formatting(d *document) []TextEdit {
+	out, err := format.Source(d.path, d.text)
+	if err != nil || string(out) == string(d.text) {
+		return []TextEdit{}
+	}
+	return []TextEdit{{
+		Range:   Range{Start: Position{}, End: d.position(len(d.text))},
+		NewText: string(out),
+	}}
+}
@@ -0,0 +1,147 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// 模拟编辑器的一次会话，返回服务端输出的所有消息
+func session(t *testing.T, msgs ...interface{}) []map[string]interface{} {
+	var in bytes.Buffer
+	for _, m := range msgs {
+		b, _ := json.Marshal(m)
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(b), b)
+	}
+
+	var out bytes.Buffer
+	if err := NewServer(&in, &out).Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	var ret []map[string]interface{}
+	r := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err != nil {
+			return ret
+		}
+		n, _ := strconv.Atoi(header.Get("Content-Length"))
+		body := make([]byte, n)
+		io.ReadFull(r, body)
+		m := map[string]interface{}{}
+		if err = json.Unmarshal(body, &m); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, m)
+	}
+}
+
+func call(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func at(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]string{"uri": uri},
+		"position":     map[string]int{"line": line, "character": character},
+	}
+}
+
+func TestServer(t *testing.T) {
+	path, _ := filepath.Abs("../demo/test.jce")
+	text, _ := ioutil.ReadFile(path)
+	uri := pathToURI(path)
+	doc := map[string]interface{}{"textDocument": map[string]string{"uri": uri}}
+
+	// 第 27 行（从 0 开始）: 18 require  base::request                 req;
+	lines := strings.Split(string(text), "\n")
+	reqLine := 27
+	typeCol := strings.Index(lines[reqLine], "base::request")
+	keyCol := strings.Index(lines[reqLine], "req;")
+
+	out := session(t,
+		call(1, "initialize", map[string]interface{}{}),
+		notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "version": 1, "text": string(text)},
+		}),
+		call(2, "textDocument/definition", at(uri, reqLine, typeCol+2)),
+		call(3, "textDocument/hover", at(uri, reqLine, keyCol)),
+		call(4, "textDocument/completion", at(uri, reqLine, typeCol)),
+		call(5, "textDocument/documentSymbol", doc),
+		call(6, "textDocument/formatting", doc),
+		notify("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+			"contentChanges": []map[string]string{{"text": "module test {\n  struct a {\n    1 require int;\n  };\n};\n"}},
+		}),
+		call(7, "shutdown", nil),
+		notify("exit", nil),
+	)
+
+	byID := map[float64]map[string]interface{}{}
+	var diags []interface{}
+	for _, m := range out {
+		if id, ok := m["id"].(float64); ok {
+			byID[id] = m
+		} else if m["method"] == "textDocument/publishDiagnostics" {
+			diags = append(diags, m["params"].(map[string]interface{})["diagnostics"])
+		}
+	}
+
+	def, _ := json.Marshal(byID[2]["result"])
+	if !strings.Contains(string(def), "base.jce") || !strings.Contains(string(def), `"line":32`) {
+		t.Errorf("definition: %s", def)
+	}
+
+	hv, _ := json.Marshal(byID[3]["result"])
+	if !strings.Contains(string(hv), "tag: `18`") || !strings.Contains(string(hv), "struct base::request") {
+		t.Errorf("hover: %s", hv)
+	}
+
+	items, _ := json.Marshal(byID[4]["result"])
+	if !strings.Contains(string(items), `"base::request"`) || !strings.Contains(string(items), `"base::EMsgSendType"`) {
+		t.Errorf("completion: %s", items)
+	}
+
+	syms, _ := json.Marshal(byID[5]["result"])
+	if !strings.Contains(string(syms), `"RequestPacket"`) || !strings.Contains(string(syms), `"buffer2"`) {
+		t.Errorf("symbols: %s", syms)
+	}
+
+	edits, _ := byID[6]["result"].([]interface{})
+	if len(edits) != 1 {
+		t.Errorf("formatting: %v", byID[6]["result"])
+	}
+
+	if len(diags) != 2 || len(diags[0].([]interface{})) != 0 || len(diags[1].([]interface{})) != 1 {
+		t.Errorf("diagnostics: %v", diags)
+	}
+}
+
+func TestEnumCompletion(t *testing.T) {
+	path, _ := filepath.Abs("../demo/x.jce")
+	text := "#include \"base.jce\"\nmodule x {\n    struct a {\n        1 optional base::EMsgSendType t = \n    };\n};\n"
+	d := newDocument(pathToURI(path), text)
+	if d.err != nil {
+		// 成员默认值未写完，解析会失败，此时使用最近一次成功的结果
+		d.update(strings.Replace(text, "t = ", "t;", 1))
+		d.update(text)
+	}
+
+	items := completion(d, Position{Line: 3, Character: 42})
+	if len(items) != 3 || items[0].Label != "hhh" || items[1].Detail != "EMsgSendType = 199" {
+		t.Errorf("unexpected items: %+v", items)
+	}
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
+	"strings"
 
+	"github.com/erpc-go/jce2go/descriptor"
 	"github.com/erpc-go/jce2go/generate"
 	"github.com/erpc-go/jce2go/log"
+	"github.com/erpc-go/jce2go/parser"
+	"github.com/erpc-go/jce2go/plugin"
 )
 
 var (
@@ -23,14 +29,47 @@ var (
 	noOptional bool
 
 	addTag bool
+
+	// 输出完整解析后的 schema 描述
+	descriptorSetOut string
+
+	// 生成的 go 代码在 init 中注册 jceschema 结构描述
+	genDescriptor bool
+
+	// 外部代码生成插件
+	plugins = pluginFlag{}
+
+	// 覆盖内置模板的目录
+	templateDir string
+
+	// 生成的语言以及参数
+	langName string
+	langOpt  string
+
+	// 监视输入文件，修改后重新生成
+	watchMode bool
+
+	// 增量生成的缓存清单
+	cacheFile string
+
+	// 只检查生成的 go 代码是否是最新的，不写入文件
+	checkMode bool
 )
 
 func main() {
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: jce2go [OPTION] <jcefile>\n")
+		fmt.Fprintf(os.Stderr, "       jce2go [COMMAND] [ARGS]\n")
 		fmt.Fprintf(os.Stderr, "jce2go support type: bool byte short int long float double vector map\n")
 		fmt.Fprintf(os.Stderr, "supported [OPTION]:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "  --NAME_out=DIR\n    \trun plugin NAME and write its files to DIR\n")
+		fmt.Fprintf(os.Stderr, "  --NAME_opt=PARAM\n    \tparameter passed to plugin NAME\n")
+		printCommands()
 	}
 
 	flag.StringVar(&modulePath, "mod", "", "model path(default github.com/erpc-go/jce2go)")
@@ -39,8 +78,19 @@ func main() {
 	flag.BoolVar(&addTag, "tag", false, "set default struct tag")
 	flag.BoolVar(&noOptional, "no-optional", false, "do not package optional fields")
 	flag.BoolVar(&debug, "debug", false, "enable debug mode")
+	flag.BoolVar(&genDescriptor, "descriptor", false, "register descriptors of structs and enums to github.com/erpc-go/jce2go/jceschema in init of generated go code")
+	flag.StringVar(&descriptorSetOut, "descriptor_set_out", "", "write resolved schema of input files and includes to file, json if it ends with .json, otherwise self-describing jce")
+
+	flag.StringVar(&templateDir, "templates", "", "dir of templates overriding the builtin ones by file name: "+strings.Join(generate.TemplateNames(), ", "))
+	flag.StringVar(&langName, "lang", "go", "language of generated code: "+langNames())
+	flag.StringVar(&langOpt, "lang_opt", "", "options of the language generator, key=value[,key=value]")
+	flag.Var(plugins, "plugin", "external generator NAME=PATH, default PATH is "+plugin.Prefix+"NAME in $PATH")
+	flag.StringVar(&cacheFile, "cache", "", "cache manifest recording hashes of sources, version and options, skip go files that are unchanged")
+	flag.BoolVar(&checkMode, "check", false, "generate go code in memory, print a diff for each out of date file and exit 1, write nothing")
+	flag.BoolVar(&watchMode, "watch", false, "watch input files and their includes, regenerate affected outputs on changes")
 
-	flag.Parse()
+	args, pluginOuts, pluginOpts := splitPluginArgs(flag.CommandLine, os.Args[1:])
+	flag.CommandLine.Parse(args)
 
 	if len(flag.Args()) == 0 {
 		flag.Usage()
@@ -51,14 +101,169 @@ func main() {
 		log.DefaultLogger.SetLevel(log.DebugLevel)
 	}
 
-	for _, filename := range flag.Args() {
+	// 只运行插件时，没有指定 -o 则不生成 go 代码
+	genGo := len(pluginOuts) == 0
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "o" {
+			genGo = true
+		}
+	})
+
+	if langName != "go" {
+		genGo = false
+	}
+
+	if checkMode {
+		if !genGo {
+			fmt.Fprintln(os.Stderr, "-check only supports go code")
+			os.Exit(1)
+		}
+		if !checkGo(flag.Args()) {
+			os.Exit(1)
+		}
+		return
+	}
+
+	regen := func(files []string) error {
+		return generateAll(files, genGo, pluginOuts, pluginOpts)
+	}
+	err := safeRegen(regen, flag.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if watchMode {
+		w := newWatcher(flag.Args())
+		if err != nil {
+			w.pending = w.files()
+		}
+		w.run(regen)
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+// 生成 files 的所有输出，schema 描述总是包括命令行中所有的文件
+func generateAll(files []string, genGo bool, pluginOuts, pluginOpts map[string]string) error {
+	if langName != "go" {
+		if err := runLang(langName, langOpt, outdir, files); err != nil {
+			return err
+		}
+	}
+
+	if genGo {
+		if err := generateGo(files); err != nil {
+			return err
+		}
+	}
+
+	if len(pluginOuts) > 0 {
+		if err := runPlugins(plugins, pluginOuts, pluginOpts, files); err != nil {
+			return err
+		}
+	}
+
+	if descriptorSetOut != "" {
+		if err := writeDescriptorSet(descriptorSetOut, flag.Args()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 生成 go 代码，指定 -cache 时跳过没有变化的文件
+func generateGo(files []string) error {
+	var cache *generate.Cache
+	if cacheFile != "" {
+		var err error
+		if cache, err = generate.LoadCache(cacheFile); err != nil {
+			return err
+		}
+	}
+
+	generate.Reset()
+	for _, filename := range files {
 		if path.Ext(filename) != ".jce" {
 			continue
 		}
 
 		log.Debug("begin parse file, name: %s", filename)
 
-		gen := generate.NewGenerate(filename, modulePath, outdir, jsonOmitEmpty)
-		gen.Gen()
+		gen := generate.NewGenerate(filename, modulePath, outdir, jsonOmitEmpty).WithTemplates(templateDir).WithDescriptor(genDescriptor).WithCache(cache)
+		if err := gen.Run(); err != nil {
+			return err
+		}
+	}
+
+	if cache != nil {
+		return cache.Save()
+	}
+	return nil
+}
+
+// 在内存中生成 go 代码并与磁盘上的文件比较，输出不一致的文件的 diff，都是最新的时返回 true
+func checkGo(files []string) bool {
+	generate.Reset()
+	ok := true
+	for _, filename := range files {
+		if path.Ext(filename) != ".jce" {
+			continue
+		}
+		mismatches, err := generate.NewGenerate(filename, modulePath, outdir, jsonOmitEmpty).WithTemplates(templateDir).WithDescriptor(genDescriptor).Check()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return false
+		}
+		for _, m := range mismatches {
+			fmt.Print(m.Diff)
+			fmt.Fprintf(os.Stderr, "[check]%s is out of date, generated from %s\n", m.Path, filename)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// 把输入文件以及它们 include 的文件的 schema 描述写入 out
+func writeDescriptorSet(out string, files []string) error {
+	ps, err := parseInputs(files)
+	if err != nil {
+		return err
+	}
+	set := descriptor.Build(ps...)
+
+	var data []byte
+	if path.Ext(out) == ".json" {
+		data, err = json.MarshalIndent(set, "", "  ")
+	} else {
+		data, err = set.MarshalJCE()
+	}
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(out, data, 0o666); err != nil {
+		return err
+	}
+
+	log.Raw("[ok]descriptor set -> %s\n", out)
+	return nil
+}
+
+// 解析命令行中的 jce 文件
+func parseInputs(files []string) ([]*parser.Parser, error) {
+	var ps []*parser.Parser
+	for _, filename := range files {
+		if path.Ext(filename) != ".jce" {
+			continue
+		}
+		src, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		p, err := parser.ParseSource(filename, src)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
 	}
+	return ps, nil
 }
@@ -9,6 +9,8 @@ type ConstInfo struct {
 	Value      string
 	PreComment string
 	Comment    string
+	Line       int // 常量名所在的行号
+	Column     int // 常量名所在的列号
 }
 
 func (cst *ConstInfo) Rename() {
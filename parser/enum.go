@@ -18,6 +18,8 @@ type EnumMember struct {
 	Value   int32  // type 0
 	Name    string // type 1
 	Comment string
+	Line    int // 成员名所在的行号
+	Column  int // 成员名所在的列号
 }
 
 // EnumInfo record EnumMember information include name.
@@ -27,6 +29,8 @@ type EnumInfo struct {
 	TypeComment string
 	Comment     string
 	Member      []EnumMember
+	Line        int // 枚举名所在的行号
+	Column      int // 枚举名所在的列号
 }
 
 // enum 变量重命名，即把首字母都大写
@@ -36,3 +40,23 @@ func (en *EnumInfo) Rename() {
 		en.Member[i].Key = utils.UpperFirstLetter(en.Member[i].Key)
 	}
 }
+
+// Values 计算每个枚举成员的实际取值：显式赋值、引用前面的成员、或者在上一个成员的基础上加一
+func (en *EnumInfo) Values() map[string]int32 {
+	values := make(map[string]int32, len(en.Member))
+
+	var it int32
+	for _, v := range en.Member {
+		switch v.Type {
+		case EnumTypeComment:
+			continue
+		case EnumTypeValue:
+			it = v.Value
+		case EnumTypeName:
+			it = values[v.Name]
+		}
+		values[v.Key] = it
+		it++
+	}
+	return values
+}
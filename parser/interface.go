@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/log"
+)
+
+// ArgInfo 接口方法的参数
+type ArgInfo struct {
+	Name  string
+	IsOut bool // out 参数
+	Type  *VarType
+}
+
+// FunInfo 接口方法
+type FunInfo struct {
+	Name    string
+	RetType *VarType // void 时为 nil
+	Args    []ArgInfo
+	Comment string
+	Line    int // 方法名所在的行号
+	Column  int // 方法名所在的列号
+}
+
+// InterfaceInfo 接口，只记录定义，生成代码时忽略
+type InterfaceInfo struct {
+	Name                string
+	Funcs               []FunInfo
+	Comment             string
+	DependModule        map[string]bool
+	DependModuleWithJce map[string]string
+	Line                int // 接口名所在的行号
+	Column              int // 接口名所在的列号
+}
+
+// parseInterface 解析接口
+// interface Name { int fun(string a, out string b); };
+func (p *Parser) parseInterface() {
+	log.Debug("begin parseInterface")
+
+	itf := InterfaceInfo{Comment: p.getPreComments()}
+	p.expect(lex.TkName)
+	itf.Name = p.token.Value.String
+	itf.Line, itf.Column = p.token.Line, p.token.Column
+	for _, v := range p.Interfaces {
+		if v.Name == itf.Name {
+			p.parseErr(itf.Name + " Redefine.")
+		}
+	}
+	p.expect(lex.TkBraceLeft)
+
+	for {
+		p.next()
+		switch p.token.Type {
+		case lex.TkBraceRight:
+			p.expect(lex.TkSemi)
+			p.Interfaces = append(p.Interfaces, itf)
+			return
+		case lex.TkComment:
+			p.comments = append(p.comments, *p.token)
+			continue
+		}
+
+		fun := FunInfo{Comment: p.getPreComments()}
+		if p.token.Type != lex.TkVoid {
+			fun.RetType = p.parseType()
+		}
+		p.expect(lex.TkName)
+		fun.Name = p.token.Value.String
+		fun.Line, fun.Column = p.token.Line, p.token.Column
+		for _, v := range itf.Funcs {
+			if v.Name == fun.Name {
+				p.parseErr(itf.Name + "::" + fun.Name + " Redefine.")
+			}
+		}
+		p.expect(lex.TkPtl)
+		if p.peek().Type == lex.TkPtr {
+			p.next()
+		}
+		for p.token.Type != lex.TkPtr {
+			arg := ArgInfo{}
+			p.next()
+			if p.token.Type == lex.TkOut {
+				arg.IsOut = true
+				p.next()
+			}
+			arg.Type = p.parseType()
+			p.expect(lex.TkName)
+			arg.Name = p.token.Value.String
+			fun.Args = append(fun.Args, arg)
+
+			p.next()
+			if p.token.Type != lex.TkComma && p.token.Type != lex.TkPtr {
+				p.parseErr("expect , or )")
+			}
+		}
+		p.expect(lex.TkSemi)
+		itf.Funcs = append(itf.Funcs, fun)
+	}
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/lex"
+)
+
+func TestParseInterface(t *testing.T) {
+	src := `module test
+{
+    struct Req
+    {
+        0 require string name;
+    };
+
+    // 服务
+    interface Service
+    {
+        // 发送
+        int send(Req req, out vector<string> rsp);
+        void ping();
+        map<string, Req> query(out int total);
+    };
+};
+`
+	p, err := ParseSource("test.jce", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Interfaces) != 1 {
+		t.Fatalf("expect 1 interface, got %d", len(p.Interfaces))
+	}
+	itf := p.Interfaces[0]
+	if itf.Name != "Service" || !strings.Contains(itf.Comment, "服务") || itf.Line != 9 || len(itf.Funcs) != 3 {
+		t.Fatalf("unexpected interface %+v", itf)
+	}
+
+	send := itf.Funcs[0]
+	if send.Name != "send" || !strings.Contains(send.Comment, "发送") || send.RetType == nil || send.RetType.Type != lex.TkTInt || send.Line != 12 {
+		t.Errorf("unexpected method %+v", send)
+	}
+	if len(send.Args) != 2 || send.Args[0].Name != "req" || send.Args[0].IsOut || send.Args[0].Type.String() != "Req" ||
+		send.Args[1].Name != "rsp" || !send.Args[1].IsOut || send.Args[1].Type.String() != "vector<string>" {
+		t.Errorf("unexpected args %+v", send.Args)
+	}
+
+	ping := itf.Funcs[1]
+	if ping.Name != "ping" || ping.RetType != nil || len(ping.Args) != 0 {
+		t.Errorf("unexpected method %+v", ping)
+	}
+
+	query := itf.Funcs[2]
+	if query.RetType == nil || query.RetType.String() != "map<string, Req>" || len(query.Args) != 1 || !query.Args[0].IsOut {
+		t.Errorf("unexpected method %+v", query)
+	}
+}
+
+func TestParseInterfaceError(t *testing.T) {
+	for _, c := range []struct{ body, err string }{
+		{"interface S { void a(); void a(); };", "S::a Redefine"},
+		{"interface S { void a(); }; interface S { void b(); };", "S Redefine"},
+		{"interface S { void a(int x int y); };", "expect , or )"},
+		{"interface S { void a(int x); }", ""},
+		{"interface S { void a(", ""},
+	} {
+		_, err := ParseSource("test.jce", []byte("module test {\n"+c.body+"\n};\n"))
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expect error %q, got %v", c.body, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package parser
+
+import "strings"
+
+// 对外提供的符号查找，供 lsp、rename 等工具按 module::Name 在 include 链中定位定义
+
+// qualify 把不带 module 的名字补全为 module::Name
+func (p *Parser) qualify(name string) string {
+	if strings.Contains(name, "::") {
+		return name
+	}
+	return p.Module + "::" + name
+}
+
+// FindStruct 在当前文件及其 include 的文件中查找结构体定义，返回定义所在的语法树和结构体信息。
+// name 可以是 module::Name，不带 module 时在当前 module 中查找。
+func (p *Parser) FindStruct(name string) (*Parser, *StructInfo) {
+	name = p.qualify(name)
+	for i := range p.Structs {
+		if p.Module+"::"+p.Structs[i].Name == name {
+			return p, &p.Structs[i]
+		}
+	}
+
+	for _, pInc := range p.IncParse {
+		if fp, st := pInc.FindStruct(name); st != nil {
+			return fp, st
+		}
+	}
+	return nil, nil
+}
+
+// FindEnum 在当前文件及其 include 的文件中查找枚举定义，返回定义所在的语法树和枚举信息。
+func (p *Parser) FindEnum(name string) (*Parser, *EnumInfo) {
+	name = p.qualify(name)
+	for i := range p.Enums {
+		if p.Module+"::"+p.Enums[i].Name == name {
+			return p, &p.Enums[i]
+		}
+	}
+
+	for _, pInc := range p.IncParse {
+		if fp, en := pInc.FindEnum(name); en != nil {
+			return fp, en
+		}
+	}
+	return nil, nil
+}
+
+// FindEnumMember 查找枚举成员，name 可以是成员名，也可以是 module::成员名。
+// 和 jce 的语义一致，不带 module 时在所有可见的枚举中查找，返回第一个匹配的成员。
+func (p *Parser) FindEnumMember(name string) (*Parser, *EnumInfo, *EnumMember) {
+	module := ""
+	if i := strings.Index(name, "::"); i >= 0 {
+		module, name = name[:i], name[i+2:]
+	}
+
+	if module == "" || module == p.Module {
+		for i := range p.Enums {
+			for j := range p.Enums[i].Member {
+				mb := &p.Enums[i].Member[j]
+				if mb.Type != EnumTypeComment && mb.Key == name {
+					return p, &p.Enums[i], mb
+				}
+			}
+		}
+	}
+
+	if module != "" {
+		name = module + "::" + name
+	}
+	for _, pInc := range p.IncParse {
+		if fp, en, mb := pInc.FindEnumMember(name); mb != nil {
+			return fp, en, mb
+		}
+	}
+	return nil, nil, nil
+}
+
+// Visit 依次访问当前语法树以及所有 include 的语法树，每个文件只访问一次
+func (p *Parser) Visit(fn func(*Parser)) {
+	seen := map[string]bool{}
+	var walk func(*Parser)
+	walk = func(cur *Parser) {
+		if seen[cur.Filepath] {
+			return
+		}
+		seen[cur.Filepath] = true
+		fn(cur)
+		for _, inc := range cur.IncParse {
+			walk(inc)
+		}
+	}
+	walk(p)
+}
+
+// Symbol 语法树中的一个符号定义，Parser 为定义所在的语法树
+type Symbol struct {
+	Parser     *Parser
+	Struct     *StructInfo
+	Member     *StructMember // Struct 的成员
+	Enum       *EnumInfo
+	EnumMember *EnumMember // Enum 的成员
+	Const      *ConstInfo
+}
+
+// Position 返回符号定义的行列号（从 1 开始）和名字
+func (sym *Symbol) Position() (line, column int, name string) {
+	switch {
+	case sym.Member != nil:
+		return sym.Member.Line, sym.Member.Column, sym.Member.Key
+	case sym.Struct != nil:
+		return sym.Struct.Line, sym.Struct.Column, sym.Struct.Name
+	case sym.EnumMember != nil:
+		return sym.EnumMember.Line, sym.EnumMember.Column, sym.EnumMember.Key
+	case sym.Enum != nil:
+		return sym.Enum.Line, sym.Enum.Column, sym.Enum.Name
+	case sym.Const != nil:
+		return sym.Const.Line, sym.Const.Column, sym.Const.Name
+	}
+	return 0, 0, ""
+}
+
+// DeclarationAt 查找当前文件中定义在 line:column 处的符号
+func (p *Parser) DeclarationAt(line, column int) *Symbol {
+	at := func(l, c int) bool { return l == line && c == column }
+
+	for i := range p.Structs {
+		st := &p.Structs[i]
+		if at(st.Line, st.Column) {
+			return &Symbol{Parser: p, Struct: st}
+		}
+		for j := range st.Member {
+			if mb := &st.Member[j]; mb.CommentType == "" && at(mb.Line, mb.Column) {
+				return &Symbol{Parser: p, Struct: st, Member: mb}
+			}
+		}
+	}
+	for i := range p.Enums {
+		en := &p.Enums[i]
+		if at(en.Line, en.Column) {
+			return &Symbol{Parser: p, Enum: en}
+		}
+		for j := range en.Member {
+			if mb := &en.Member[j]; mb.Type != EnumTypeComment && at(mb.Line, mb.Column) {
+				return &Symbol{Parser: p, Enum: en, EnumMember: mb}
+			}
+		}
+	}
+	for i := range p.Consts {
+		if cst := &p.Consts[i]; at(cst.Line, cst.Column) {
+			return &Symbol{Parser: p, Const: cst}
+		}
+	}
+	return nil
+}
+
+// Resolve 按名字查找代码中引用的符号，依次尝试结构体、枚举、枚举成员
+func (p *Parser) Resolve(name string) *Symbol {
+	if fp, st := p.FindStruct(name); st != nil {
+		return &Symbol{Parser: fp, Struct: st}
+	}
+	if fp, en := p.FindEnum(name); en != nil {
+		return &Symbol{Parser: fp, Enum: en}
+	}
+	if fp, en, mb := p.FindEnumMember(name); mb != nil {
+		return &Symbol{Parser: fp, Enum: en, EnumMember: mb}
+	}
+	return nil
+}
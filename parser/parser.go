@@ -28,6 +28,8 @@ type Parser struct {
 	Consts  []ConstInfo  // 常量信息列表
 	Structs []StructInfo // 结构体信息列表
 
+	Interfaces []InterfaceInfo // 接口信息列表
+
 	comments []lex.Token // 临时存储的注释
 
 	// have parsed include file
@@ -56,8 +58,10 @@ func newParse(filepath string, source []byte, incChain []string) *Parser {
 	}
 
 	for _, v := range incChain {
-		if filepath == v {
-			panic("jce circular reference: " + filepath)
+		if path.Clean(filepath) == path.Clean(v) {
+			// 报在 include 了 filepath 的文件上，ParseSource 可以作为 *lex.Error 返回
+			chain := strings.Join(append(append([]string{}, incChain...), filepath), " -> ")
+			panic(&lex.Error{Filename: incChain[len(incChain)-1], Msg: "jce circular reference: " + chain})
 		}
 	}
 
@@ -76,7 +80,7 @@ func newParse(filepath string, source []byte, incChain []string) *Parser {
 func ParseFile(filePath string, incChain []string) *Parser {
 	b, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		log.Error("read file %s failed, err:%s", filePath, err)
+		panic(&lex.Error{Filename: filePath, Msg: "read file failed, " + err.Error()})
 	}
 
 	p := newParse(filePath, b, incChain)
@@ -86,6 +90,24 @@ func ParseFile(filePath string, incChain []string) *Parser {
 	return p
 }
 
+// ParseSource 解析内存中的 jce 源码，include 的文件仍然从磁盘读取。
+// 与 ParseFile 不同，词法、语法错误不会 panic，而是以 *lex.Error 的形式返回，便于编辑器等工具使用。
+func ParseSource(filePath string, source []byte) (p *Parser, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			le, ok := e.(*lex.Error)
+			if !ok {
+				panic(e)
+			}
+			p, err = nil, le
+		}
+	}()
+
+	p = newParse(filePath, source, nil)
+	p.parse()
+	return p, nil
+}
+
 // parse 方法是 Parser 结构的一个成员方法，用于执行语法分析。它遍历由词法分析器生成的 token，并根据 token 的类型调用相应的处理方法。以下是方法的主要步骤：
 
 // 使用 for 循环遍历 token。在每次迭代中，调用 p.next() 方法获取下一个 token，并将其存储在局部变量 t 中。
@@ -139,14 +161,16 @@ func (p *Parser) expect(t lex.TokenType) {
 	}
 }
 
-// parseErr 方法接受一个错误字符串 err 作为参数，并引发一个 panic，其中包含发生错误的文件路径和行号。这个方法在解析过程中遇到错误时被调用。
+// parseErr 方法接受一个错误字符串 err 作为参数，并引发一个 panic，panic 的值是包含文件路径和行列号的 *lex.Error。这个方法在解析过程中遇到错误时被调用。
 func (p *Parser) parseErr(err string) {
-	line := "0"
+	e := &lex.Error{Filename: p.Filepath, Msg: err}
 	if p.token != nil {
-		line = strconv.Itoa(p.token.Line)
+		e.Line = p.token.Line
+		e.Column = p.token.Column
 	}
 
-	log.Error("[" + p.Filepath + ":" + line + "]" + err)
+	log.Debug("[" + p.Filepath + ":" + strconv.Itoa(e.Line) + "]" + err)
+	panic(e)
 }
 
 // parseInclude 方法用于处理包含指令。它首先调用 expect 方法，期望下一个 token 是一个字符串。然后，将该字符串添加到 Includes 字段中。
@@ -191,6 +215,7 @@ func (p *Parser) parseModule() {
 				incParse.Structs = append(incParse.Structs, newp.Structs...)
 				incParse.Enums = append(incParse.Enums, newp.Enums...)
 				incParse.Consts = append(incParse.Consts, newp.Consts...)
+				incParse.Interfaces = append(incParse.Interfaces, newp.Interfaces...)
 				break
 			}
 		}
@@ -223,6 +248,8 @@ func (p *Parser) parseModuleSegment() {
 			p.parseEnum()
 		case lex.TkStruct: //  如果 token 类型为 lex.TkStruct，则调用 parseStruct 方法处理结构体声明
 			p.parseStruct()
+		case lex.TkInterface: // 接口只记录定义
+			p.parseInterface()
 		case lex.TkComment: // 注释暂存
 			p.comments = append(p.comments, *p.token)
 		default: // 对于其他 token 类型，引发一个解析错误，指出不期望的 token 类型
@@ -265,6 +292,7 @@ func (p *Parser) parseConst() {
 	// 使用 expect 方法检查下一个 token 是否为名称，并将其存储在 m.Name 中。
 	p.expect(lex.TkName)
 	consts.Name = p.token.Value.String
+	consts.Line, consts.Column = p.token.Line, p.token.Column
 
 	// 使用 expect 方法检查下一个 token 是否为等号（lex.TkEq）。
 	p.expect(lex.TkEq)
@@ -320,6 +348,7 @@ func (p *Parser) parseEnum() {
 	// 使用 expect 方法检查下一个 token 是否为名称，并将其存储在 enum.Name 中。
 	p.expect(lex.TkName)
 	enum.Name = p.token.Value.String
+	enum.Line, enum.Column = p.token.Line, p.token.Column
 	// 遍历已解析的枚举列表，检查是否有与当前枚举名称相同的枚举。
 	for _, v := range p.Enums {
 		// 如果有重复的枚举名称，引发一个解析错误。
@@ -354,10 +383,11 @@ LFOR:
 			break LFOR
 		case lex.TkName: // 如果 token 类型为 lex.TkName，则获取成员名称，并根据下一个 token 的类型设置成员值。成员值可以是整数、名称或未指定。
 			k := p.token.Value.String
+			line, column := p.token.Line, p.token.Column
 			p.next()
 			switch p.token.Type {
 			case lex.TkComma: // ,
-				m := EnumMember{Key: k, Type: EnumTypeEqual}
+				m := EnumMember{Key: k, Type: EnumTypeEqual, Line: line, Column: column}
 				t := p.peek()
 				if t.Type == lex.TkComment { // 枚举支持一个注释
 					m.Comment = t.Value.String
@@ -365,7 +395,7 @@ LFOR:
 				}
 				enum.Member = append(enum.Member, m)
 			case lex.TkBraceRight: // }
-				m := EnumMember{Key: k, Type: EnumTypeEqual}
+				m := EnumMember{Key: k, Type: EnumTypeEqual, Line: line, Column: column}
 				enum.Member = append(enum.Member, m)
 				break LFOR
 			case lex.TkEq: // =
@@ -379,6 +409,7 @@ LFOR:
 				default:
 					p.parseErr("not expect " + lex.TokenMap[p.token.Type])
 				}
+				m.Line, m.Column = line, column
 				p.next()
 				if p.token.Type == lex.TkBraceRight { // }
 					enum.Member = append(enum.Member, m)
@@ -393,14 +424,18 @@ LFOR:
 				} else {
 					p.parseErr("expect , or }")
 				}
+			default:
+				p.parseErr("expect , = or }")
 			}
 		case lex.TkComment:
 			m := EnumMember{Type: 3, Comment: p.token.Value.String}
 			enum.Member = append(enum.Member, m)
 
+		case lex.TkEos: // 文件在枚举定义中结束，如编辑器中正在输入的内容
+			p.parseErr("expect }")
 		default:
 			// 对于其他 token 类型，引发一个解析错误，指出不期望的 token 类型。
-
+			p.parseErr("not expect " + lex.TokenMap[p.token.Type])
 		}
 
 	}
@@ -423,6 +458,7 @@ func (p *Parser) parseStruct() {
 	// 使用 expect 方法检查下一个 token 是否为名称，并将其存储在 st.Name 中。
 	p.expect(lex.TkName)
 	st.Name = p.token.Value.String
+	st.Line, st.Column = p.token.Line, p.token.Column
 
 	log.Debug("1")
 
@@ -531,6 +567,7 @@ func (p *Parser) parseStructMember() *StructMember {
 	// key
 	p.expect(lex.TkName)
 	m.Key = p.token.Value.String
+	m.Line, m.Column = p.token.Line, p.token.Column
 
 	log.Debug("8")
 	// 获取下一个 token。根据 token 的类型，处理成员的默认值、数组类型或其他情况。如果遇到不符合预期的 token 类型，引发一个解析错误。
@@ -652,6 +689,7 @@ func (p *Parser) parseStructMemberDefault(m *StructMember) {
 	default:
 		p.parseErr("default value format error")
 	}
+	m.OriginDefault = m.Default
 }
 
 func (p *Parser) checkTag(st *StructInfo) {
@@ -781,6 +819,14 @@ func (p *Parser) analyzeTName() {
 			p.checkDepTName(ty, &p.Structs[i].DependModule, &p.Structs[i].DependModuleWithJce)
 		}
 	}
+	for i, itf := range p.Interfaces {
+		for _, fun := range itf.Funcs {
+			p.checkDepTName(fun.RetType, &p.Interfaces[i].DependModule, &p.Interfaces[i].DependModuleWithJce)
+			for _, arg := range fun.Args {
+				p.checkDepTName(arg.Type, &p.Interfaces[i].DependModule, &p.Interfaces[i].DependModuleWithJce)
+			}
+		}
+	}
 
 	log.Debug("end analyzeTName")
 }
@@ -795,7 +841,8 @@ func (p *Parser) analyzeDefault() {
 					p.parseErr("can not find default value" + r.Default)
 				}
 
-				defValue := enum.Name + "_" + utils.UpperFirstLetter(mb.Key)
+				// 和生成的枚举常量同名
+				defValue := utils.UpperFirstLetter(enum.Name) + utils.UpperFirstLetter(mb.Key)
 
 				var currModule string
 				currModule = p.Module
@@ -2,7 +2,13 @@ package parser
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/erpc-go/jce2go/lex"
 )
 
 func Test_newParse(t *testing.T) {
@@ -11,3 +17,88 @@ func Test_newParse(t *testing.T) {
 	// fmt.Printf("%+v\n", p.Consts)
 	fmt.Printf("%+v\n", p.Enums)
 }
+
+func TestCircularInclude(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.jce")
+	b := filepath.Join(dir, "b.jce")
+	srcA := "#include \"b.jce\"\nmodule a\n{\n    struct A { 0 require int id; };\n};\n"
+	srcB := "#include \"./a.jce\"\nmodule b\n{\n    struct B { 0 require int id; };\n};\n"
+	for name, src := range map[string]string{a: srcA, b: srcB} {
+		if err := ioutil.WriteFile(name, []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := ParseSource(a, []byte(srcA))
+	le, ok := err.(*lex.Error)
+	if !ok {
+		t.Fatalf("expected *lex.Error, got %v", err)
+	}
+	if le.Filename != b || !strings.Contains(le.Msg, "jce circular reference: ") {
+		t.Errorf("unexpected error %+v", le)
+	}
+}
+
+func TestEnumDefault(t *testing.T) {
+	dir := t.TempDir()
+	base := "module base\n{\n    enum EMsgSendType { eSendTypeOnline = 1, eSendTypeOffline };\n};\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "base.jce"), []byte(base), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	src := `#include "base.jce"
+module test
+{
+    enum color { red = 1, green };
+    struct Packet
+    {
+        0 optional color c = green;
+        1 optional base::EMsgSendType t = base::eSendTypeOffline;
+    };
+};
+`
+	filename := filepath.Join(dir, "test.jce")
+	p, err := ParseSource(filename, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 和生成的 go 代码中的枚举常量同名
+	mbs := p.Structs[0].Member
+	if mbs[0].Default != "ColorGreen" || mbs[1].Default != "base.EMsgSendTypeESendTypeOffline" {
+		t.Errorf("unexpected defaults %q %q", mbs[0].Default, mbs[1].Default)
+	}
+}
+
+// 编辑器中输入到一半的内容需要报错，不能死循环
+func TestTruncated(t *testing.T) {
+	for _, src := range []string{
+		"enum Color {",
+		"enum Color { Red",
+		"enum Color { Red =",
+		"enum Color { Red = 1,",
+		"enum Color { Red = 1, // 红\n",
+		"enum Color { Red 1 };",
+		"enum Color { 1 };",
+		"enum Color { Red = 1 }",
+		"struct A {",
+		"struct A { 0 require int",
+		"struct A { 0 require int id;",
+		"interface S {",
+		"interface S { void f(int a,",
+		"",
+	} {
+		done := make(chan error, 1)
+		go func() {
+			_, err := ParseSource("test.jce", []byte("module test {\n"+src))
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if _, ok := err.(*lex.Error); !ok {
+				t.Errorf("%q: expected *lex.Error, got %v", src, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%q: parser does not return", src)
+		}
+	}
+}
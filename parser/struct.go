@@ -7,15 +7,18 @@ import (
 
 // StructMember member struct.
 type StructMember struct {
-	CommentType string
-	Tag         int32
-	Require     bool
-	Type        *VarType
-	Key         string // after the uppercase converted key
-	OriginKey   string // original key
-	Default     string
-	DefType     lex.TokenType
-	Comment     string
+	CommentType   string
+	Tag           int32
+	Require       bool
+	Type          *VarType
+	Key           string // after the uppercase converted key
+	OriginKey     string // original key
+	Default       string
+	OriginDefault string // 源码中的默认值，枚举默认值为成员名（Default 会被改写为 go 代码中的名字）
+	DefType       lex.TokenType
+	Comment       string
+	Line          int // 成员名所在的行号
+	Column        int // 成员名所在的列号
 }
 
 // StructMemberSorter When serializing, make sure the tags are ordered.
@@ -33,6 +36,8 @@ type StructInfo struct {
 	commentTagNum       int
 	DependModule        map[string]bool
 	DependModuleWithJce map[string]string
+	Line                int // 结构体名所在的行号
+	Column              int // 结构体名所在的列号
 }
 
 // 1. struct Rename
@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/erpc-go/jce2go/lex"
+import (
+	"strconv"
+
+	"github.com/erpc-go/jce2go/lex"
+)
 
 // VarType contains variable type(token)
 type VarType struct {
@@ -12,3 +16,29 @@ type VarType struct {
 	TypeV    *VarType      // the value of map
 	TypeL    int64         // length of array
 }
+
+// String 返回 jce 源码中的类型写法，如 vector<map<int, string>>、unsigned byte、base::request
+func (t *VarType) String() string {
+	if t == nil {
+		return ""
+	}
+
+	s := ""
+	switch t.Type {
+	case lex.TkName:
+		return t.TypeSt
+	case lex.TkTVector:
+		s = "vector<" + t.TypeK.String() + ">"
+	case lex.TkTMap:
+		s = "map<" + t.TypeK.String() + ", " + t.TypeV.String() + ">"
+	case lex.TkTArray:
+		s = t.TypeK.String() + "[" + strconv.FormatInt(t.TypeL, 10) + "]"
+	default:
+		s = lex.TokenMap[t.Type]
+	}
+
+	if t.Unsigned {
+		s = "unsigned " + s
+	}
+	return s
+}
@@ -0,0 +1,159 @@
+// Package plugin 外部代码生成插件的协议，类似 protoc 的插件机制
+//
+// jce2go --plugin=ts=path/to/gen-ts --ts_out=dir test.jce：
+// 1. 启动插件，把 Request（json）写入插件的标准输入，包含完整解析后的 schema（见 descriptor 包）
+// 2. 插件把 Response（json）写到标准输出，包含要生成的文件以及诊断信息
+// 3. 没有 error 级别的诊断时，jce2go 把文件写到 dir 中
+//
+// 没有通过 --plugin 指定路径时，在 PATH 中查找名为 jce2go-gen-NAME 的可执行文件。
+// go 写的插件可以直接使用 Main。
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+)
+
+// Prefix 默认插件可执行文件名的前缀
+const Prefix = "jce2go-gen-"
+
+// Request 发给插件的请求
+type Request struct {
+	Version         string          `json:"version"`         // jce2go 的版本
+	FilesToGenerate []string        `json:"filesToGenerate"` // 命令行中指定的 jce 文件，schema 中还包括它们 include 的文件
+	Parameter       string          `json:"parameter"`       // --NAME_opt 指定的参数
+	Schema          *descriptor.Set `json:"schema"`
+}
+
+// File 插件生成的文件
+type File struct {
+	Name    string `json:"name"` // 相对于输出目录的路径，不能是绝对路径或者跳出输出目录
+	Content string `json:"content"`
+}
+
+// 诊断的级别
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic 插件给出的诊断信息，File、Line、Column 可选
+type Diagnostic struct {
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // error 或 warning
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	pos := d.File
+	if pos != "" && d.Line > 0 {
+		pos += fmt.Sprintf(":%d", d.Line)
+		if d.Column > 0 {
+			pos += fmt.Sprintf(":%d", d.Column)
+		}
+	}
+	if pos != "" {
+		pos += ": "
+	}
+	return pos + d.Severity + ": " + d.Message
+}
+
+// Response 插件的响应
+type Response struct {
+	Files       []File       `json:"files"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// HasError 是否有 error 级别的诊断
+func (r *Response) HasError() bool {
+	for _, d := range r.Diagnostics {
+		if d.Severity != SeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// LookPath 查找插件的可执行文件，path 为空时在 PATH 中查找 jce2go-gen-NAME
+func LookPath(name, path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	return exec.LookPath(Prefix + name)
+}
+
+// Run 运行插件，插件的标准错误直接输出到 stderr
+func Run(path string, req *Request, stderr io.Writer) (*Response, error) {
+	in, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stdout = &out
+	cmd.Stderr = stderr
+	if err = cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %v", path, err)
+	}
+
+	resp := &Response{}
+	if err = json.Unmarshal(out.Bytes(), resp); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid response: %v", path, err)
+	}
+	return resp, nil
+}
+
+// Write 把生成的文件写到 outdir，返回写入的文件路径
+func (r *Response) Write(outdir string) ([]string, error) {
+	// 先检查所有文件名，避免只写入一部分
+	paths := make([]string, len(r.Files))
+	for i, f := range r.Files {
+		name := filepath.Clean(filepath.FromSlash(f.Name))
+		if f.Name == "" || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("invalid file name %q", f.Name)
+		}
+		paths[i] = filepath.Join(outdir, name)
+	}
+
+	for i, f := range r.Files {
+		if err := os.MkdirAll(filepath.Dir(paths[i]), 0o766); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(paths[i], []byte(f.Content), 0o666); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// Main 供 go 写的插件使用：从标准输入读请求，调用 gen，把响应写到标准输出。
+// gen 返回 error 时作为 error 级别的诊断返回给 jce2go
+func Main(gen func(req *Request) (*Response, error)) {
+	req := &Request{}
+	if err := json.NewDecoder(os.Stdin).Decode(req); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	resp, err := gen(req)
+	if err != nil {
+		resp = &Response{Diagnostics: []Diagnostic{{Severity: SeverityError, Message: err.Error()}}}
+	}
+	if err = json.NewEncoder(os.Stdout).Encode(resp); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+package plugin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+)
+
+// 测试二进制本身作为插件运行
+func TestMain(m *testing.M) {
+	if os.Getenv("JCE2GO_TEST_PLUGIN") == "1" {
+		Main(func(req *Request) (*Response, error) {
+			resp := &Response{}
+			for _, f := range req.Schema.Files {
+				for _, st := range f.Structs {
+					resp.Files = append(resp.Files, File{Name: f.Module + "/" + st.Name + ".txt", Content: req.Parameter + st.Name})
+				}
+			}
+			resp.Diagnostics = append(resp.Diagnostics, Diagnostic{File: "a.jce", Line: 1, Severity: SeverityWarning, Message: "hello"})
+			return resp, nil
+		})
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func TestRun(t *testing.T) {
+	os.Setenv("JCE2GO_TEST_PLUGIN", "1")
+	defer os.Unsetenv("JCE2GO_TEST_PLUGIN")
+
+	req := &Request{
+		Parameter: "struct ",
+		Schema: &descriptor.Set{Files: []*descriptor.File{
+			{Name: "a.jce", Module: "a", Structs: []*descriptor.Struct{{Name: "A"}, {Name: "B"}}},
+		}},
+	}
+	var stderr bytes.Buffer
+	resp, err := Run(os.Args[0], req, &stderr)
+	if err != nil {
+		t.Fatalf("%v: %s", err, stderr.String())
+	}
+	if resp.HasError() || len(resp.Diagnostics) != 1 || resp.Diagnostics[0].String() != "a.jce:1: warning: hello" {
+		t.Fatalf("unexpected diagnostics %v", resp.Diagnostics)
+	}
+
+	dir := t.TempDir()
+	written, err := resp.Write(dir)
+	if err != nil || len(written) != 2 {
+		t.Fatal(written, err)
+	}
+	if b, _ := ioutil.ReadFile(filepath.Join(dir, "a", "B.txt")); string(b) != "struct B" {
+		t.Fatalf("unexpected content %q", b)
+	}
+}
+
+func TestWriteInvalidName(t *testing.T) {
+	for _, name := range []string{"", "../x", "/etc/x", "a/../../x"} {
+		resp := &Response{Files: []File{{Name: "ok.txt"}, {Name: name}}}
+		dir := t.TempDir()
+		if _, err := resp.Write(dir); err == nil || !strings.Contains(err.Error(), "invalid file name") {
+			t.Errorf("%q: got %v", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "ok.txt")); err == nil {
+			t.Errorf("%q: files written before validation", name)
+		}
+	}
+}
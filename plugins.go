@@ -0,0 +1,140 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/log"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// --plugin=NAME=PATH，可以指定多次
+type pluginFlag map[string]string
+
+func (f pluginFlag) String() string {
+	var s []string
+	for name, p := range f {
+		s = append(s, name+"="+p)
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
+}
+
+func (f pluginFlag) Set(s string) error {
+	name, p := s, ""
+	if i := strings.Index(s, "="); i >= 0 {
+		name, p = s[:i], s[i+1:]
+	}
+	if name == "" {
+		return fmt.Errorf("invalid plugin %q, expect NAME=PATH", s)
+	}
+	f[name] = p
+	return nil
+}
+
+// splitPluginArgs 取出插件的 --NAME_out=DIR 和 --NAME_opt=PARAM 参数，这些参数的名字不固定，不能用 flag 定义。
+// fs 中已经定义过的 flag（如 --descriptor_set_out）保持不变，不是 bool 的 flag 的值在下一个参数中时一起保留
+func splitPluginArgs(fs *flag.FlagSet, args []string) (rest []string, outs, opts map[string]string) {
+	outs, opts = map[string]string{}, map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			rest = append(rest, args[i:]...)
+			break
+		}
+
+		name := strings.TrimLeft(arg, "-")
+		value, hasValue := "", false
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value, hasValue = name[:j], name[j+1:], true
+		}
+
+		if f := fs.Lookup(name); f != nil {
+			rest = append(rest, arg)
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) && i+1 < len(args) {
+				i++
+				rest = append(rest, args[i])
+			}
+			continue
+		}
+
+		var m map[string]string
+		switch {
+		case strings.HasSuffix(name, "_out"):
+			m = outs
+		case strings.HasSuffix(name, "_opt"):
+			m = opts
+		}
+		if m == nil {
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		m[name[:len(name)-4]] = value
+	}
+	return rest, outs, opts
+}
+
+// runPlugins 按 --NAME_out 运行每个插件，把生成的文件写到对应的目录
+func runPlugins(plugins pluginFlag, outs, opts map[string]string, files []string) error {
+	var jces []string
+	for _, f := range files {
+		if path.Ext(f) == ".jce" {
+			jces = append(jces, f)
+		}
+	}
+	ps, err := parseInputs(jces)
+	if err != nil {
+		return err
+	}
+	set := descriptor.Build(ps...)
+
+	names := make([]string, 0, len(outs))
+	for name := range outs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		exe, err := plugin.LookPath(name, plugins[name])
+		if err != nil {
+			return fmt.Errorf("plugin %s: %v, specify it with --plugin=%s=PATH", name, err, name)
+		}
+
+		resp, err := plugin.Run(exe, &plugin.Request{
+			Version:         version.VERSION,
+			FilesToGenerate: jces,
+			Parameter:       opts[name],
+			Schema:          set,
+		}, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range resp.Diagnostics {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, d)
+		}
+		if resp.HasError() {
+			return fmt.Errorf("plugin %s failed", name)
+		}
+
+		written, err := resp.Write(outs[name])
+		if err != nil {
+			return fmt.Errorf("plugin %s: %v", name, err)
+		}
+		for _, f := range written {
+			log.Raw("[ok]%s -> %s\n", name, f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestSplitPluginArgs(t *testing.T) {
+	fs := flag.NewFlagSet("jce2go", flag.ContinueOnError)
+	fs.String("o", "", "")
+	fs.Bool("json", false, "")
+	fs.String("descriptor_set_out", "", "")
+
+	for _, c := range []struct {
+		args       []string
+		rest       []string
+		outs, opts map[string]string
+	}{
+		{
+			args: []string{"-o", "out", "--foo_out=dir", "x.jce"},
+			rest: []string{"-o", "out", "x.jce"},
+			outs: map[string]string{"foo": "dir"},
+			opts: map[string]string{},
+		},
+		{
+			args: []string{"-json", "--foo_out", "dir", "--foo_opt=a=1", "-descriptor_set_out", "s.json", "x.jce", "--bar_out=y"},
+			rest: []string{"-json", "-descriptor_set_out", "s.json", "x.jce", "--bar_out=y"},
+			outs: map[string]string{"foo": "dir"},
+			opts: map[string]string{"foo": "a=1"},
+		},
+		{
+			// 值和 --NAME_out 同名时不能当作插件的参数
+			args: []string{"-o", "--foo_out", "x.jce"},
+			rest: []string{"-o", "--foo_out", "x.jce"},
+			outs: map[string]string{},
+			opts: map[string]string{},
+		},
+	} {
+		rest, outs, opts := splitPluginArgs(fs, c.args)
+		if !reflect.DeepEqual(rest, c.rest) || !reflect.DeepEqual(outs, c.outs) || !reflect.DeepEqual(opts, c.opts) {
+			t.Errorf("%q: got %q %v %v", c.args, rest, outs, opts)
+		}
+	}
+}
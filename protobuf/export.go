@@ -0,0 +1,306 @@
+// Package protobuf 在 jce 和 proto3 之间转换 schema
+//
+// Export 把 jce 文件转换为 .proto 文件：
+//
+//	module           package，可以加上统一的前缀
+//	#include         import
+//	struct           message，tag 作为字段编号
+//	vector<T>        repeated T，vector<byte> 为 bytes
+//	map<K, V>        map<K, V>
+//	enum             enum，没有取值为 0 的成员时插入 XXX_UNSPECIFIED = 0
+//
+// 没有对应写法的内容转换后给出警告：固定长度的 array、默认值、常量、tag 0、
+// proto 不支持的 map key 以及嵌套的容器
+//
+// Import 把 .proto 文件转换为 jce 文件，不支持的内容直接报错
+package protobuf
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// Warning 没有对应写法的内容
+type Warning struct {
+	File    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.File, w.Message)
+}
+
+// ExportOptions 转换的参数
+type ExportOptions struct {
+	// Package package 的前缀，如 com.foo 时 module test 对应 package com.foo.test
+	Package string
+}
+
+// Export 把 set 中所有文件转换为同名的 .proto 文件
+func Export(set *descriptor.Set, opts ExportOptions) ([]plugin.File, []Warning, error) {
+	e := &exporter{opts: opts}
+	var files []plugin.File
+	seen := map[string]string{}
+	for _, f := range set.Files {
+		name := FileName(f.Name, ".proto")
+		if prev, ok := seen[name]; ok {
+			return nil, nil, fmt.Errorf("%s and %s both generate %s", prev, f.Name, name)
+		}
+		seen[name] = f.Name
+		files = append(files, plugin.File{Name: name, Content: e.file(f)})
+	}
+	return files, e.warnings, nil
+}
+
+// FileName test.jce 转换为 test.proto，ext 为新的后缀
+func FileName(name, ext string) string {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	return strings.TrimSuffix(base, path.Ext(base)) + ext
+}
+
+type exporter struct {
+	opts     ExportOptions
+	warnings []Warning
+
+	f *descriptor.File
+	p *lang.Printer
+	// 当前 message 需要生成的包装 message
+	wrappers []*wrapper
+}
+
+func (e *exporter) warn(format string, args ...interface{}) {
+	e.warnings = append(e.warnings, Warning{File: e.f.Name, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *exporter) pkg(module string) string {
+	if e.opts.Package != "" {
+		return e.opts.Package + "." + module
+	}
+	return module
+}
+
+func (e *exporter) file(f *descriptor.File) string {
+	e.f, e.p = f, lang.NewPrinter("  ")
+	p := e.p
+	p.P("// code generated by jce2go %s.", version.VERSION)
+	p.P("// source: %s", path.Base(f.Name))
+	p.P("")
+	p.P(`syntax = "proto3";`)
+	p.P("")
+	p.P("package %s;", e.pkg(f.Module))
+	if len(f.Includes) > 0 {
+		p.P("")
+		for _, inc := range f.Includes {
+			p.P(`import "%s";`, FileName(inc, ".proto"))
+		}
+	}
+
+	for _, c := range f.Consts {
+		e.warn("const %s = %s has no proto equivalent, kept as a comment", c.Name, c.Value)
+	}
+	if len(f.Consts) > 0 {
+		p.P("")
+		for _, c := range f.Consts {
+			p.Raw(lang.Comment("// ", c.Comment))
+			p.P("// const %s %s = %s;", typeName(c.Type), c.Name, c.Value)
+		}
+	}
+	for _, en := range f.Enums {
+		e.enum(en)
+	}
+	for _, st := range f.Structs {
+		e.message(st)
+	}
+	return p.String()
+}
+
+// jce 中的类型写法，用于注释
+func typeName(t *descriptor.Type) string {
+	name := t.Kind
+	if t.IsUnsigned {
+		name = "unsigned " + name
+	}
+	switch t.Kind {
+	case "struct", "enum":
+		return strings.ReplaceAll(t.Name, ".", "::")
+	case "vector":
+		return "vector<" + typeName(t.Params[0]) + ">"
+	case "array":
+		return fmt.Sprintf("%s[%d]", typeName(t.Params[0]), t.Len)
+	case "map":
+		return "map<" + typeName(t.Params[0]) + ", " + typeName(t.Params[1]) + ">"
+	}
+	return name
+}
+
+func (e *exporter) enum(en *descriptor.Enum) {
+	p := e.p
+	p.P("")
+	p.Raw(lang.Comment("// ", en.Comment))
+	p.P("enum %s {", en.Name)
+	p.In()
+
+	// proto3 的第一个成员必须为 0
+	values := append([]*descriptor.EnumValue(nil), en.Values...)
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Value == 0 && values[j].Value != 0 })
+	if len(values) == 0 || values[0].Value != 0 {
+		zero := en.Name + "_UNSPECIFIED"
+		e.warn("enum %s has no zero value, inserted %s = 0", en.Name, zero)
+		values = append([]*descriptor.EnumValue{{Name: zero}}, values...)
+	}
+	seen := map[int32]bool{}
+	alias := false
+	for _, v := range values {
+		alias = alias || seen[v.Value]
+		seen[v.Value] = true
+	}
+	if alias {
+		p.P("option allow_alias = true;")
+	}
+	for _, v := range values {
+		p.Raw(lang.Comment("// ", v.Comment))
+		p.P("%s = %d;", v.Name, v.Value)
+	}
+	p.Out()
+	p.P("}")
+}
+
+func (e *exporter) message(st *descriptor.Struct) {
+	p := e.p
+	e.wrappers = nil
+
+	// proto 的字段编号从 1 开始，使用了 tag 0 时所有编号加 1
+	shift := int32(0)
+	for _, mb := range st.Members {
+		if mb.Tag == 0 {
+			shift = 1
+			e.warn("%s uses tag 0, which is not a valid field number; field numbers are tag + 1", st.Name)
+			break
+		}
+	}
+
+	p.P("")
+	p.Raw(lang.Comment("// ", st.Comment))
+	p.P("message %s {", st.Name)
+	p.In()
+	for _, mb := range st.Members {
+		p.Raw(lang.Comment("// ", mb.Comment))
+		if mb.Default != "" {
+			e.warn("%s.%s: default value %s dropped, proto3 fields default to zero", st.Name, mb.Name, mb.Default)
+			p.P("// default: %s", mb.Default)
+		}
+		p.P("%s %s = %d;", e.field(st.Name, mb.Name, mb.Type), mb.Name, mb.Tag+shift)
+	}
+	// 嵌套容器的包装 message 放在 message 内部
+	for _, w := range e.wrappers {
+		p.P("")
+		p.P("message %s {", w.name)
+		for _, f := range w.fields {
+			p.P("  %s", f)
+		}
+		p.P("}")
+	}
+	p.Out()
+	p.P("}")
+}
+
+// 字段的类型，包括 repeated
+func (e *exporter) field(st, name string, t *descriptor.Type) string {
+	switch {
+	case lang.IsBytes(t):
+		if t.Kind == "array" {
+			e.warn("%s.%s: fixed length %s exported as bytes", st, name, typeName(t))
+		}
+		return "bytes"
+	case t.Kind == "vector" || t.Kind == "array":
+		if t.Kind == "array" {
+			e.warn("%s.%s: fixed length %s exported as repeated", st, name, typeName(t))
+		}
+		return "repeated " + e.elem(st, name, t.Params[0])
+	case t.Kind == "map":
+		key := t.Params[0]
+		if !validKey(key) {
+			e.warn("%s.%s: %s is not a valid map key, exported as repeated entry message", st, name, typeName(key))
+			w := e.wrapper(lang.Upper(name) + "Entry")
+			w.fields = []string{e.elem(st, name, key) + " key = 1;", e.elem(st, name, t.Params[1]) + " value = 2;"}
+			return "repeated " + w.name
+		}
+		return "map<" + e.scalar(key) + ", " + e.elem(st, name, t.Params[1]) + ">"
+	}
+	return e.scalar(t)
+}
+
+// repeated、map value 的类型，嵌套的容器包装为 message
+func (e *exporter) elem(st, name string, t *descriptor.Type) string {
+	if (t.Kind != "vector" && t.Kind != "array" && t.Kind != "map") || lang.IsBytes(t) {
+		return e.scalar(t)
+	}
+	e.warn("%s.%s: nested %s wrapped in a message", st, name, typeName(t))
+	w := e.wrapper(lang.Upper(name) + "Item")
+	w.fields = []string{e.field(st, name, t) + " values = 1;"}
+	return w.name
+}
+
+// 包装 message，先占用名字再生成字段，字段中嵌套的容器使用其他名字
+type wrapper struct {
+	name   string
+	fields []string
+}
+
+func (e *exporter) wrapper(name string) *wrapper {
+	w := &wrapper{name: name}
+	for i := 2; e.hasWrapper(w.name); i++ {
+		w.name = fmt.Sprintf("%s%d", name, i)
+	}
+	e.wrappers = append(e.wrappers, w)
+	return w
+}
+
+func (e *exporter) hasWrapper(name string) bool {
+	for _, w := range e.wrappers {
+		if w.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// proto 的 map key 只能是整数、bool、string
+func validKey(t *descriptor.Type) bool {
+	switch t.Kind {
+	case "bool", "byte", "short", "int", "long", "string":
+		return true
+	}
+	return false
+}
+
+func (e *exporter) scalar(t *descriptor.Type) string {
+	switch t.Kind {
+	case "bool", "float", "double", "string":
+		return t.Kind
+	case "byte", "short", "int":
+		if t.IsUnsigned {
+			return "uint32"
+		}
+		return "int32"
+	case "long":
+		if t.IsUnsigned {
+			return "uint64"
+		}
+		return "int64"
+	case "struct", "enum":
+		m, short := lang.SplitName(t.Name)
+		if m == e.f.Module {
+			return short
+		}
+		return e.pkg(m) + "." + short
+	}
+	return "bytes"
+}
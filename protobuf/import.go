@@ -0,0 +1,720 @@
+package protobuf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/format"
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// Import 把 .proto 文件以及它 import 的文件转换为同名的 .jce 文件，输出经过格式化
+//
+// 支持 proto3 的以下内容：
+//
+//	package          module，. 替换为 _
+//	import           #include
+//	message          struct，字段编号作为 tag，所有字段都是 optional；嵌套的 message、enum 展开为 Outer_Inner
+//	repeated T       vector<T>
+//	map<K, V>        map<K, V>
+//	enum             enum
+//
+// 文件、message、enum 的 option 和 reserved 会被忽略，其他内容（proto2、oneof、service、extend、
+// 字段的 option、uint64 等 jce 没有的类型、google/protobuf 中的类型）都会报错。
+// import 的文件先在 importPaths 中查找，再在当前文件所在的目录查找
+func Import(filename string, importPaths []string) ([]plugin.File, error) {
+	im := &importer{paths: importPaths, files: map[string]*protoFile{}, types: map[string]*protoType{}}
+	root, err := im.load(filename, filepath.Dir(filename))
+	if err != nil {
+		return nil, err
+	}
+
+	var files []plugin.File
+	seen := map[string]string{}
+	var visit func(f *protoFile) error
+	visit = func(f *protoFile) error {
+		if _, ok := seen[f.out]; ok {
+			if seen[f.out] != f.path {
+				return fmt.Errorf("%s and %s both generate %s", seen[f.out], f.path, f.out)
+			}
+			return nil
+		}
+		seen[f.out] = f.path
+		for _, dep := range f.deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		src, err := im.gen(f)
+		if err != nil {
+			return err
+		}
+		files = append(files, plugin.File{Name: f.out, Content: string(src)})
+		return nil
+	}
+	if err := visit(root); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// proto 文件
+type protoFile struct {
+	path    string // 读取的路径
+	name    string // import 时使用的名字
+	out     string // 输出的 .jce 文件名
+	pkg     string
+	module  string
+	imports []string
+	deps    []*protoFile
+	types   []*protoType // 按定义的顺序，嵌套的类型在外层之后
+}
+
+// message、enum
+type protoType struct {
+	file    *protoFile
+	full    string // 全名，pkg.Outer.Inner
+	name    string // 展开后的名字，Outer_Inner
+	scope   string // 查找引用的作用域，即 full
+	enum    bool
+	comment string
+	line    int
+	fields  []*protoField
+	values  []*protoValue
+}
+
+type protoField struct {
+	name     string
+	repeated bool
+	typ      string
+	key      string // map 的 key，非 map 时为空
+	number   int
+	comment  string
+	line     int
+}
+
+type protoValue struct {
+	name    string
+	value   string
+	comment string
+}
+
+type importer struct {
+	paths []string
+	files map[string]*protoFile // 按读取的路径
+	types map[string]*protoType // 按全名
+	// 正在加载的文件，用于发现循环 import
+	loading []string
+}
+
+// 在 import 路径中查找文件
+func (im *importer) resolve(name, dir string) string {
+	for _, p := range append(append([]string(nil), im.paths...), dir) {
+		full := filepath.Join(p, name)
+		if _, err := os.Stat(full); err == nil {
+			return full
+		}
+	}
+	return filepath.Join(dir, name)
+}
+
+func (im *importer) load(name, dir string) (*protoFile, error) {
+	path := name
+	if !filepath.IsAbs(name) && len(im.loading) > 0 {
+		path = im.resolve(name, dir)
+	}
+	path = filepath.Clean(path)
+	if f, ok := im.files[path]; ok {
+		return f, nil
+	}
+	for _, l := range im.loading {
+		if l == path {
+			return nil, fmt.Errorf("import cycle: %s", strings.Join(append(im.loading, path), " -> "))
+		}
+	}
+	if strings.HasPrefix(filepath.ToSlash(name), "google/protobuf/") {
+		return nil, fmt.Errorf("%s: well-known types are not supported", name)
+	}
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parseProto(path, src)
+	if err != nil {
+		return nil, err
+	}
+	f.name = name
+	f.out = FileName(path, ".jce")
+
+	im.loading = append(im.loading, path)
+	for _, imp := range f.imports {
+		dep, err := im.load(imp, filepath.Dir(path))
+		if err != nil {
+			return nil, err
+		}
+		f.deps = append(f.deps, dep)
+	}
+	im.loading = im.loading[:len(im.loading)-1]
+
+	for _, t := range f.types {
+		if prev, ok := im.types[t.full]; ok {
+			return nil, fmt.Errorf("%s:%d: %s already defined in %s", path, t.line, t.full, prev.file.path)
+		}
+		im.types[t.full] = t
+	}
+	im.files[path] = f
+	return f, nil
+}
+
+// proto 的标量类型对应的 jce 类型
+var scalars = map[string]string{
+	"double":   "double",
+	"float":    "float",
+	"int32":    "int",
+	"sint32":   "int",
+	"sfixed32": "int",
+	"int64":    "long",
+	"sint64":   "long",
+	"sfixed64": "long",
+	"uint32":   "unsigned int",
+	"fixed32":  "unsigned int",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "vector<byte>",
+}
+
+// 按 proto 的作用域规则查找类型，从内层向外层查找
+func (im *importer) lookup(t *protoType, name string) *protoType {
+	if strings.HasPrefix(name, ".") {
+		return im.types[name[1:]]
+	}
+	scope := t.scope
+	for {
+		full := name
+		if scope != "" {
+			full = scope + "." + name
+		}
+		if found, ok := im.types[full]; ok {
+			return found
+		}
+		if scope == "" {
+			return nil
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+// 字段类型的 jce 写法，refs 记录引用的同一文件中的 message
+func (im *importer) jceType(t *protoType, fd *protoField, name string, refs map[*protoType]bool) (string, error) {
+	if s, ok := scalars[name]; ok {
+		return s, nil
+	}
+	switch name {
+	case "uint64", "fixed64":
+		return "", fmt.Errorf("%s:%d: %s: jce has no unsigned long", t.file.path, fd.line, name)
+	case "group":
+		return "", fmt.Errorf("%s:%d: groups are not supported", t.file.path, fd.line)
+	}
+	ref := im.lookup(t, name)
+	if ref == nil {
+		return "", fmt.Errorf("%s:%d: type %s not found", t.file.path, fd.line, name)
+	}
+	if ref.file == t.file {
+		if !ref.enum {
+			refs[ref] = true
+		}
+		return ref.name, nil
+	}
+	return ref.file.module + "::" + ref.name, nil
+}
+
+// 生成 jce 源码并格式化
+func (im *importer) gen(f *protoFile) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// code generated by jce2go %s.\n// source: %s\n\n", version.VERSION, filepath.Base(f.path))
+	for _, dep := range f.deps {
+		fmt.Fprintf(&b, "#include \"%s\"\n", dep.out)
+	}
+	if len(f.deps) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "module %s\n{\n", f.module)
+
+	for _, t := range f.types {
+		if !t.enum {
+			continue
+		}
+		b.WriteString(comment(t.comment))
+		fmt.Fprintf(&b, "enum %s\n{\n", t.name)
+		for _, v := range t.values {
+			b.WriteString(comment(v.comment))
+			fmt.Fprintf(&b, "%s = %s,\n", v.name, v.value)
+		}
+		b.WriteString("};\n\n")
+	}
+
+	// jce 的类型需要先定义后使用，message 按依赖排序
+	bodies := map[*protoType]string{}
+	deps := map[*protoType]map[*protoType]bool{}
+	var messages []*protoType
+	for _, t := range f.types {
+		if t.enum {
+			continue
+		}
+		messages = append(messages, t)
+		refs := map[*protoType]bool{}
+		var body bytes.Buffer
+		body.WriteString(comment(t.comment))
+		fmt.Fprintf(&body, "struct %s\n{\n", t.name)
+		for _, fd := range t.fields {
+			typ, err := im.jceType(t, fd, fd.typ, refs)
+			if err != nil {
+				return nil, err
+			}
+			if fd.key != "" {
+				key, err := im.jceType(t, fd, fd.key, refs)
+				if err != nil {
+					return nil, err
+				}
+				typ = "map<" + key + ", " + typ + ">"
+			} else if fd.repeated {
+				typ = "vector<" + typ + ">"
+			}
+			body.WriteString(comment(fd.comment))
+			fmt.Fprintf(&body, "%d optional %s %s;\n", fd.number, typ, fd.name)
+		}
+		body.WriteString("};\n\n")
+		bodies[t] = body.String()
+		deps[t] = refs
+	}
+
+	done := map[*protoType]bool{}
+	visiting := map[*protoType]bool{}
+	var emit func(t *protoType) error
+	emit = func(t *protoType) error {
+		if done[t] {
+			return nil
+		}
+		if visiting[t] {
+			return fmt.Errorf("%s:%d: %s is recursive, which jce does not support", f.path, t.line, t.full)
+		}
+		visiting[t] = true
+		var refs []*protoType
+		for ref := range deps[t] {
+			refs = append(refs, ref)
+		}
+		sort.Slice(refs, func(i, j int) bool { return refs[i].line < refs[j].line })
+		for _, ref := range refs {
+			if err := emit(ref); err != nil {
+				return err
+			}
+		}
+		done[t] = true
+		b.WriteString(bodies[t])
+		return nil
+	}
+	for _, t := range messages {
+		if err := emit(t); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("};\n")
+
+	return format.Source(f.out, b.Bytes())
+}
+
+func comment(c string) string {
+	if c == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(c, "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+// jce 的关键字和类型名，不能作为名字
+func isJceKeyword(name string) bool {
+	for i := lex.TkDummyKeywordBegin + 1; i < lex.TkDummyKeywordEnd; i++ {
+		if lex.TokenMap[i] == name {
+			return true
+		}
+	}
+	for i := lex.TkDummyTypeBegin + 1; i < lex.TkDummyTypeEnd; i++ {
+		if lex.TokenMap[i] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// proto 源码的 token
+type token struct {
+	text    string
+	str     bool // 字符串字面量，text 为去掉引号的内容
+	line    int
+	comment string // 前面单独成行的注释
+}
+
+func tokenize(path string, src []byte) ([]token, error) {
+	var tokens []token
+	line := 1
+	var pending []string
+	lastLine := 0
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := bytes.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			text := strings.TrimSpace(string(src[i+2 : i+end]))
+			if line != lastLine {
+				pending = append(pending, text)
+			}
+			i += end
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated comment", path, line)
+			}
+			text := string(src[i+2 : i+2+end])
+			if line != lastLine {
+				for _, l := range strings.Split(text, "\n") {
+					if l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "*")); l != "" {
+						pending = append(pending, l)
+					}
+				}
+			}
+			line += strings.Count(text, "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) || src[j] != c {
+				return nil, fmt.Errorf("%s:%d: unterminated string", path, line)
+			}
+			s, err := strconv.Unquote(`"` + strings.ReplaceAll(string(src[i+1:j]), `"`, `\"`) + `"`)
+			if err != nil {
+				s = string(src[i+1 : j])
+			}
+			tokens = append(tokens, token{text: s, str: true, line: line, comment: strings.Join(pending, "\n")})
+			pending, lastLine = nil, line
+			i = j + 1
+		case isWord(c):
+			j := i
+			for j < len(src) && (isWord(src[j]) || src[j] == '.' || src[j] == '+' && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(src[i:j]), line: line, comment: strings.Join(pending, "\n")})
+			pending, lastLine = nil, line
+			i = j
+		case strings.IndexByte("{}[]<>()=;,", c) >= 0:
+			tokens = append(tokens, token{text: string(c), line: line, comment: strings.Join(pending, "\n")})
+			pending, lastLine = nil, line
+			i++
+		default:
+			return nil, fmt.Errorf("%s:%d: unexpected character %q", path, line, c)
+		}
+		// 空行隔开的注释不属于后面的定义
+		if c == '\n' && i < len(src) && src[i] == '\n' {
+			pending = nil
+		}
+	}
+	return tokens, nil
+}
+
+func isWord(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.' || c == '-'
+}
+
+// proto 语法分析，只支持 proto3 的子集
+type protoParser struct {
+	path   string
+	tokens []token
+	pos    int
+	file   *protoFile
+}
+
+type parseError struct{ err error }
+
+func parseProto(path string, src []byte) (f *protoFile, err error) {
+	tokens, err := tokenize(path, src)
+	if err != nil {
+		return nil, err
+	}
+	p := &protoParser{path: path, tokens: tokens, file: &protoFile{path: path}}
+	// 语法错误在递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if pe, ok := e.(parseError); ok {
+				err = pe.err
+				return
+			}
+			panic(e)
+		}
+	}()
+	p.parseFile()
+	return p.file, nil
+}
+
+func (p *protoParser) errorf(tk token, format string, args ...interface{}) {
+	panic(parseError{fmt.Errorf("%s:%d: %s", p.path, tk.line, fmt.Sprintf(format, args...))})
+}
+
+func (p *protoParser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	line := 1
+	if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return token{line: line}
+}
+
+func (p *protoParser) next() token {
+	tk := p.peek()
+	if p.pos >= len(p.tokens) {
+		p.errorf(tk, "unexpected end of file")
+	}
+	p.pos++
+	return tk
+}
+
+func (p *protoParser) expect(text string) token {
+	tk := p.next()
+	if tk.str || tk.text != text {
+		p.errorf(tk, "expect %q, got %q", text, tk.text)
+	}
+	return tk
+}
+
+func (p *protoParser) ident() token {
+	tk := p.next()
+	if tk.str || tk.text == "" || !isWord(tk.text[0]) || tk.text[0] >= '0' && tk.text[0] <= '9' || tk.text[0] == '-' {
+		p.errorf(tk, "expect identifier, got %q", tk.text)
+	}
+	return tk
+}
+
+// 跳过到 ; 为止，用于忽略 option、reserved
+func (p *protoParser) skipStatement() {
+	for p.next().text != ";" {
+	}
+}
+
+// 检查名字可以在 jce 中使用
+func (p *protoParser) checkName(tk token) {
+	if isJceKeyword(tk.text) {
+		p.errorf(tk, "%s is a jce keyword", tk.text)
+	}
+}
+
+func (p *protoParser) parseFile() {
+	f := p.file
+	first := true
+	for p.pos < len(p.tokens) {
+		tk := p.next()
+		if tk.str {
+			p.errorf(tk, "unexpected string %q", tk.text)
+		}
+		switch tk.text {
+		case "syntax":
+			p.expect("=")
+			v := p.next()
+			if !v.str || v.text != "proto3" {
+				p.errorf(v, "only proto3 is supported, got syntax %q", v.text)
+			}
+			p.expect(";")
+		case "package":
+			f.pkg = p.ident().text
+			p.expect(";")
+		case "import":
+			v := p.next()
+			if !v.str {
+				p.errorf(v, "import %s is not supported", v.text)
+			}
+			f.imports = append(f.imports, v.text)
+			p.expect(";")
+		case "option":
+			p.skipStatement()
+		case "message":
+			p.parseMessage(tk, "")
+		case "enum":
+			p.parseEnum(tk, "")
+		case ";":
+		case "service", "extend":
+			p.errorf(tk, "%s is not supported", tk.text)
+		default:
+			p.errorf(tk, "unexpected %q", tk.text)
+		}
+		if first && tk.text != "syntax" {
+			p.errorf(tk, "missing syntax = \"proto3\", proto2 is not supported")
+		}
+		first = false
+	}
+
+	f.module = strings.ReplaceAll(f.pkg, ".", "_")
+	if f.module == "" {
+		f.module = FileName(p.path, "")
+	}
+	if isJceKeyword(f.module) {
+		p.errorf(token{line: 1}, "package %s is a jce keyword", f.module)
+	}
+}
+
+// 全名和展开后的名字，outer 为外层 message 的全名
+func (p *protoParser) names(outer, name string) (full, flat string) {
+	if outer != "" {
+		parent := outer
+		if p.file.pkg != "" {
+			parent = strings.TrimPrefix(outer, p.file.pkg+".")
+		}
+		return outer + "." + name, strings.ReplaceAll(parent, ".", "_") + "_" + name
+	}
+	if p.file.pkg != "" {
+		return p.file.pkg + "." + name, name
+	}
+	return name, name
+}
+
+func (p *protoParser) parseMessage(kw token, outer string) {
+	name := p.ident()
+	p.checkName(name)
+	full, flat := p.names(outer, name.text)
+	t := &protoType{file: p.file, full: full, name: flat, scope: full, comment: kw.comment, line: kw.line}
+	p.file.types = append(p.file.types, t)
+	p.expect("{")
+
+	numbers := map[int]string{}
+	for {
+		tk := p.next()
+		if tk.str {
+			p.errorf(tk, "unexpected string %q", tk.text)
+		}
+		switch tk.text {
+		case "}":
+			return
+		case ";":
+			continue
+		case "message":
+			p.parseMessage(tk, full)
+			continue
+		case "enum":
+			p.parseEnum(tk, full)
+			continue
+		case "option", "reserved":
+			p.skipStatement()
+			continue
+		case "oneof", "extensions", "extend", "group", "required":
+			p.errorf(tk, "%s is not supported", tk.text)
+		}
+
+		fd := &protoField{comment: tk.comment, line: tk.line}
+		switch tk.text {
+		case "repeated":
+			fd.repeated = true
+			fd.typ = p.ident().text
+		case "optional":
+			fd.typ = p.ident().text
+		case "map":
+			p.expect("<")
+			fd.key = p.ident().text
+			p.expect(",")
+			fd.typ = p.ident().text
+			p.expect(">")
+			if _, ok := scalars[fd.key]; !ok || fd.key == "double" || fd.key == "float" || fd.key == "bytes" {
+				p.errorf(tk, "invalid map key type %s", fd.key)
+			}
+		default:
+			fd.typ = tk.text
+		}
+		if fd.typ == "group" {
+			p.errorf(tk, "groups are not supported")
+		}
+		nameTk := p.ident()
+		p.checkName(nameTk)
+		fd.name = nameTk.text
+		p.expect("=")
+		num := p.next()
+		n, err := strconv.ParseInt(num.text, 0, 32)
+		if err != nil || n < 1 {
+			p.errorf(num, "invalid field number %q", num.text)
+		}
+		if n > 255 {
+			p.errorf(num, "field number %d exceeds the max jce tag 255", n)
+		}
+		if prev, ok := numbers[int(n)]; ok {
+			p.errorf(num, "field number %d used by both %s and %s", n, prev, fd.name)
+		}
+		numbers[int(n)] = fd.name
+		fd.number = int(n)
+		if p.peek().text == "[" {
+			p.errorf(p.peek(), "field options are not supported")
+		}
+		p.expect(";")
+		t.fields = append(t.fields, fd)
+	}
+}
+
+func (p *protoParser) parseEnum(kw token, outer string) {
+	name := p.ident()
+	p.checkName(name)
+	full, flat := p.names(outer, name.text)
+	t := &protoType{file: p.file, full: full, name: flat, scope: full, enum: true, comment: kw.comment, line: kw.line}
+	p.file.types = append(p.file.types, t)
+	p.expect("{")
+	for {
+		tk := p.next()
+		switch {
+		case tk.str:
+			p.errorf(tk, "unexpected string %q", tk.text)
+		case tk.text == "}":
+			return
+		case tk.text == ";":
+			continue
+		case tk.text == "option" || tk.text == "reserved":
+			p.skipStatement()
+			continue
+		}
+		p.pos--
+		vname := p.ident()
+		p.checkName(vname)
+		p.expect("=")
+		v := p.next()
+		if _, err := strconv.ParseInt(v.text, 0, 32); err != nil {
+			p.errorf(v, "invalid enum value %q", v.text)
+		}
+		if p.peek().text == "[" {
+			p.errorf(p.peek(), "enum value options are not supported")
+		}
+		p.expect(";")
+		t.values = append(t.values, &protoValue{name: vname.text, value: v.text, comment: tk.comment})
+	}
+}
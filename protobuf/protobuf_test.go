@@ -0,0 +1,238 @@
+package protobuf
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/descriptor"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+const baseSchema = `module base
+{
+    enum Color
+    {
+        Red = 1,
+        Green,
+        Verde = 2,
+    };
+
+    struct Item
+    {
+        1 require int id;
+    };
+};
+`
+
+const schema = `#include "base.jce"
+
+module test
+{
+    const int VERSION = 3;
+
+    // 测试的结构体
+    struct Packet
+    {
+        0  require  byte                       b;
+        1  optional string                     s = "hi";
+        2  optional unsigned int               ui;
+        3  optional vector<byte>               raw;
+        4  optional vector<base::Item>         items;
+        5  optional map<string, vector<int>>   m;
+        6  optional int                        fixed[3];
+        7  optional map<base::Color, string>   byColor;
+        8  optional vector<vector<vector<int>>> deep;
+        9  optional base::Color                c;
+    };
+};
+`
+
+func parse(t *testing.T) *descriptor.Set {
+	dir := t.TempDir()
+	for name, src := range map[string]string{"base.jce": baseSchema, "test.jce": schema} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := parser.ParseSource(filepath.Join(dir, "test.jce"), []byte(schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return descriptor.Build(p)
+}
+
+func TestExport(t *testing.T) {
+	files, warnings, err := Export(parse(t), ExportOptions{Package: "com.foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "base.proto" || files[1].Name != "test.proto" {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	base := files[0].Content
+	for _, s := range []string{
+		"package com.foo.base;",
+		"enum Color {\n  option allow_alias = true;\n  Color_UNSPECIFIED = 0;\n  Red = 1;\n  Green = 2;\n  Verde = 2;\n}",
+		"message Item {\n  int32 id = 1;\n}",
+	} {
+		if !strings.Contains(base, s) {
+			t.Errorf("missing %q in:\n%s", s, base)
+		}
+	}
+
+	code := files[1].Content
+	for _, s := range []string{
+		`syntax = "proto3";`,
+		`import "base.proto";`,
+		"// const int VERSION = 3;",
+		"// 测试的结构体\nmessage Packet {",
+		"  int32 b = 1;",
+		"  // default: \"hi\"\n  string s = 2;",
+		"  uint32 ui = 3;",
+		"  bytes raw = 4;",
+		"  repeated com.foo.base.Item items = 5;",
+		"  map<string, MItem> m = 6;",
+		"  repeated int32 fixed = 7;",
+		"  repeated ByColorEntry byColor = 8;",
+		"  repeated DeepItem deep = 9;",
+		"  com.foo.base.Color c = 10;",
+		"  message MItem {\n    repeated int32 values = 1;\n  }",
+		"  message ByColorEntry {\n    com.foo.base.Color key = 1;\n    string value = 2;\n  }",
+		"  message DeepItem {\n    repeated DeepItem2 values = 1;\n  }",
+		"  message DeepItem2 {\n    repeated int32 values = 1;\n  }",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+
+	var msgs []string
+	for _, w := range warnings {
+		msgs = append(msgs, w.Message)
+	}
+	all := strings.Join(msgs, "\n")
+	for _, s := range []string{
+		"enum Color has no zero value",
+		"const VERSION = 3 has no proto equivalent",
+		"Packet uses tag 0",
+		"Packet.s: default value \"hi\" dropped",
+		"Packet.fixed: fixed length int[3] exported as repeated",
+		"Packet.byColor: base::Color is not a valid map key",
+		"Packet.m: nested vector<int> wrapped in a message",
+	} {
+		if !strings.Contains(all, s) {
+			t.Errorf("missing warning %q in:\n%s", s, all)
+		}
+	}
+}
+
+const commonProto = `syntax = "proto3";
+package demo.common;
+
+message Page {
+  int32 offset = 1;
+}
+`
+
+const userProto = `syntax = "proto3";
+
+package demo;
+
+import "common.proto";
+
+option go_package = "x/demo";
+
+// 用户
+message User {
+  // 编号
+  int64 id = 1;
+  repeated string tags = 3;
+  map<string, Address> addrs = 4;
+  Status status = 5;
+  bytes avatar = 6;
+  uint32 age = 7;
+  demo.common.Page page = 8;
+  reserved 9;
+
+  message Address {
+    Kind kind = 2;
+    enum Kind {
+      HOME = 0;
+    }
+  }
+}
+
+enum Status {
+  UNKNOWN = 0;
+}
+`
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		full := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(full, []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		return full
+	}
+	write("common.proto", commonProto)
+	files, err := Import(write("user.proto", userProto), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "common.jce" || files[1].Name != "user.jce" {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	// 生成的 jce 可以被解析
+	for _, f := range files {
+		write(f.Name, f.Content)
+	}
+	if _, err := parser.ParseSource(filepath.Join(dir, "user.jce"), []byte(files[1].Content)); err != nil {
+		t.Fatalf("%v:\n%s", err, files[1].Content)
+	}
+
+	code := files[1].Content
+	for _, s := range []string{
+		`#include "common.jce"`,
+		"module demo",
+		"enum User_Address_Kind",
+		"// 用户\n    struct User\n",
+		"        // 编号\n        1 optional long",
+		"vector<string>",
+		"map<string, User_Address>",
+		"6 optional vector<byte>",
+		"7 optional unsigned int",
+		"demo_common::Page",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+	if strings.Index(code, "struct User_Address") > strings.Index(code, "struct User\n") {
+		t.Errorf("nested message should be defined first:\n%s", code)
+	}
+
+	for _, c := range []struct{ src, err string }{
+		{`syntax = "proto2"; message A {}`, "only proto3"},
+		{`message A {}`, "missing syntax"},
+		{`syntax = "proto3"; message A { oneof x { int32 a = 1; } }`, "oneof is not supported"},
+		{`syntax = "proto3"; service S {}`, "service is not supported"},
+		{`syntax = "proto3"; message A { int32 a = 1 [deprecated = true]; }`, "field options"},
+		{`syntax = "proto3"; message A { uint64 a = 1; }`, "unsigned long"},
+		{`syntax = "proto3"; message A { int32 a = 256; }`, "exceeds"},
+		{`syntax = "proto3"; message A { int32 struct = 1; }`, "jce keyword"},
+		{`syntax = "proto3"; message A { B b = 1; }`, "B not found"},
+		{`syntax = "proto3"; message A { A a = 1; }`, "recursive"},
+		{`syntax = "proto3"; import "google/protobuf/any.proto";`, "well-known"},
+	} {
+		_, err := Import(write("bad.proto", c.src), nil)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expect error %q, got %v", c.src, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package refactor
+
+import "github.com/erpc-go/jce2go/lex"
+
+// 名字 token 在源码中的用途
+const (
+	refTypeDecl       = iota // struct、enum 的定义
+	refType                  // 成员的类型
+	refMemberDecl            // struct 成员的定义
+	refDefault               // struct 成员的默认值，只能是枚举成员
+	refEnumMemberDecl        // 枚举成员的定义
+	refEnumValue             // 枚举成员的值引用了前面的成员
+	refConstDecl             // 常量的定义
+)
+
+// 块的类型
+const (
+	blockModule = iota
+	blockStruct
+	blockEnum
+	blockInterface
+	blockOther
+)
+
+// 源码中出现的一个名字
+type reference struct {
+	token  lex.Token
+	kind   int
+	module string // 所在的 module
+	owner  string // 所在的 struct 或 enum 名
+	block  int    // refTypeDecl 定义的是 struct 还是 enum
+}
+
+type block struct {
+	kind int
+	name string
+}
+
+// classify 根据上下文给源码中所有的名字分类
+func classify(tokens []lex.Token) []reference {
+	var ts []lex.Token
+	for _, tk := range tokens {
+		if tk.Type != lex.TkComment {
+			ts = append(ts, tk)
+		}
+	}
+	typeOf := func(i int) lex.TokenType {
+		if i < 0 || i >= len(ts) {
+			return lex.TkEos
+		}
+		return ts[i].Type
+	}
+
+	var (
+		refs    []reference
+		module  string
+		stack   []block
+		pending = block{kind: blockOther}
+	)
+
+	for i, tk := range ts {
+		switch tk.Type {
+		case lex.TkModule:
+			if typeOf(i+1) == lex.TkName {
+				module = ts[i+1].Value.String
+			}
+			pending = block{kind: blockModule}
+			continue
+		case lex.TkInterface:
+			pending = block{kind: blockInterface}
+			continue
+		case lex.TkBraceLeft:
+			stack = append(stack, pending)
+			pending = block{kind: blockOther}
+			continue
+		case lex.TkBraceRight:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			continue
+		case lex.TkName:
+		default:
+			continue
+		}
+
+		if typeOf(i-1) == lex.TkModule {
+			continue
+		}
+
+		ref := reference{token: tk, module: module}
+		if prev := typeOf(i - 1); prev == lex.TkStruct || prev == lex.TkEnum {
+			ref.kind = refTypeDecl
+			ref.block = blockStruct
+			if prev == lex.TkEnum {
+				ref.block = blockEnum
+			}
+			pending = block{kind: ref.block, name: tk.Value.String}
+			refs = append(refs, ref)
+			continue
+		}
+
+		cur := block{kind: blockOther}
+		if len(stack) > 0 {
+			cur = stack[len(stack)-1]
+		}
+		ref.owner = cur.name
+
+		prev, next := typeOf(i-1), typeOf(i+1)
+		switch cur.kind {
+		case blockStruct:
+			switch {
+			case prev == lex.TkEq:
+				ref.kind = refDefault
+			case next == lex.TkSemi || next == lex.TkEq || next == lex.TkSquareLeft:
+				ref.kind = refMemberDecl
+			default:
+				ref.kind = refType
+			}
+		case blockEnum:
+			if prev == lex.TkEq {
+				ref.kind = refEnumValue
+			} else {
+				ref.kind = refEnumMemberDecl
+			}
+		case blockInterface:
+			// 跟在类型后面的是方法名或参数名
+			if prev == lex.TkName || prev == lex.TkShr || prev == lex.TkVoid || lex.IsType(prev) {
+				continue
+			}
+			ref.kind = refType
+		case blockModule:
+			if next != lex.TkEq {
+				continue
+			}
+			ref.kind = refConstDecl
+		default:
+			ref.kind = refType
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
@@ -0,0 +1,395 @@
+package refactor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// 符号重命名
+// 1. 找到位置处的符号，可以是定义也可以是引用，解析到最终的定义
+// 2. 在同目录以及 include 链上的所有 jce 文件中，找出能看到该定义的文件
+// 3. 基于 token 改写定义和所有引用，其余内容（格式、注释）原样保留
+
+// Edit 对某个文件的一处修改
+type Edit struct {
+	Filename string
+	Line     int // 行号，从 1 开始
+	Column   int // 列号，从 1 开始
+	Offset   int // 被替换内容的起始字节偏移
+	End      int // 被替换内容的结束字节偏移（不含）
+	Old      string
+	New      string
+}
+
+func (e Edit) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s -> %s", e.Filename, e.Line, e.Column, e.Old, e.New)
+}
+
+// 被重命名的符号
+type target struct {
+	file   string // 定义所在文件的绝对路径
+	module string
+	name   string
+	enum   string // 枚举成员所属的枚举名
+	sym    *parser.Symbol
+}
+
+func (t *target) isType() bool {
+	return t.sym.Member == nil && t.sym.EnumMember == nil && t.sym.Const == nil
+}
+
+// Rename 计算把 filename 中 line:column 处的符号重命名为 newName 所需要的全部修改
+func Rename(filename string, line, column int, newName string) ([]Edit, error) {
+	if err := checkIdent(newName); err != nil {
+		return nil, err
+	}
+
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parser.ParseSource(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := lex.Tokenize(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var tk *lex.Token
+	for i := range tokens {
+		t := &tokens[i]
+		if t.Type == lex.TkName && t.Line == line && column >= t.Column && column < t.Column+t.End-t.Offset {
+			tk = t
+			break
+		}
+	}
+	if tk == nil {
+		return nil, fmt.Errorf("%s:%d:%d: no identifier at position", filename, line, column)
+	}
+
+	sym := p.DeclarationAt(tk.Line, tk.Column)
+	if sym == nil {
+		sym = p.Resolve(tk.Value.String)
+	}
+	if sym == nil {
+		return nil, fmt.Errorf("%s:%d:%d: can not resolve %s", filename, line, column, tk.Value.String)
+	}
+
+	t := &target{file: abs(sym.Parser.Filepath), module: sym.Parser.Module, sym: sym}
+	_, _, t.name = sym.Position()
+	if sym.EnumMember != nil {
+		t.enum = sym.Enum.Name
+	}
+	if t.name == newName {
+		return nil, nil
+	}
+
+	files, err := candidates(filename, sym.Parser.Filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []Edit
+	for _, f := range files {
+		fe, err := renameInFile(f, t, newName)
+		if err != nil {
+			return nil, err
+		}
+		edits = append(edits, fe...)
+	}
+	return edits, nil
+}
+
+// 可能引用该符号的文件：起始文件、定义文件所在目录中的 jce 文件，以及它们直接、间接 include 的文件
+func candidates(files ...string) ([]string, error) {
+	set := map[string]bool{}
+	var queue []string
+	add := func(f string) {
+		if f = abs(f); !set[f] {
+			set[f] = true
+			queue = append(queue, f)
+		}
+	}
+	for _, f := range files {
+		add(f)
+		matches, err := filepath.Glob(filepath.Join(filepath.Dir(f), "*.jce"))
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	// include 的文件可能在其他目录中
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		for _, inc := range includes(f) {
+			add(inc)
+		}
+	}
+
+	ret := make([]string, 0, len(set))
+	for f := range set {
+		ret = append(ret, f)
+	}
+	sort.Strings(ret)
+	return ret, nil
+}
+
+// 文件中 #include 的已存在的文件，只做词法分析，出错时返回已经找到的部分
+func includes(filename string) []string {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+	tokens, _ := lex.Tokenize(filename, src)
+	var ret []string
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].Type != lex.TkInclude || tokens[i+1].Type != lex.TkString {
+			continue
+		}
+		inc := filepath.Join(filepath.Dir(filename), tokens[i+1].Value.String)
+		if _, err := os.Stat(inc); err == nil {
+			ret = append(ret, inc)
+		}
+	}
+	return ret
+}
+
+func abs(filename string) string {
+	if a, err := filepath.Abs(filename); err == nil {
+		return filepath.Clean(a)
+	}
+	return filepath.Clean(filename)
+}
+
+// reaches 判断 filename 本身或者它直接、间接 include 的文件是否为 target，只做词法分析
+func reaches(filename, target string) bool {
+	seen := map[string]bool{}
+	queue := []string{abs(filename)}
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		if f == target {
+			return true
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		for _, inc := range includes(f) {
+			queue = append(queue, abs(inc))
+		}
+	}
+	return false
+}
+
+// renameInFile 计算单个文件中的修改，看不到定义的文件直接跳过。
+// 同目录中解析出错的文件如果没有 include 定义所在的文件，不可能引用该符号，同样跳过
+func renameInFile(filename string, t *target, newName string) ([]Edit, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parser.ParseSource(filename, src)
+	if err != nil {
+		if !reaches(filename, t.file) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	visible := false
+	p.Visit(func(fp *parser.Parser) {
+		if abs(fp.Filepath) == t.file {
+			visible = true
+		}
+	})
+	if !visible {
+		return nil, nil
+	}
+
+	if err = checkConflict(p, t, newName); err != nil {
+		return nil, err
+	}
+
+	tokens, err := lex.Tokenize(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	var edits []Edit
+	self := abs(filename) == t.file
+	for _, ref := range classify(tokens) {
+		tk := ref.token
+		v := tk.Value.String
+		if !t.matches(p, ref, self) {
+			continue
+		}
+
+		replaced := newName
+		if i := strings.LastIndex(v, "::"); i >= 0 {
+			replaced = v[:i+2] + newName
+		}
+		edits = append(edits, Edit{
+			Filename: filename,
+			Line:     tk.Line,
+			Column:   tk.Column,
+			Offset:   tk.Offset,
+			End:      tk.End,
+			Old:      string(src[tk.Offset:tk.End]),
+			New:      replaced,
+		})
+	}
+	return edits, nil
+}
+
+// matches 判断名字 token 是否指向被重命名的符号
+func (t *target) matches(p *parser.Parser, ref reference, self bool) bool {
+	v := ref.token.Value.String
+	module, name := ref.module, v
+	if i := strings.Index(v, "::"); i >= 0 {
+		module, name = v[:i], v[i+2:]
+	}
+	if name != t.name {
+		return false
+	}
+
+	switch {
+	case t.isType():
+		switch ref.kind {
+		case refTypeDecl:
+			return self && module == t.module && (ref.block == blockStruct) == (t.sym.Struct != nil)
+		case refType:
+			return module == t.module
+		}
+	case t.sym.EnumMember != nil:
+		switch ref.kind {
+		case refEnumMemberDecl:
+			return self && ref.module == t.module && ref.owner == t.enum
+		case refEnumValue:
+			// 枚举成员只能引用同一个枚举中前面的成员
+			return self && ref.module == t.module && ref.owner == t.enum
+		case refDefault:
+			if strings.Contains(v, "::") && module != t.module {
+				return false
+			}
+			fp, en, _ := p.FindEnumMember(v)
+			return fp != nil && abs(fp.Filepath) == t.file && en.Name == t.enum
+		}
+	case t.sym.Member != nil:
+		return ref.kind == refMemberDecl && self && ref.module == t.module && ref.owner == t.sym.Struct.Name
+	case t.sym.Const != nil:
+		return ref.kind == refConstDecl && self && ref.module == t.module
+	}
+	return false
+}
+
+// checkConflict 检查新名字是否和已有的定义冲突
+func checkConflict(p *parser.Parser, t *target, newName string) error {
+	conflict := func(what string) error {
+		return fmt.Errorf("%s: %s %s already exists", p.Filepath, what, newName)
+	}
+
+	switch {
+	case t.isType():
+		if abs(p.Filepath) != t.file {
+			return nil
+		}
+		for _, st := range p.Structs {
+			if st.Name == newName {
+				return conflict("struct")
+			}
+		}
+		for _, en := range p.Enums {
+			if en.Name == newName {
+				return conflict("enum")
+			}
+		}
+	case t.sym.EnumMember != nil:
+		if fp, _, _ := p.FindEnumMember(newName); fp != nil {
+			return conflict("enum member")
+		}
+	case t.sym.Member != nil:
+		for _, mb := range t.sym.Struct.Member {
+			if mb.CommentType == "" && mb.Key == newName {
+				return conflict("member")
+			}
+		}
+	case t.sym.Const != nil:
+		for _, cst := range p.Consts {
+			if cst.Name == newName {
+				return conflict("const")
+			}
+		}
+	}
+	return nil
+}
+
+// checkIdent 检查新名字是否是合法的标识符
+func checkIdent(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty name")
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		letter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_'
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return fmt.Errorf("invalid identifier %q", name)
+		}
+	}
+	for _, kw := range lex.TokenMap {
+		if kw == name {
+			return fmt.Errorf("%q is a keyword", name)
+		}
+	}
+	return nil
+}
+
+// Apply 把修改写回文件，返回修改过的文件列表
+func Apply(edits []Edit) ([]string, error) {
+	byFile := map[string][]Edit{}
+	var files []string
+	for _, e := range edits {
+		if _, ok := byFile[e.Filename]; !ok {
+			files = append(files, e.Filename)
+		}
+		byFile[e.Filename] = append(byFile[e.Filename], e)
+	}
+
+	for _, f := range files {
+		src, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if err = ioutil.WriteFile(f, ApplySource(src, byFile[f]), 0o666); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// ApplySource 把同一个文件的修改应用到源码上
+func ApplySource(src []byte, edits []Edit) []byte {
+	sorted := append([]Edit(nil), edits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	var out []byte
+	last := 0
+	for _, e := range sorted {
+		out = append(out, src[last:e.Offset]...)
+		out = append(out, e.New...)
+		last = e.End
+	}
+	return append(out, src[last:]...)
+}
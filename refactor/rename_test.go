@@ -0,0 +1,167 @@
+package refactor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseJce = `module base
+{
+    enum EMsgSendType
+    {
+        eSendTypeOnline = 1, // online
+        eSendTypeOffline = eSendTypeOnline,
+    };
+
+    // request
+    struct request {
+        0 optional int request; // same name as struct
+        1 optional EMsgSendType t = eSendTypeOnline;
+    };
+};
+`
+
+const testJce = `#include "base.jce"
+
+module test {
+    struct RequestPacket
+    {
+        1 require base::request        req;   // keep comment
+        2 optional vector<base::request> reqs;
+        3 optional base::EMsgSendType   s = base::eSendTypeOnline;
+    };
+
+    interface Service
+    {
+        int send(base::request request, out vector<base::request> reqs);
+    };
+};
+`
+
+func setup(t *testing.T) string {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "base.jce"), []byte(baseJce), 0o666)
+	ioutil.WriteFile(filepath.Join(dir, "test.jce"), []byte(testJce), 0o666)
+	return dir
+}
+
+func read(t *testing.T, filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRenameStruct(t *testing.T) {
+	dir := setup(t)
+
+	// 从引用处发起重命名
+	edits, err := Rename(filepath.Join(dir, "test.jce"), 6, 30, "Request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 5 {
+		t.Fatalf("expect 5 edits, got %v", edits)
+	}
+	if _, err = Apply(edits); err != nil {
+		t.Fatal(err)
+	}
+
+	base, test := read(t, filepath.Join(dir, "base.jce")), read(t, filepath.Join(dir, "test.jce"))
+	if !strings.Contains(base, "struct Request {") || !strings.Contains(base, "int request;") {
+		t.Errorf("base.jce:\n%s", base)
+	}
+	if !strings.Contains(test, "base::Request        req;   // keep comment") || !strings.Contains(test, "vector<base::Request>") ||
+		!strings.Contains(test, "send(base::Request request, out vector<base::Request> reqs)") {
+		t.Errorf("test.jce:\n%s", test)
+	}
+}
+
+func TestRenameEnumMember(t *testing.T) {
+	dir := setup(t)
+
+	edits, err := Rename(filepath.Join(dir, "base.jce"), 5, 9, "eOnline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 4 {
+		t.Fatalf("expect 4 edits, got %v", edits)
+	}
+	Apply(edits)
+
+	base, test := read(t, filepath.Join(dir, "base.jce")), read(t, filepath.Join(dir, "test.jce"))
+	if !strings.Contains(base, "eOnline = 1, // online") || !strings.Contains(base, "eSendTypeOffline = eOnline,") ||
+		!strings.Contains(base, "t = eOnline;") {
+		t.Errorf("base.jce:\n%s", base)
+	}
+	if !strings.Contains(test, "s = base::eOnline;") {
+		t.Errorf("test.jce:\n%s", test)
+	}
+}
+
+func TestRenameConflict(t *testing.T) {
+	dir := setup(t)
+
+	if _, err := Rename(filepath.Join(dir, "base.jce"), 3, 10, "request"); err == nil {
+		t.Error("expect conflict error")
+	}
+	if _, err := Rename(filepath.Join(dir, "base.jce"), 3, 10, "struct"); err == nil {
+		t.Error("expect keyword error")
+	}
+}
+
+func TestRenameAcrossDirectories(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"z/def.jce": "module z\n{\n    struct Def { 0 require int id; };\n};\n",
+		"y/mid.jce": "#include \"../z/def.jce\"\n\nmodule y\n{\n    struct Mid { 0 require z::Def d; };\n};\n",
+		"x/top.jce": "#include \"../y/mid.jce\"\n\nmodule x\n{\n    struct Top\n    {\n        0 require z::Def d;\n        1 require y::Mid m;\n    };\n};\n",
+	}
+	for name, src := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o766)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 从 x/top.jce 中的引用发起，y/mid.jce 既不在起始文件也不在定义文件的目录中
+	edits, err := Rename(filepath.Join(dir, "x/top.jce"), 7, 22, "Definition")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Apply(edits); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"z/def.jce": "struct Definition {",
+		"y/mid.jce": "0 require z::Definition d;",
+		"x/top.jce": "0 require z::Definition d;",
+	} {
+		if got := read(t, filepath.Join(dir, name)); !strings.Contains(got, want) {
+			t.Errorf("%s: missing %q in:\n%s", name, want, got)
+		}
+	}
+}
+
+// 同目录中解析出错的文件没有 include 定义所在的文件时跳过，include 了时报错
+func TestRenameSkipBrokenFiles(t *testing.T) {
+	dir := setup(t)
+	ioutil.WriteFile(filepath.Join(dir, "broken.jce"), []byte("module broken\n{\n    struct A {\n"), 0o666)
+
+	edits, err := Rename(filepath.Join(dir, "test.jce"), 6, 30, "Request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(edits) != 5 {
+		t.Fatalf("expect 5 edits, got %v", edits)
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "broken.jce"), []byte("#include \"base.jce\"\nmodule broken\n{\n    struct A {\n"), 0o666)
+	if _, err = Rename(filepath.Join(dir, "test.jce"), 6, 30, "Request"); err == nil || !strings.Contains(err.Error(), "broken.jce") {
+		t.Fatalf("expect error of broken.jce, got %v", err)
+	}
+}
@@ -0,0 +1,492 @@
+package thrift
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// thrift 源码的 token
+type token struct {
+	text    string
+	str     bool // 字符串字面量，text 为去掉引号的内容
+	line    int
+	comment string // 前面单独成行的注释
+}
+
+func tokenize(path string, src []byte) ([]token, error) {
+	var tokens []token
+	line := 1
+	var pending []string
+	lastLine := 0
+	add := func(tk token) {
+		tk.line, tk.comment = line, strings.Join(pending, "\n")
+		tokens = append(tokens, tk)
+		pending, lastLine = nil, line
+	}
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			// 空行隔开的注释不属于后面的定义
+			if i < len(src) && src[i] == '\n' {
+				pending = nil
+			}
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || c == '/' && i+1 < len(src) && src[i+1] == '/':
+			end := bytes.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			text := string(src[i+1 : i+end])
+			if c == '/' {
+				text = text[1:]
+			}
+			if line != lastLine {
+				pending = append(pending, strings.TrimSpace(text))
+			}
+			i += end
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := bytes.Index(src[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, fmt.Errorf("%s:%d: unterminated comment", path, line)
+			}
+			text := string(src[i+2 : i+2+end])
+			if line != lastLine {
+				for _, l := range strings.Split(text, "\n") {
+					if l = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), "*")); l != "" {
+						pending = append(pending, l)
+					}
+				}
+			}
+			line += strings.Count(text, "\n")
+			i += end + 4
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(src) && src[j] != c && src[j] != '\n' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) || src[j] != c {
+				return nil, fmt.Errorf("%s:%d: unterminated string", path, line)
+			}
+			s, err := strconv.Unquote(`"` + strings.ReplaceAll(string(src[i+1:j]), `"`, `\"`) + `"`)
+			if err != nil {
+				s = string(src[i+1 : j])
+			}
+			add(token{text: s, str: true})
+			i = j + 1
+		case isWord(c) || (c == '-' || c == '+') && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i + 1
+			for j < len(src) && (isWord(src[j]) || (src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			add(token{text: string(src[i:j])})
+			i = j
+		case strings.IndexByte("{}[]<>()=;,:", c) >= 0:
+			add(token{text: string(c)})
+			i++
+		default:
+			return nil, fmt.Errorf("%s:%d: unexpected character %q", path, line, c)
+		}
+	}
+	return tokens, nil
+}
+
+func isWord(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.'
+}
+
+// thrift 语法分析
+type thriftParser struct {
+	im     *importer
+	path   string
+	tokens []token
+	pos    int
+	file   *file
+	// 是否已经对注解给出警告
+	annotated bool
+}
+
+type parseError struct{ err error }
+
+func parse(im *importer, path string, src []byte) (f *file, err error) {
+	tokens, err := tokenize(path, src)
+	if err != nil {
+		return nil, err
+	}
+	f = &file{path: path, out: fileName(path, ".jce"), module: fileName(path, ""), names: map[string]*def{}}
+	p := &thriftParser{im: im, path: path, tokens: tokens, file: f}
+	// 语法错误在递归中发现，统一在这里转换为 error
+	defer func() {
+		if e := recover(); e != nil {
+			if pe, ok := e.(parseError); ok {
+				f, err = nil, pe.err
+				return
+			}
+			panic(e)
+		}
+	}()
+	if isJceKeyword(f.module) || !validName(f.module) {
+		p.errorf(token{line: 1}, "file name %s can not be used as jce module name", f.module)
+	}
+	p.parseFile()
+	return f, nil
+}
+
+// 文件名去掉目录和后缀，加上 ext
+func fileName(path, ext string) string {
+	base := path[strings.LastIndexAny(path, `/\`)+1:]
+	if i := strings.LastIndex(base, "."); i > 0 {
+		base = base[:i]
+	}
+	return base + ext
+}
+
+func validName(name string) bool {
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isWord(name[i]) || name[i] == '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *thriftParser) errorf(tk token, format string, args ...interface{}) {
+	panic(parseError{fmt.Errorf("%s:%d: %s", p.path, tk.line, fmt.Sprintf(format, args...))})
+}
+
+func (p *thriftParser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	line := 1
+	if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return token{line: line}
+}
+
+func (p *thriftParser) next() token {
+	tk := p.peek()
+	if p.pos >= len(p.tokens) {
+		p.errorf(tk, "unexpected end of file")
+	}
+	p.pos++
+	return tk
+}
+
+func (p *thriftParser) accept(text string) bool {
+	if tk := p.peek(); !tk.str && tk.text == text && p.pos < len(p.tokens) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *thriftParser) expect(text string) token {
+	tk := p.next()
+	if tk.str || tk.text != text {
+		p.errorf(tk, "expect %q, got %q", text, tk.text)
+	}
+	return tk
+}
+
+// 名字，jce 的关键字不能作为名字
+func (p *thriftParser) name() token {
+	tk := p.next()
+	if tk.str || !validName(tk.text) {
+		p.errorf(tk, "expect identifier, got %q", tk.text)
+	}
+	if isJceKeyword(tk.text) {
+		p.errorf(tk, "%s is a jce keyword", tk.text)
+	}
+	return tk
+}
+
+// 定义之间、字段之间可选的分隔符
+func (p *thriftParser) separator() {
+	if !p.accept(",") {
+		p.accept(";")
+	}
+}
+
+// 注解 (key = "value", ...) 被忽略
+func (p *thriftParser) annotations() {
+	if !p.accept("(") {
+		return
+	}
+	if !p.annotated {
+		p.annotated = true
+		p.im.warn(p.file, "annotations dropped")
+	}
+	for !p.accept(")") {
+		p.next()
+	}
+}
+
+func (p *thriftParser) define(d *def, tk token) {
+	if _, ok := p.file.names[d.name]; ok {
+		p.errorf(tk, "%s redefined", d.name)
+	}
+	d.file, d.line = p.file, tk.line
+	p.file.names[d.name] = d
+	p.file.defs = append(p.file.defs, d)
+}
+
+func (p *thriftParser) parseFile() {
+	for p.pos < len(p.tokens) {
+		tk := p.next()
+		if tk.str {
+			p.errorf(tk, "unexpected string %q", tk.text)
+		}
+		switch tk.text {
+		case "include":
+			inc := p.next()
+			if !inc.str {
+				p.errorf(inc, "expect file name after include")
+			}
+			p.file.includes = append(p.file.includes, inc.text)
+		case "cpp_include":
+			p.next()
+			p.im.warn(p.file, "cpp_include dropped")
+		case "namespace":
+			// namespace 只对各语言的代码生成有意义
+			p.next()
+			p.next()
+			p.annotations()
+		case "const":
+			d := &def{kind: defConst, keyword: tk.text, comment: tk.comment}
+			d.typ = p.parseType()
+			name := p.name()
+			d.name = name.text
+			p.expect("=")
+			d.value = p.parseValue()
+			p.define(d, name)
+		case "typedef":
+			d := &def{kind: defTypedef, keyword: tk.text, comment: tk.comment}
+			d.typ = p.parseType()
+			name := p.name()
+			d.name = name.text
+			p.annotations()
+			p.define(d, name)
+		case "enum":
+			p.parseEnum(tk)
+		case "struct", "union", "exception":
+			p.parseStruct(tk)
+		case "service":
+			p.parseService(tk)
+		case "senum":
+			p.errorf(tk, "senum is not supported")
+		default:
+			p.errorf(tk, "unexpected %q", tk.text)
+		}
+		p.separator()
+	}
+}
+
+func (p *thriftParser) parseType() *typ {
+	tk := p.next()
+	if tk.str {
+		p.errorf(tk, "expect type, got string %q", tk.text)
+	}
+	t := &typ{name: tk.text, line: tk.line}
+	switch tk.text {
+	case "list", "set":
+		p.expect("<")
+		t.params = []*typ{p.parseType()}
+		p.expect(">")
+	case "map":
+		p.expect("<")
+		t.params = []*typ{p.parseType()}
+		p.expect(",")
+		t.params = append(t.params, p.parseType())
+		p.expect(">")
+	case "void":
+		p.errorf(tk, "void is not a field type")
+	case "slist":
+		p.errorf(tk, "slist is not supported")
+	default:
+		if !validName(strings.ReplaceAll(tk.text, ".", "_")) {
+			p.errorf(tk, "expect type, got %q", tk.text)
+		}
+	}
+	p.annotations()
+	return t
+}
+
+// 常量的值，list、map 只记录源码
+func (p *thriftParser) parseValue() *value {
+	tk := p.next()
+	if tk.str {
+		return &value{text: tk.text, str: true}
+	}
+	switch tk.text {
+	case "[", "{":
+		var b strings.Builder
+		b.WriteString(tk.text)
+		depth := 1
+		for depth > 0 {
+			t := p.next()
+			switch {
+			case t.str:
+				b.WriteString(strconv.Quote(t.text))
+			case t.text == "[" || t.text == "{":
+				depth++
+				b.WriteString(t.text)
+			case t.text == "]" || t.text == "}":
+				depth--
+				b.WriteString(t.text)
+			case t.text == "," || t.text == ":":
+				b.WriteString(t.text + " ")
+			default:
+				b.WriteString(t.text)
+			}
+		}
+		return &value{text: b.String(), container: true}
+	}
+	if !isWord(tk.text[0]) && tk.text[0] != '-' && tk.text[0] != '+' {
+		p.errorf(tk, "expect value, got %q", tk.text)
+	}
+	return &value{text: strings.TrimPrefix(tk.text, "+")}
+}
+
+func (p *thriftParser) parseEnum(kw token) {
+	name := p.name()
+	d := &def{kind: defEnum, keyword: kw.text, name: name.text, comment: kw.comment}
+	p.expect("{")
+	next := int64(0)
+	seen := map[string]bool{}
+	for !p.accept("}") {
+		tk := p.name()
+		if seen[tk.text] {
+			p.errorf(tk, "%s.%s redefined", d.name, tk.text)
+		}
+		seen[tk.text] = true
+		item := &enumItem{name: tk.text, value: next, comment: tk.comment}
+		if p.accept("=") {
+			v := p.next()
+			n, err := strconv.ParseInt(v.text, 0, 32)
+			if err != nil {
+				p.errorf(v, "invalid enum value %q", v.text)
+			}
+			item.value = n
+		}
+		next = item.value + 1
+		p.annotations()
+		p.separator()
+		d.values = append(d.values, item)
+	}
+	p.annotations()
+	p.define(d, name)
+}
+
+func (p *thriftParser) parseStruct(kw token) {
+	name := p.name()
+	d := &def{kind: defStruct, keyword: kw.text, name: name.text, comment: kw.comment}
+	p.accept("xsd_all")
+	p.expect("{")
+	d.fields = p.parseFields("}", d.name)
+	for _, fd := range d.fields {
+		if !fd.hasID {
+			p.errorf(token{line: fd.line}, "%s.%s: field id is required", d.name, fd.name)
+		}
+		if fd.id < 0 || fd.id > 255 {
+			p.errorf(token{line: fd.line}, "%s.%s: field id %d is out of jce tag range 0-255", d.name, fd.name, fd.id)
+		}
+	}
+	p.annotations()
+	p.define(d, name)
+}
+
+// 字段列表，直到 end 为止
+func (p *thriftParser) parseFields(end, owner string) []*field {
+	var fields []*field
+	ids := map[int]string{}
+	names := map[string]bool{}
+	for !p.accept(end) {
+		first := p.peek()
+		fd := &field{comment: first.comment, line: first.line}
+		if tk := p.peek(); !tk.str && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].text == ":" {
+			p.next()
+			n, err := strconv.Atoi(tk.text)
+			if err != nil {
+				p.errorf(tk, "invalid field id %q", tk.text)
+			}
+			p.next()
+			fd.id, fd.hasID = n, true
+		}
+		if p.accept("required") {
+			fd.required = true
+		} else {
+			p.accept("optional")
+		}
+		fd.typ = p.parseType()
+		name := p.name()
+		fd.name = name.text
+		if names[fd.name] {
+			p.errorf(name, "%s.%s redefined", owner, fd.name)
+		}
+		names[fd.name] = true
+		if fd.hasID {
+			if prev, ok := ids[fd.id]; ok {
+				p.errorf(name, "field id %d used by both %s and %s", fd.id, prev, fd.name)
+			}
+			ids[fd.id] = fd.name
+		}
+		if p.accept("=") {
+			fd.value = p.parseValue()
+		}
+		if p.accept("xsd_optional") || p.accept("xsd_nillable") {
+			p.errorf(name, "xsd field options are not supported")
+		}
+		p.annotations()
+		p.separator()
+		fields = append(fields, fd)
+	}
+	return fields
+}
+
+func (p *thriftParser) parseService(kw token) {
+	name := p.name()
+	d := &def{kind: defService, keyword: kw.text, name: name.text, comment: kw.comment}
+	if p.accept("extends") {
+		d.extends = p.next().text
+	}
+	p.expect("{")
+	seen := map[string]bool{}
+	for !p.accept("}") {
+		first := p.peek()
+		fn := &function{comment: first.comment, line: first.line}
+		fn.oneway = p.accept("oneway")
+		if !p.accept("void") {
+			fn.ret = p.parseType()
+		}
+		tk := p.name()
+		fn.name = tk.text
+		if seen[fn.name] {
+			p.errorf(tk, "%s.%s redefined", d.name, fn.name)
+		}
+		seen[fn.name] = true
+		p.expect("(")
+		fn.args = p.parseFields(")", d.name+"."+fn.name)
+		if p.accept("throws") {
+			p.expect("(")
+			fn.throws = p.parseFields(")", d.name+"."+fn.name)
+		}
+		p.annotations()
+		p.separator()
+		d.funcs = append(d.funcs, fn)
+	}
+	p.annotations()
+	p.define(d, name)
+}
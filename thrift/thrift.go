@@ -0,0 +1,584 @@
+// Package thrift 把 thrift IDL 转换为 jce
+//
+//	include          #include，module 为文件名
+//	struct           struct，字段编号作为 tag，没有指定 required 的字段为 optional
+//	enum             enum，所有成员写出取值
+//	const            const，只支持 jce 的基本类型
+//	typedef          展开为原来的类型
+//	service          interface
+//	i16/i32/i64      short/int/long
+//	binary           vector<byte>
+//	list<T>/set<T>   vector<T>
+//
+// 没有对应写法的内容转换后给出警告：set、exception、union、typedef、容器类型的常量和默认值、
+// oneway、throws、extends 以及注解。字段没有编号、编号超过 255、使用 jce 关键字等内容直接报错
+package thrift
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/erpc-go/jce2go/format"
+	"github.com/erpc-go/jce2go/lang"
+	"github.com/erpc-go/jce2go/lex"
+	"github.com/erpc-go/jce2go/plugin"
+	"github.com/erpc-go/jce2go/version"
+)
+
+// Warning 没有对应写法的内容
+type Warning struct {
+	File    string
+	Message string
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("%s: %s", w.File, w.Message)
+}
+
+// Import 把 .thrift 文件以及它 include 的文件转换为同名的 .jce 文件，输出经过格式化。
+// include 的文件先在当前文件所在的目录查找，再在 includePaths 中查找
+func Import(filename string, includePaths []string) ([]plugin.File, []Warning, error) {
+	im := &importer{paths: includePaths, files: map[string]*file{}}
+	root, err := im.load(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []plugin.File
+	seen := map[string]string{}
+	var visit func(f *file) error
+	visit = func(f *file) error {
+		if prev, ok := seen[f.out]; ok {
+			if prev != f.path {
+				return fmt.Errorf("%s and %s both generate %s", prev, f.path, f.out)
+			}
+			return nil
+		}
+		seen[f.out] = f.path
+		for _, dep := range f.deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		src, err := im.gen(f)
+		if err != nil {
+			return err
+		}
+		files = append(files, plugin.File{Name: f.out, Content: string(src)})
+		return nil
+	}
+	if err := visit(root); err != nil {
+		return nil, nil, err
+	}
+	return files, im.warnings, nil
+}
+
+// thrift 文件
+type file struct {
+	path     string
+	out      string // 输出的 .jce 文件名
+	module   string // 文件名，引用其他文件中的类型时作为前缀
+	includes []string
+	deps     []*file
+	defs     []*def
+	names    map[string]*def
+}
+
+// 定义的种类
+const (
+	defEnum = iota
+	defStruct
+	defConst
+	defTypedef
+	defService
+)
+
+// 一个定义
+type def struct {
+	kind    int
+	keyword string // struct、union、exception 等源码中的关键字
+	name    string
+	comment string
+	line    int
+	file    *file
+
+	fields  []*field    // struct 的字段
+	values  []*enumItem // enum 的成员
+	typ     *typ        // const、typedef 的类型
+	value   *value      // const 的值
+	funcs   []*function // service 的方法
+	extends string
+}
+
+type typ struct {
+	name   string // 基本类型、list、set、map 或者定义的名字
+	params []*typ
+	line   int
+}
+
+func (t *typ) String() string {
+	if len(t.params) == 0 {
+		return t.name
+	}
+	var ps []string
+	for _, p := range t.params {
+		ps = append(ps, p.String())
+	}
+	return t.name + "<" + strings.Join(ps, ", ") + ">"
+}
+
+// 常量的值，list、map 只记录源码
+type value struct {
+	text      string
+	str       bool
+	container bool
+}
+
+type field struct {
+	id       int
+	hasID    bool
+	required bool
+	typ      *typ
+	name     string
+	value    *value
+	comment  string
+	line     int
+}
+
+type enumItem struct {
+	name    string
+	value   int64
+	comment string
+}
+
+type function struct {
+	name    string
+	ret     *typ // void 时为 nil
+	oneway  bool
+	args    []*field
+	throws  []*field
+	comment string
+	line    int
+}
+
+type importer struct {
+	paths    []string
+	files    map[string]*file
+	loading  []string
+	warnings []Warning
+}
+
+func (im *importer) warn(f *file, format string, args ...interface{}) {
+	im.warnings = append(im.warnings, Warning{File: f.path, Message: fmt.Sprintf(format, args...)})
+}
+
+// 查找 include 的文件
+func (im *importer) resolve(name, dir string) string {
+	for _, p := range append([]string{dir}, im.paths...) {
+		full := filepath.Join(p, name)
+		if _, err := os.Stat(full); err == nil {
+			return full
+		}
+	}
+	return filepath.Join(dir, name)
+}
+
+func (im *importer) load(path string) (*file, error) {
+	path = filepath.Clean(path)
+	if f, ok := im.files[path]; ok {
+		return f, nil
+	}
+	for _, l := range im.loading {
+		if l == path {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(im.loading, path), " -> "))
+		}
+	}
+
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parse(im, path, src)
+	if err != nil {
+		return nil, err
+	}
+
+	im.loading = append(im.loading, path)
+	for _, inc := range f.includes {
+		dep, err := im.load(im.resolve(inc, filepath.Dir(path)))
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range f.deps {
+			if d.module == dep.module {
+				return nil, fmt.Errorf("%s: %s and %s have the same name", path, d.path, dep.path)
+			}
+		}
+		f.deps = append(f.deps, dep)
+	}
+	im.loading = im.loading[:len(im.loading)-1]
+
+	im.files[path] = f
+	return f, nil
+}
+
+// 查找引用的定义，name 为 Name 或者 file.Name
+func (im *importer) lookup(f *file, name string) *def {
+	if d, ok := f.names[name]; ok {
+		return d
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		for _, dep := range f.deps {
+			if dep.module == name[:i] {
+				return dep.names[name[i+1:]]
+			}
+		}
+	}
+	return nil
+}
+
+// thrift 的基本类型对应的 jce 类型
+var scalars = map[string]string{
+	"bool":   "bool",
+	"byte":   "byte",
+	"i8":     "byte",
+	"i16":    "short",
+	"i32":    "int",
+	"i64":    "long",
+	"double": "double",
+	"string": "string",
+	"binary": "vector<byte>",
+}
+
+// 生成一个文件时的状态
+type generator struct {
+	im   *importer
+	f    *file
+	refs map[*def]bool // 当前 struct 引用的同一文件中的 struct
+	// 已经给出警告的内容，同样的警告只给出一次
+	warned map[string]bool
+}
+
+func (g *generator) warn(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !g.warned[msg] {
+		g.warned[msg] = true
+		g.im.warn(g.f, "%s", msg)
+	}
+}
+
+// 类型的 jce 写法，in 为类型所在的文件，where 用于警告和报错
+func (g *generator) jceType(in *file, t *typ, where string) (string, error) {
+	if s, ok := scalars[t.name]; ok {
+		return s, nil
+	}
+	switch t.name {
+	case "list", "set":
+		if t.name == "set" {
+			g.warn("%s: set<%s> converted to vector, uniqueness is not kept", where, t.params[0])
+		}
+		elem, err := g.jceType(in, t.params[0], where)
+		if err != nil {
+			return "", err
+		}
+		return "vector<" + elem + ">", nil
+	case "map":
+		key, err := g.jceType(in, t.params[0], where)
+		if err != nil {
+			return "", err
+		}
+		val, err := g.jceType(in, t.params[1], where)
+		if err != nil {
+			return "", err
+		}
+		return "map<" + key + ", " + val + ">", nil
+	}
+
+	d := g.im.lookup(in, t.name)
+	if d == nil {
+		return "", fmt.Errorf("%s:%d: type %s not found", in.path, t.line, t.name)
+	}
+	switch d.kind {
+	case defTypedef:
+		// typedef 中的名字在定义 typedef 的文件中查找
+		return g.jceType(d.file, d.typ, where)
+	case defEnum, defStruct:
+		if d.file == g.f {
+			if d.kind == defStruct {
+				g.refs[d] = true
+			}
+			return d.name, nil
+		}
+		return d.file.module + "::" + d.name, nil
+	}
+	return "", fmt.Errorf("%s:%d: %s is not a type", in.path, t.line, t.name)
+}
+
+// 展开 typedef 后的类型，以及类型为 enum、struct 时的定义
+func (g *generator) underlying(t *typ) (*typ, *def) {
+	in := g.f
+	for {
+		if _, ok := scalars[t.name]; ok || len(t.params) > 0 {
+			return t, nil
+		}
+		d := g.im.lookup(in, t.name)
+		if d == nil || d.kind != defTypedef {
+			return t, d
+		}
+		t, in = d.typ, d.file
+	}
+}
+
+// 常量、默认值的 jce 写法，不能转换时返回空
+func (g *generator) jceValue(t *typ, v *value) string {
+	if v.container {
+		return ""
+	}
+	base, d := g.underlying(t)
+	if v.str {
+		if base.name == "string" {
+			return strconv.Quote(v.text)
+		}
+		return ""
+	}
+
+	// 引用了常量或者枚举成员
+	if ref, ok := g.constRef(v.text); ok {
+		return g.jceValue(t, ref)
+	}
+	if d != nil && d.kind == defEnum {
+		name := v.text
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			name = name[i+1:]
+		}
+		for _, item := range d.values {
+			if item.name == name {
+				if d.file != g.f {
+					return d.file.module + "::" + name
+				}
+				return name
+			}
+		}
+		if _, err := strconv.ParseInt(v.text, 0, 32); err == nil {
+			return v.text
+		}
+		return ""
+	}
+
+	switch base.name {
+	case "bool":
+		switch v.text {
+		case "true", "1":
+			return "true"
+		case "false", "0":
+			return "false"
+		}
+	case "byte", "i8", "i16", "i32", "i64":
+		if _, err := strconv.ParseInt(v.text, 0, 64); err == nil {
+			return v.text
+		}
+	case "double":
+		if _, err := strconv.ParseFloat(v.text, 64); err == nil {
+			return v.text
+		}
+	}
+	return ""
+}
+
+// 查找常量的值
+func (g *generator) constRef(name string) (*value, bool) {
+	d := g.im.lookup(g.f, name)
+	if d == nil || d.kind != defConst {
+		return nil, false
+	}
+	return d.value, true
+}
+
+// 生成 jce 源码并格式化
+func (im *importer) gen(f *file) ([]byte, error) {
+	g := &generator{im: im, f: f, refs: map[*def]bool{}, warned: map[string]bool{}}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// code generated by jce2go %s.\n// source: %s\n\n", version.VERSION, filepath.Base(f.path))
+	for _, dep := range f.deps {
+		fmt.Fprintf(&b, "#include \"%s\"\n", dep.out)
+	}
+	if len(f.deps) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "module %s\n{\n", f.module)
+
+	for _, d := range f.defs {
+		switch d.kind {
+		case defTypedef:
+			g.warn("typedef %s inlined as %s", d.name, d.typ)
+		case defConst:
+			b.WriteString(lang.Comment("// ", d.comment))
+			base, _ := g.underlying(d.typ)
+			val := g.jceValue(d.typ, d.value)
+			typ, ok := scalars[base.name]
+			if !ok || val == "" || base.name == "binary" {
+				g.warn("const %s %s has no jce equivalent, kept as a comment", d.typ, d.name)
+				fmt.Fprintf(&b, "// const %s %s = %s;\n\n", d.typ, d.name, d.value.text)
+				continue
+			}
+			fmt.Fprintf(&b, "const %s %s = %s;\n\n", typ, d.name, val)
+		case defEnum:
+			b.WriteString(lang.Comment("// ", d.comment))
+			fmt.Fprintf(&b, "enum %s\n{\n", d.name)
+			for _, v := range d.values {
+				b.WriteString(lang.Comment("// ", v.comment))
+				fmt.Fprintf(&b, "%s = %d,\n", v.name, v.value)
+			}
+			b.WriteString("};\n\n")
+		}
+	}
+
+	// jce 的类型需要先定义后使用，struct 按依赖排序
+	bodies := map[*def]string{}
+	deps := map[*def]map[*def]bool{}
+	var structs []*def
+	for _, d := range f.defs {
+		if d.kind != defStruct {
+			continue
+		}
+		switch d.keyword {
+		case "exception":
+			g.warn("exception %s converted to struct", d.name)
+		case "union":
+			g.warn("union %s converted to struct, only one field set is not enforced", d.name)
+		}
+		structs = append(structs, d)
+		g.refs = map[*def]bool{}
+		var body bytes.Buffer
+		body.WriteString(lang.Comment("// ", d.comment))
+		fmt.Fprintf(&body, "struct %s\n{\n", d.name)
+		for _, fd := range d.fields {
+			where := d.name + "." + fd.name
+			typ, err := g.jceType(f, fd.typ, where)
+			if err != nil {
+				return nil, err
+			}
+			req := "optional"
+			if fd.required && d.keyword != "union" {
+				req = "require"
+			}
+			body.WriteString(lang.Comment("// ", fd.comment))
+			fmt.Fprintf(&body, "%d %s %s %s", fd.id, req, typ, fd.name)
+			if fd.value != nil {
+				if val := g.jceValue(fd.typ, fd.value); val != "" {
+					fmt.Fprintf(&body, " = %s", val)
+				} else {
+					g.warn("%s: default value %s dropped", where, fd.value.text)
+				}
+			}
+			body.WriteString(";\n")
+		}
+		body.WriteString("};\n\n")
+		bodies[d] = body.String()
+		deps[d] = g.refs
+	}
+
+	done := map[*def]bool{}
+	visiting := map[*def]bool{}
+	var emit func(d *def) error
+	emit = func(d *def) error {
+		if done[d] {
+			return nil
+		}
+		if visiting[d] {
+			return fmt.Errorf("%s:%d: %s is recursive, which jce does not support", f.path, d.line, d.name)
+		}
+		visiting[d] = true
+		var refs []*def
+		for ref := range deps[d] {
+			refs = append(refs, ref)
+		}
+		sort.Slice(refs, func(i, j int) bool { return refs[i].line < refs[j].line })
+		for _, ref := range refs {
+			if err := emit(ref); err != nil {
+				return err
+			}
+		}
+		done[d] = true
+		b.WriteString(bodies[d])
+		return nil
+	}
+	for _, d := range structs {
+		if err := emit(d); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, d := range f.defs {
+		if d.kind != defService {
+			continue
+		}
+		if err := g.service(&b, d); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("};\n")
+
+	return format.Source(f.out, b.Bytes())
+}
+
+func (g *generator) service(b *bytes.Buffer, d *def) error {
+	if d.extends != "" {
+		g.warn("service %s extends %s dropped, methods of %s are not included", d.name, d.extends, d.extends)
+	}
+	b.WriteString(lang.Comment("// ", d.comment))
+	fmt.Fprintf(b, "interface %s\n{\n", d.name)
+	for _, fn := range d.funcs {
+		where := d.name + "." + fn.name
+		if fn.oneway {
+			g.warn("%s: oneway dropped", where)
+		}
+		if len(fn.throws) > 0 {
+			g.warn("%s: throws dropped", where)
+		}
+		ret := "void"
+		if fn.ret != nil {
+			s, err := g.jceType(g.f, fn.ret, where)
+			if err != nil {
+				return err
+			}
+			ret = s
+		}
+		var args []string
+		for _, arg := range fn.args {
+			s, err := g.jceType(g.f, arg.typ, where)
+			if err != nil {
+				return err
+			}
+			if arg.value != nil {
+				g.warn("%s: default value of argument %s dropped", where, arg.name)
+			}
+			args = append(args, s+" "+arg.name)
+		}
+		b.WriteString(lang.Comment("// ", fn.comment))
+		fmt.Fprintf(b, "%s %s(%s);\n", ret, fn.name, strings.Join(args, ", "))
+	}
+	b.WriteString("};\n\n")
+	return nil
+}
+
+// jce 的关键字和类型名，不能作为名字
+func isJceKeyword(name string) bool {
+	for i := lex.TkDummyKeywordBegin + 1; i < lex.TkDummyKeywordEnd; i++ {
+		if lex.TokenMap[i] == name {
+			return true
+		}
+	}
+	for i := lex.TkDummyTypeBegin + 1; i < lex.TkDummyTypeEnd; i++ {
+		if lex.TokenMap[i] == name {
+			return true
+		}
+	}
+	return false
+}
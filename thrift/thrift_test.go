@@ -0,0 +1,147 @@
+package thrift
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/erpc-go/jce2go/parser"
+)
+
+const sharedThrift = `namespace go shared
+
+typedef i64 Timestamp
+
+struct Page {
+  1: i32 offset,
+  2: i32 limit = 20,
+}
+`
+
+const userThrift = `include "shared.thrift"
+
+const i32 MAX_USERS = 100
+const list<string> TAGS = ["a", "b"]
+
+typedef map<string, string> Attrs
+
+/** 状态 */
+enum Status {
+  UNKNOWN,
+  ACTIVE = 2,
+  BANNED
+}
+
+// 用户
+struct User {
+  // 编号
+  1: required i64 id
+  2: optional string name = "x"
+  3: set<string> tags
+  4: Attrs attrs
+  5: Status status = Status.ACTIVE
+  6: binary avatar
+  7: shared.Timestamp created
+  8: list<Address> addrs
+  9: i16 age (go.tag = "json")
+}
+
+struct Address {
+  1: string city
+}
+
+exception NotFound {
+  1: string message
+}
+
+service UserService {
+  User get(1: i64 id, 2: shared.Page page) throws (1: NotFound nf),
+  oneway void ping(),
+}
+`
+
+func TestImport(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) string {
+		full := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(full, []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		return full
+	}
+	write("shared.thrift", sharedThrift)
+	files, warnings, err := Import(write("user.thrift", userThrift), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Name != "shared.jce" || files[1].Name != "user.jce" {
+		t.Fatalf("unexpected files %v", files)
+	}
+
+	// 生成的 jce 可以被解析
+	for _, f := range files {
+		write(f.Name, f.Content)
+	}
+	p, err := parser.ParseSource(filepath.Join(dir, "user.jce"), []byte(files[1].Content))
+	if err != nil {
+		t.Fatalf("%v:\n%s", err, files[1].Content)
+	}
+	if len(p.Interfaces) != 1 || len(p.Interfaces[0].Funcs) != 2 {
+		t.Fatalf("unexpected interfaces %+v", p.Interfaces)
+	}
+
+	code := files[1].Content
+	for _, s := range []string{
+		`#include "shared.jce"`,
+		"module user",
+		"const int MAX_USERS = 100;",
+		`// const list<string> TAGS = ["a", "b"];`,
+		"// 状态\n    enum Status",
+		"UNKNOWN = 0,",
+		"BANNED = 3,",
+		"// 编号\n        1 require  long",
+		`string              name = "x";`,
+		"3 optional vector<string>",
+		"4 optional map<string, string>",
+		"Status              status = ACTIVE;",
+		"6 optional vector<byte>",
+		"7 optional long",
+		"9 optional short",
+		"User get(long id, shared::Page page);",
+		"void ping();",
+	} {
+		if !strings.Contains(code, s) {
+			t.Errorf("missing %q in:\n%s", s, code)
+		}
+	}
+	if strings.Index(code, "struct Address") > strings.Index(code, "struct User") {
+		t.Errorf("Address should be defined before User:\n%s", code)
+	}
+
+	var msgs []string
+	for _, w := range warnings {
+		msgs = append(msgs, w.String())
+	}
+	all := strings.Join(msgs, "\n")
+	for _, s := range []string{"set<string> converted to vector", "exception NotFound", "typedef Timestamp", "TAGS", "throws", "oneway", "annotations"} {
+		if !strings.Contains(all, s) {
+			t.Errorf("missing warning %q in:\n%s", s, all)
+		}
+	}
+
+	for _, c := range []struct{ src, err string }{
+		{`struct A { i32 a }`, "field id is required"},
+		{`struct A { 256: i32 a }`, "out of jce tag range"},
+		{`struct A { 1: i32 struct }`, "jce keyword"},
+		{`struct A { 1: B b }`, "B not found"},
+		{`struct A { 1: A a }`, "recursive"},
+		{`struct A { 1: i32 a, 1: i32 b }`, "field id 1"},
+		{`senum S { "a" }`, "senum"},
+	} {
+		_, _, err := Import(write("bad.thrift", c.src), nil)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: expect error %q, got %v", c.src, c.err, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	if d := UnifiedDiff("a", "b", []byte("x\n"), []byte("x\n")); d != "" {
+		t.Errorf("expected no diff, got %q", d)
+	}
+
+	var a, b []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprint(i))
+		b = append(b, fmt.Sprint(i))
+	}
+	b[1] = "two"                  // 第 2 行修改
+	b = append(b[:15], b[16:]...) // 删除第 16 行
+	b = append(b, "21")           // 末尾增加一行
+	want := `--- a
++++ b
+@@ -1,5 +1,5 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
+@@ -13,8 +13,8 @@
+ 13
+ 14
+ 15
+-16
+ 17
+ 18
+ 19
+ 20
++21
+`
+	got := UnifiedDiff("a", "b", []byte(strings.Join(a, "\n")+"\n"), []byte(strings.Join(b, "\n")+"\n"))
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got = UnifiedDiff("a", "b", []byte("x"), []byte("x\n"))
+	if want := "--- a\n+++ b\n@@ -1,1 +1,1 @@\n-x\n\\ No newline at end of file\n+x\n"; got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
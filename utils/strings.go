@@ -24,3 +24,18 @@ func Path2PackageName(path string, suffix string) string {
 
 	return path[iBegin:iEnd]
 }
+
+// TrimComment 去掉注释的 //、/* */ 标记和首尾空白，多行注释按行拼接，去掉空行
+func TrimComment(comment string) string {
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "//")
+		line = strings.TrimPrefix(line, "/*")
+		line = strings.TrimSuffix(line, "*/")
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erpc-go/jce2go/log"
+	"github.com/erpc-go/jce2go/parser"
+)
+
+// watch 模式轮询的间隔，以及检测到修改后等待连续的保存结束的时间
+const (
+	watchInterval = 500 * time.Millisecond
+	watchDebounce = 300 * time.Millisecond
+)
+
+// 文件的状态，修改时间或者大小变化时认为文件被修改
+type fileState struct {
+	modTime int64
+	size    int64
+	exists  bool
+}
+
+func statFile(name string) fileState {
+	fi, err := os.Stat(name)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: fi.ModTime().UnixNano(), size: fi.Size(), exists: true}
+}
+
+// watcher 轮询输入文件以及它们 include 的所有文件，只使用标准库。
+// 同一个文件在命令行和 include 中的写法可能不同（如 base.jce 和 ./base.jce），除 inputs 外都使用 filepath.Clean 后的路径
+type watcher struct {
+	inputs   []string
+	includes map[string][]string // 文件直接 include 的文件，解析失败时保留上一次的结果
+	states   map[string]fileState
+	pending  []string // 上一次生成失败时修改的文件，下一次修改时一起重新生成
+}
+
+func newWatcher(inputs []string) *watcher {
+	w := &watcher{inputs: inputs, includes: map[string][]string{}, states: map[string]fileState{}}
+	w.scan()
+	for _, f := range w.files() {
+		w.states[f] = statFile(f)
+	}
+	return w
+}
+
+// 重新解析输入文件，更新 include 关系
+func (w *watcher) scan() {
+	for _, f := range w.inputs {
+		if path.Ext(f) != ".jce" {
+			continue
+		}
+		if p := parseQuiet(f); p != nil {
+			w.addIncludes(p)
+		}
+	}
+}
+
+// 解析文件，出错时返回 nil，错误在重新生成时输出
+func parseQuiet(filename string) (p *parser.Parser) {
+	defer func() {
+		if recover() != nil {
+			p = nil
+		}
+	}()
+	ps, err := parseInputs([]string{filename})
+	if err != nil {
+		return nil
+	}
+	return ps[0]
+}
+
+func (w *watcher) addIncludes(p *parser.Parser) {
+	var incs []string
+	for _, inc := range p.IncParse {
+		incs = append(incs, filepath.Clean(inc.Filepath))
+		w.addIncludes(inc)
+	}
+	w.includes[filepath.Clean(p.Filepath)] = incs
+}
+
+// 输入文件以及它们直接、间接 include 的所有文件
+func (w *watcher) files() []string {
+	seen := map[string]bool{}
+	var files []string
+	var visit func(f string)
+	visit = func(f string) {
+		if seen[f] {
+			return
+		}
+		seen[f] = true
+		files = append(files, f)
+		for _, inc := range w.includes[f] {
+			visit(inc)
+		}
+	}
+	for _, f := range w.inputs {
+		visit(filepath.Clean(f))
+	}
+	return files
+}
+
+// 修改过的文件，同时更新记录的状态
+func (w *watcher) changed() []string {
+	var changed []string
+	for _, f := range w.files() {
+		st := statFile(f)
+		if old, ok := w.states[f]; !ok || old != st {
+			w.states[f] = st
+			changed = append(changed, f)
+		}
+	}
+	return changed
+}
+
+// 受 changed 影响的输入文件：一个文件受影响当且仅当它或者它 include 的文件被修改
+func (w *watcher) affected(changed []string) (inputs []string) {
+	memo := map[string]bool{}
+	for _, f := range changed {
+		memo[f] = true
+	}
+	var visit func(f string, seen map[string]bool) bool
+	visit = func(f string, seen map[string]bool) bool {
+		if v, ok := memo[f]; ok || seen[f] {
+			return v
+		}
+		seen[f] = true
+		for _, inc := range w.includes[f] {
+			if visit(inc, seen) {
+				memo[f] = true
+				return true
+			}
+		}
+		memo[f] = false
+		return false
+	}
+
+	for _, f := range w.inputs {
+		if visit(filepath.Clean(f), map[string]bool{}) || path.Ext(f) != ".jce" {
+			inputs = append(inputs, f)
+		}
+	}
+	return inputs
+}
+
+// run 一直运行，文件修改后调用 regen 重新生成受影响的输出，出错时输出错误并继续监视
+func (w *watcher) run(regen func(files []string) error) {
+	log.Raw("[watch]watching %d files, press Ctrl+C to stop\n", len(w.files()))
+	for {
+		time.Sleep(watchInterval)
+		changed := w.changed()
+		if len(changed) == 0 {
+			continue
+		}
+		// 编辑器保存时可能连续写入多次，等到一段时间内没有新的修改再生成
+		for {
+			time.Sleep(watchDebounce)
+			more := w.changed()
+			if len(more) == 0 {
+				break
+			}
+			changed = append(changed, more...)
+		}
+
+		// include 关系可能改变，新 include 的文件也需要生成
+		w.scan()
+		changed = append(changed, w.changed()...)
+		changed = unique(append(changed, w.pending...))
+
+		log.Raw("[watch]changed: %s\n", strings.Join(changed, ", "))
+		if err := safeRegen(regen, w.affected(changed)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			w.pending = changed
+			continue
+		}
+		w.pending = nil
+		log.Raw("[watch]done\n")
+	}
+}
+
+// 调用 regen，把 panic（如解析时非 *lex.Error 的 panic）转换为错误，避免退出监视
+func safeRegen(regen func(files []string) error, files []string) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	return regen(files)
+}
+
+func unique(list []string) []string {
+	sort.Strings(list)
+	ret := list[:0]
+	for i, s := range list {
+		if i == 0 || s != list[i-1] {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
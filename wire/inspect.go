@@ -0,0 +1,249 @@
+package wire
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// 不依赖 schema 解析 jce 数据，只能得到 tag、type 和原始取值，用于排查不知道类型或者已经损坏的数据
+
+// Node 解析出的一个字段
+type Node struct {
+	Offset   int         // head 的起始偏移
+	Data     int         // data 的起始偏移，容器类型为长度之后第一个元素的偏移
+	End      int         // 字段的结束偏移（不含）
+	Type     Type        //
+	Tag      int         //
+	Length   int         // String、List、Map、SimpleList 的长度
+	Value    interface{} // 整数为 int64，浮点为 float64，String 为 string，SimpleList 为 []byte
+	Children []*Node     // StructBegin 的成员，List 的元素，Map 交替的 key、value
+}
+
+// Inspect 解析 data 中所有的字段。出错时返回已经解析出的部分，
+// 出错的字段及其所有上层字段的 End 为出错位置，错误为 *Error
+func Inspect(data []byte) ([]*Node, error) {
+	r := NewReader(data)
+	var nodes []*Node
+	for r.Len() > 0 {
+		n, err := inspectField(r, 0)
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+		if err != nil {
+			return nodes, err
+		}
+		if n.Type == StructEnd {
+			return nodes, &Error{Offset: n.Offset, Msg: "unexpected StructEnd without StructBegin"}
+		}
+	}
+	return nodes, nil
+}
+
+// 嵌套过深的数据一定是坏数据，避免栈溢出
+const maxDepth = 100
+
+func inspectField(r *Reader, depth int) (*Node, error) {
+	start := r.Offset()
+	ty, tag, err := r.ReadHead()
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{Offset: start, Type: ty, Tag: tag, Data: r.Offset()}
+	if depth > maxDepth {
+		n.End = r.Offset()
+		return n, &Error{Offset: start, Msg: "nesting too deep"}
+	}
+
+	fail := func(err error) (*Node, error) {
+		n.End = r.Offset()
+		return n, err
+	}
+
+	switch ty {
+	case ZeroTag, StructEnd:
+		if ty == ZeroTag {
+			n.Value = int64(0)
+		}
+	case Int1, Int2, Int4, Int8:
+		if n.Value, err = r.ReadInt(ty); err != nil {
+			return fail(err)
+		}
+	case Float, Double:
+		if n.Value, err = r.ReadFloat(ty); err != nil {
+			return fail(err)
+		}
+	case String1, String4:
+		s, err := r.ReadString(ty)
+		if err != nil {
+			return fail(err)
+		}
+		n.Length, n.Value = len(s), s
+	case SimpleList:
+		l, err := r.ReadLength()
+		if err != nil {
+			return fail(err)
+		}
+		n.Length, n.Data = int(l), r.Offset()
+		if n.Value, err = r.ReadBytes(int(l)); err != nil {
+			return fail(err)
+		}
+	case List, Map:
+		l, err := r.ReadLength()
+		if err != nil {
+			return fail(err)
+		}
+		n.Length, n.Data = int(l), r.Offset()
+		count := int(l)
+		if ty == Map {
+			count *= 2
+		}
+		for i := 0; i < count; i++ {
+			child, err := inspectField(r, depth+1)
+			if child != nil {
+				n.Children = append(n.Children, child)
+			}
+			if err != nil {
+				return fail(err)
+			}
+			if child.Type == StructEnd {
+				return fail(&Error{Offset: child.Offset, Msg: "unexpected StructEnd in " + ty.String()})
+			}
+			want := 0
+			if ty == Map {
+				want = i % 2
+			}
+			if child.Tag != want {
+				return fail(&Error{Offset: child.Offset, Msg: fmt.Sprintf("element tag %d, expect %d", child.Tag, want)})
+			}
+		}
+	case StructBegin:
+		for {
+			if r.Len() == 0 {
+				return fail(&Error{Offset: r.Offset(), Msg: "unexpected end of data, StructEnd not found"})
+			}
+			child, err := inspectField(r, depth+1)
+			if child != nil {
+				n.Children = append(n.Children, child)
+			}
+			if err != nil {
+				return fail(err)
+			}
+			if child.Type == StructEnd {
+				break
+			}
+		}
+	}
+	n.End = r.Offset()
+	return n, nil
+}
+
+// Dump 解析 data 并输出带偏移和十六进制内容的字段树，出错时在最后输出出错位置附近的数据
+func Dump(w io.Writer, data []byte) error {
+	nodes, err := Inspect(data)
+	for _, n := range nodes {
+		dumpNode(w, data, n, 0)
+	}
+	if err != nil {
+		e, ok := err.(*Error)
+		if !ok {
+			return err
+		}
+		fmt.Fprintf(w, "\nmalformed at offset %d (0x%x): %s\n", e.Offset, e.Offset, e.Msg)
+		dumpContext(w, data, e.Offset)
+	}
+	return err
+}
+
+// 每行最多显示的字节数
+const hexWidth = 16
+
+func dumpNode(w io.Writer, data []byte, n *Node, depth int) {
+	// 容器类型只显示 head 和长度，内容由子节点显示
+	end := n.End
+	if n.Type == List || n.Type == Map || n.Type == StructBegin {
+		end = n.Data
+	}
+	raw := data[n.Offset:end]
+	more := ""
+	if len(raw) > hexWidth {
+		raw, more = raw[:hexWidth-1], " .."
+	}
+
+	fmt.Fprintf(w, "%08x  %-*s  %s%s\n", n.Offset, hexWidth*3-1, hexBytes(raw)+more, strings.Repeat("  ", depth), describe(n))
+	for _, c := range n.Children {
+		dumpNode(w, data, c, depth+1)
+	}
+}
+
+func hexBytes(b []byte) string {
+	parts := make([]string, len(b))
+	for i, c := range b {
+		parts[i] = hex.EncodeToString([]byte{c})
+	}
+	return strings.Join(parts, " ")
+}
+
+func describe(n *Node) string {
+	s := "tag " + strconv.Itoa(n.Tag) + " " + n.Type.String()
+	switch n.Type {
+	case ZeroTag, Int1, Int2, Int4, Int8, Float, Double:
+		if n.Value != nil {
+			s += " " + fmt.Sprint(n.Value)
+		}
+	case String1, String4:
+		if n.Value != nil {
+			s += " " + strconv.Quote(n.Value.(string))
+		}
+	case SimpleList:
+		s += " len=" + strconv.Itoa(n.Length)
+		if b, ok := n.Value.([]byte); ok && printable(b) {
+			s += " " + strconv.Quote(string(b))
+		}
+	case List, Map:
+		s += " len=" + strconv.Itoa(n.Length)
+	}
+	return s
+}
+
+// 是否是可以直接显示的文本
+func printable(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// dumpContext 以 16 字节为一行输出出错位置前后的数据，并标出出错的字节
+func dumpContext(w io.Writer, data []byte, off int) {
+	row := off / hexWidth * hexWidth
+	from, to := row-2*hexWidth, row+2*hexWidth
+	if from < 0 {
+		from = 0
+	}
+	if to > len(data) {
+		to = len(data)
+	}
+
+	for line := from; line < to; line += hexWidth {
+		end := line + hexWidth
+		if end > len(data) {
+			end = len(data)
+		}
+		fmt.Fprintf(w, "%08x  %s\n", line, hexBytes(data[line:end]))
+		if line == row {
+			fmt.Fprintf(w, "%8s  %s^^\n", "", strings.Repeat(" ", (off-line)*3))
+		}
+	}
+	if off >= len(data) && off == row {
+		// 出错位置在数据末尾，且正好是新的一行
+		fmt.Fprintf(w, "%08x  ^^ (end of data)\n", off)
+	}
+}
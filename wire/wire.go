@@ -0,0 +1,412 @@
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// jce 二进制编码的基础读写，供不生成代码、直接按 schema 或者无 schema 解析数据的工具使用
+//
+// 每个字段由 head + data 组成：
+// ------------------------------------------------
+// | head(type、tag) |             data            |
+// |    1 or 2 B     |              ?              |
+// ------------------------------------------------
+// head 第一个字节高 4 位是 tag、低 4 位是 type，tag >= 15 时高 4 位为 15，第二个字节存 tag
+//
+// data 按 type 区分：
+// 1. Int1、Int2、Int4、Int8：大端整数，写入时按取值选择最小的类型，0 使用 ZeroTag 且没有 data
+// 2. Float、Double：大端 IEEE 754，0 使用 ZeroTag
+// 3. String1、String4：1B 或 4B 长度 + 内容
+// 4. List：4B 长度 + 元素，每个元素都是 tag 为 0 的字段
+// 5. Map：4B 长度 + key、value 交替，key 的 tag 为 0，value 的 tag 为 1
+// 6. SimpleList：4B 长度 + 字节内容，用于 vector<byte>
+// 7. StructBegin、StructEnd：结构体的开始和结束，中间是结构体的各个字段
+
+// Type 编码类型
+type Type byte
+
+const (
+	Int1 Type = iota
+	Int2
+	Int4
+	Int8
+	Float
+	Double
+	String1
+	String4
+	Map
+	List
+	StructBegin
+	StructEnd
+	ZeroTag
+	SimpleList
+)
+
+var typeNames = [...]string{
+	Int1:        "Int1",
+	Int2:        "Int2",
+	Int4:        "Int4",
+	Int8:        "Int8",
+	Float:       "Float",
+	Double:      "Double",
+	String1:     "String1",
+	String4:     "String4",
+	Map:         "Map",
+	List:        "List",
+	StructBegin: "StructBegin",
+	StructEnd:   "StructEnd",
+	ZeroTag:     "ZeroTag",
+	SimpleList:  "SimpleList",
+}
+
+func (t Type) String() string {
+	if int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("Type(%d)", byte(t))
+}
+
+// IsInt 是否为整数类型（包括 ZeroTag）
+func (t Type) IsInt() bool {
+	return t <= Int8 || t == ZeroTag
+}
+
+// Error 数据格式错误，Offset 为出错位置的字节偏移
+type Error struct {
+	Offset int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("offset %d: %s", e.Offset, e.Msg)
+}
+
+// Reader 从字节数组中读取 jce 数据
+type Reader struct {
+	buf []byte
+	off int
+}
+
+// NewReader 创建 Reader
+func NewReader(b []byte) *Reader {
+	return &Reader{buf: b}
+}
+
+// Offset 当前读取位置
+func (r *Reader) Offset() int {
+	return r.off
+}
+
+// Len 剩余未读取的字节数
+func (r *Reader) Len() int {
+	return len(r.buf) - r.off
+}
+
+func (r *Reader) errorf(off int, format string, args ...interface{}) error {
+	return &Error{Offset: off, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (r *Reader) next(n int) ([]byte, error) {
+	if n < 0 || r.Len() < n {
+		return nil, r.errorf(r.off, "unexpected end of data, need %d bytes, have %d", n, r.Len())
+	}
+	b := r.buf[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+// ReadHead 读取 head，返回类型和 tag
+func (r *Reader) ReadHead() (Type, int, error) {
+	start := r.off
+	b, err := r.next(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	ty, tag := Type(b[0]&0x0f), int(b[0]>>4)
+	if tag == 15 {
+		if b, err = r.next(1); err != nil {
+			return 0, 0, err
+		}
+		tag = int(b[0])
+	}
+	if ty > SimpleList {
+		return 0, 0, r.errorf(start, "invalid type %d", byte(ty))
+	}
+	return ty, tag, nil
+}
+
+// PeekHead 读取 head 但不移动读取位置
+func (r *Reader) PeekHead() (Type, int, error) {
+	off := r.off
+	ty, tag, err := r.ReadHead()
+	r.off = off
+	return ty, tag, err
+}
+
+// ReadInt 读取整数类型的 data
+func (r *Reader) ReadInt(ty Type) (int64, error) {
+	switch ty {
+	case ZeroTag:
+		return 0, nil
+	case Int1:
+		b, err := r.next(1)
+		if err != nil {
+			return 0, err
+		}
+		return int64(int8(b[0])), nil
+	case Int2:
+		b, err := r.next(2)
+		if err != nil {
+			return 0, err
+		}
+		return int64(int16(binary.BigEndian.Uint16(b))), nil
+	case Int4:
+		b, err := r.next(4)
+		if err != nil {
+			return 0, err
+		}
+		return int64(int32(binary.BigEndian.Uint32(b))), nil
+	case Int8:
+		b, err := r.next(8)
+		if err != nil {
+			return 0, err
+		}
+		return int64(binary.BigEndian.Uint64(b)), nil
+	}
+	return 0, r.errorf(r.off, "type %s is not an integer", ty)
+}
+
+// ReadFloat 读取浮点类型的 data
+func (r *Reader) ReadFloat(ty Type) (float64, error) {
+	switch ty {
+	case ZeroTag:
+		return 0, nil
+	case Float:
+		b, err := r.next(4)
+		if err != nil {
+			return 0, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case Double:
+		b, err := r.next(8)
+		if err != nil {
+			return 0, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	}
+	return 0, r.errorf(r.off, "type %s is not a float", ty)
+}
+
+// ReadString 读取字符串类型的 data
+func (r *Reader) ReadString(ty Type) (string, error) {
+	var n int
+	switch ty {
+	case String1:
+		b, err := r.next(1)
+		if err != nil {
+			return "", err
+		}
+		n = int(b[0])
+	case String4:
+		l, err := r.ReadLength()
+		if err != nil {
+			return "", err
+		}
+		n = int(l)
+	default:
+		return "", r.errorf(r.off, "type %s is not a string", ty)
+	}
+
+	b, err := r.next(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ReadLength 读取 4B 长度
+func (r *Reader) ReadLength() (uint32, error) {
+	start := r.off
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	n := binary.BigEndian.Uint32(b)
+	if int64(n) > int64(r.Len()) {
+		// 每个元素至少 1B，长度超过剩余数据一定是坏数据，避免按长度分配大量内存
+		return 0, r.errorf(start, "length %d exceeds remaining %d bytes", n, r.Len())
+	}
+	return n, nil
+}
+
+// ReadBytes 读取 n 个字节
+func (r *Reader) ReadBytes(n int) ([]byte, error) {
+	return r.next(n)
+}
+
+// Skip 跳过一个指定类型的 data
+func (r *Reader) Skip(ty Type) error {
+	switch ty {
+	case ZeroTag, StructEnd:
+		return nil
+	case Int1, Int2, Int4, Int8:
+		_, err := r.ReadInt(ty)
+		return err
+	case Float, Double:
+		_, err := r.ReadFloat(ty)
+		return err
+	case String1, String4:
+		_, err := r.ReadString(ty)
+		return err
+	case SimpleList:
+		n, err := r.ReadLength()
+		if err != nil {
+			return err
+		}
+		_, err = r.next(int(n))
+		return err
+	case List, Map:
+		n, err := r.ReadLength()
+		if err != nil {
+			return err
+		}
+		if ty == Map {
+			n *= 2
+		}
+		for i := uint32(0); i < n; i++ {
+			if err = r.SkipField(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case StructBegin:
+		return r.SkipToStructEnd()
+	}
+	return r.errorf(r.off, "invalid type %d", byte(ty))
+}
+
+// SkipField 跳过一个完整的字段（head + data）
+func (r *Reader) SkipField() error {
+	ty, _, err := r.ReadHead()
+	if err != nil {
+		return err
+	}
+	return r.Skip(ty)
+}
+
+// SkipToStructEnd 跳过结构体剩余的字段，直到读到 StructEnd
+func (r *Reader) SkipToStructEnd() error {
+	for {
+		ty, _, err := r.ReadHead()
+		if err != nil {
+			return err
+		}
+		if ty == StructEnd {
+			return nil
+		}
+		if err = r.Skip(ty); err != nil {
+			return err
+		}
+	}
+}
+
+// Writer 把 jce 数据写入内存
+type Writer struct {
+	buf bytes.Buffer
+}
+
+// NewWriter 创建 Writer
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// Bytes 返回已写入的数据
+func (w *Writer) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+// WriteHead 写 head
+func (w *Writer) WriteHead(ty Type, tag int) {
+	if tag < 15 {
+		w.buf.WriteByte(byte(tag)<<4 | byte(ty))
+		return
+	}
+	w.buf.WriteByte(0xf0 | byte(ty))
+	w.buf.WriteByte(byte(tag))
+}
+
+// WriteInt 写整数，按取值选择最小的编码类型
+func (w *Writer) WriteInt(v int64, tag int) {
+	switch {
+	case v == 0:
+		w.WriteHead(ZeroTag, tag)
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		w.WriteHead(Int1, tag)
+		w.buf.WriteByte(byte(int8(v)))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		w.WriteHead(Int2, tag)
+		binary.Write(&w.buf, binary.BigEndian, int16(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		w.WriteHead(Int4, tag)
+		binary.Write(&w.buf, binary.BigEndian, int32(v))
+	default:
+		w.WriteHead(Int8, tag)
+		binary.Write(&w.buf, binary.BigEndian, v)
+	}
+}
+
+// WriteBool 写 bool，按整数 0、1 编码
+func (w *Writer) WriteBool(v bool, tag int) {
+	if v {
+		w.WriteInt(1, tag)
+		return
+	}
+	w.WriteInt(0, tag)
+}
+
+// WriteFloat 写 float
+func (w *Writer) WriteFloat(v float32, tag int) {
+	if v == 0 {
+		w.WriteHead(ZeroTag, tag)
+		return
+	}
+	w.WriteHead(Float, tag)
+	binary.Write(&w.buf, binary.BigEndian, math.Float32bits(v))
+}
+
+// WriteDouble 写 double
+func (w *Writer) WriteDouble(v float64, tag int) {
+	if v == 0 {
+		w.WriteHead(ZeroTag, tag)
+		return
+	}
+	w.WriteHead(Double, tag)
+	binary.Write(&w.buf, binary.BigEndian, math.Float64bits(v))
+}
+
+// WriteString 写字符串，长度不超过 255 时使用 String1
+func (w *Writer) WriteString(s string, tag int) {
+	if len(s) <= math.MaxUint8 {
+		w.WriteHead(String1, tag)
+		w.buf.WriteByte(byte(len(s)))
+	} else {
+		w.WriteHead(String4, tag)
+		w.WriteLength(uint32(len(s)))
+	}
+	w.buf.WriteString(s)
+}
+
+// WriteLength 写 4B 长度
+func (w *Writer) WriteLength(n uint32) {
+	binary.Write(&w.buf, binary.BigEndian, n)
+}
+
+// WriteSimpleList 写 vector<byte>
+func (w *Writer) WriteSimpleList(b []byte, tag int) {
+	w.WriteHead(SimpleList, tag)
+	w.WriteLength(uint32(len(b)))
+	w.buf.Write(b)
+}
@@ -0,0 +1,63 @@
+package wire
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	w := NewWriter()
+	for i, v := range []int64{0, -1, 300, 1 << 20, 1 << 40} {
+		w.WriteInt(v, i)
+	}
+	w.WriteString(strings.Repeat("a", 300), 20)
+
+	r := NewReader(w.Bytes())
+	for i, v := range []int64{0, -1, 300, 1 << 20, 1 << 40} {
+		ty, tag, err := r.ReadHead()
+		if err != nil || tag != i {
+			t.Fatalf("head %d: %v %d", i, err, tag)
+		}
+		if got, err := r.ReadInt(ty); err != nil || got != v {
+			t.Fatalf("int %d: got %d, %v", i, got, err)
+		}
+	}
+	ty, tag, _ := r.ReadHead()
+	if s, err := r.ReadString(ty); ty != String4 || tag != 20 || len(s) != 300 || err != nil {
+		t.Fatalf("string: %s %d %d %v", ty, tag, len(s), err)
+	}
+}
+
+func TestDump(t *testing.T) {
+	w := NewWriter()
+	w.WriteHead(StructBegin, 0)
+	w.WriteString("hi", 1)
+	w.WriteHead(List, 2)
+	w.WriteLength(1)
+	w.WriteInt(7, 0)
+	w.WriteHead(StructEnd, 0)
+
+	var out bytes.Buffer
+	if err := Dump(&out, w.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"00000000  0a", `tag 1 String1 "hi"`, "tag 2 List len=1", "      tag 0 Int1 7"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in\n%s", want, out.String())
+		}
+	}
+
+	// list 长度被改坏
+	data := w.Bytes()
+	data[len(data)-4] = 2
+	out.Reset()
+	err := Dump(&out, data)
+	e, ok := err.(*Error)
+	if !ok || e.Offset != len(data)-1 {
+		t.Fatalf("unexpected error %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "malformed at offset 12") {
+		t.Errorf("missing error offset in\n%s", out.String())
+	}
+}